@@ -1,5 +1,103 @@
-package todoliist
+package main
 
-func main(){
-	
-}
\ No newline at end of file
+import (
+	"botasks/internal/bot"
+	"botasks/internal/cli"
+	"botasks/internal/event"
+	"botasks/internal/reminder"
+	"botasks/internal/repository"
+	"botasks/internal/repository/eventsourced"
+	"botasks/internal/service"
+	"botasks/internal/telegram"
+	"botasks/internal/undo"
+	"context"
+	"fmt"
+	"os"
+)
+
+func main() {
+	actor := os.Getenv("USER")
+	if actor == "" {
+		actor = "cli"
+	}
+	ctx, cancel := context.WithCancel(service.ContextWithActor(context.Background(), actor))
+	defer cancel()
+
+	// Com BOTASKS_DATA, as tarefas ficam no log em arquivo; sem ele, em memória
+	var (
+		taskRepo     repository.TaskRepository
+		taskListRepo repository.TaskListRepository
+		uow          repository.UnitOfWorkFactory
+	)
+	dataPath := os.Getenv("BOTASKS_DATA")
+	if dataPath != "" {
+		log, err := eventsourced.OpenFileLog(dataPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer log.Close()
+		store, err := eventsourced.Open(ctx, log)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		taskRepo, taskListRepo, uow = store.Tasks(), store.TaskLists(), store
+	} else {
+		memoryTasks := repository.NewMemoryTaskRepository()
+		memoryLists := repository.NewMemoryTaskListRepository(memoryTasks)
+		taskRepo, taskListRepo, uow = memoryTasks, memoryLists, memoryLists
+	}
+	bus := event.NewBus()
+	defer bus.Close()
+
+	svc := service.NewTaskListService(taskListRepo, taskRepo,
+		service.WithUnitOfWork(uow),
+		service.WithEventPublisher(bus),
+	)
+	undoManager := undo.NewManager(svc, 100)
+	undoManager.Subscribe(bus)
+
+	// Lembretes vão para a saída padrão, a menos que tenham outro canal
+	notifiers := reminder.Router{"": reminder.NewWriterNotifier(os.Stdout)}
+	if url := os.Getenv("BOTASKS_REMINDER_WEBHOOK"); url != "" {
+		notifiers["webhook"] = reminder.NewWebhookNotifier(url, os.Getenv("BOTASKS_REMINDER_WEBHOOK_SECRET"), nil)
+	}
+	if dir := os.Getenv("BOTASKS_REMINDER_MAILDIR"); dir != "" {
+		notifiers["email"] = reminder.NewEmailNotifier(dir, os.Getenv("BOTASKS_REMINDER_FROM"))
+	}
+	var store reminder.Store = reminder.NewMemoryStore()
+	if path := os.Getenv("BOTASKS_REMINDERS"); path != "" {
+		// Lembretes em arquivo apontariam para tarefas que somem a cada reinício
+		if dataPath == "" {
+			fmt.Fprintln(os.Stderr, "BOTASKS_REMINDERS requires BOTASKS_DATA")
+			os.Exit(1)
+		}
+		fileStore, err := reminder.OpenFileStore(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		store = fileStore
+	}
+	scheduler := reminder.New(svc, store, notifiers)
+	scheduler.Subscribe(bus)
+
+	// Com um token, o bot do Telegram atende em paralelo ao modo interativo
+	if token := os.Getenv("BOTASKS_TELEGRAM_TOKEN"); token != "" {
+		adapter := telegram.New(token, bot.New(svc, bot.WithReminders(scheduler, "telegram")))
+		notifiers["telegram"] = bot.NewReminderNotifier(adapter)
+		go func() {
+			if err := adapter.Poll(ctx); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}()
+	}
+
+	go scheduler.Run(ctx)
+
+	if err := cli.New(svc, undoManager, os.Stdout, cli.WithReminders(scheduler)).Run(ctx, os.Stdin); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
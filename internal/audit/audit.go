@@ -0,0 +1,186 @@
+// Package audit registra cada alteração feita pelo TaskListService, com
+// autor, instante, operação e os valores anteriores e novos de cada campo.
+//
+// O Log assina o barramento de eventos e grava as entradas em um Store, que
+// só aceita inclusões.
+package audit
+
+import (
+	"botasks/internal/event"
+	"botasks/internal/list"
+	"botasks/internal/task"
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+// Tipos de entidade registrados nas entradas.
+const (
+	EntityTask = "task"
+	EntityList = "list"
+)
+
+// FieldChange descreve a mudança de um campo. Before ou After ficam vazios
+// quando o campo não existia antes ou deixou de existir.
+type FieldChange struct {
+	Field  string
+	Before string
+	After  string
+}
+
+// Entry é um registro de auditoria.
+type Entry struct {
+	Seq        int64 // atribuído pelo Store, crescente
+	At         time.Time
+	Actor      string
+	Operation  string // nome do evento, por exemplo "task.updated"
+	EntityType string
+	EntityID   string
+	ListID     string // lista relacionada, quando houver
+	Changes    []FieldChange
+}
+
+// Filter seleciona entradas do Store. Campos vazios não filtram; RelatedTo
+// seleciona entradas cujo EntityID ou ListID seja o informado.
+type Filter struct {
+	EntityType string
+	EntityID   string
+	RelatedTo  string
+}
+
+func (f Filter) Match(e Entry) bool {
+	if f.EntityType != "" && e.EntityType != f.EntityType {
+		return false
+	}
+	if f.EntityID != "" && e.EntityID != f.EntityID {
+		return false
+	}
+	if f.RelatedTo != "" && e.EntityID != f.RelatedTo && e.ListID != f.RelatedTo {
+		return false
+	}
+	return true
+}
+
+// Store persiste as entradas. Não há operações de alteração ou exclusão.
+type Store interface {
+	Append(ctx context.Context, e Entry) (Entry, error)
+	Entries(ctx context.Context, f Filter) ([]Entry, error)
+}
+
+// Log converte eventos de domínio em entradas de auditoria.
+type Log struct {
+	store Store
+}
+
+// NewLog cria um Log que grava no store informado.
+func NewLog(store Store) *Log {
+	return &Log{store: store}
+}
+
+// Subscribe inscreve o Log no barramento de forma síncrona, para que a
+// entrada seja gravada antes de a operação do serviço retornar.
+func (l *Log) Subscribe(bus *event.Bus) (unsubscribe func()) {
+	return bus.Subscribe(l.Handle)
+}
+
+// Handle grava a entrada correspondente ao evento. Pode ser usado
+// diretamente como event.Handler. A gravação ignora o cancelamento do
+// contexto: a operação já foi confirmada e precisa da sua entrada mesmo se
+// o cliente desistir da requisição.
+func (l *Log) Handle(ctx context.Context, e event.Event) {
+	entry, ok := entryFor(e)
+	if !ok {
+		return
+	}
+	if _, err := l.store.Append(context.WithoutCancel(ctx), entry); err != nil {
+		log.Printf("audit: failed to record %s %s: %v", e.Name(), e.AggregateID(), err)
+	}
+}
+
+// TaskHistory retorna as entradas da tarefa, da mais antiga para a mais recente.
+func (l *Log) TaskHistory(ctx context.Context, taskID string) ([]Entry, error) {
+	return l.store.Entries(ctx, Filter{RelatedTo: taskID, EntityType: EntityTask})
+}
+
+// ListHistory retorna as entradas da lista e das tarefas criadas, adicionadas
+// ou removidas dela, da mais antiga para a mais recente.
+func (l *Log) ListHistory(ctx context.Context, taskListID string) ([]Entry, error) {
+	return l.store.Entries(ctx, Filter{RelatedTo: taskListID})
+}
+
+func entryFor(e event.Event) (Entry, bool) {
+	meta := e.Metadata()
+	entry := Entry{At: meta.At, Actor: meta.Actor, Operation: e.Name(), EntityID: e.AggregateID()}
+
+	switch e := e.(type) {
+	case event.TaskCreated:
+		entry.EntityType = EntityTask
+		entry.ListID = e.ListID
+		entry.Changes = diffTask(task.Task{}, e.Task)
+	case event.TaskUpdated:
+		entry.EntityType = EntityTask
+		entry.Changes = diffTask(e.Before, e.After)
+	case event.TaskCompleted:
+		entry.EntityType = EntityTask
+		entry.Changes = diffTask(e.Before, e.After)
+	case event.TaskDeleted:
+		entry.EntityType = EntityTask
+		entry.Changes = diffTask(e.Task, task.Task{})
+	case event.ListCreated:
+		entry.EntityType = EntityList
+		entry.Changes = diffList(list.TaskList{}, e.List)
+	case event.ListRenamed:
+		entry.EntityType = EntityList
+		entry.Changes = diffList(e.Before, e.After)
+	case event.ListDeleted:
+		entry.EntityType = EntityList
+		entry.Changes = diffList(e.List, list.TaskList{})
+	case event.TaskAddedToList:
+		// Registrada como alteração da tarefa, com a lista relacionada, para
+		// aparecer tanto no histórico da tarefa quanto no da lista.
+		entry.EntityType = EntityTask
+		entry.EntityID = e.TaskID
+		entry.ListID = e.ListID
+		entry.Changes = []FieldChange{{Field: "ListID", After: e.ListID}}
+	case event.TaskRemovedFromList:
+		entry.EntityType = EntityTask
+		entry.EntityID = e.TaskID
+		entry.ListID = e.ListID
+		entry.Changes = []FieldChange{{Field: "ListID", Before: e.ListID}}
+	default:
+		return Entry{}, false
+	}
+	return entry, true
+}
+
+func diffTask(before, after task.Task) []FieldChange {
+	var changes []FieldChange
+	changes = appendChange(changes, "Title", before.Title, after.Title)
+	changes = appendChange(changes, "Description", before.Description, after.Description)
+	changes = appendChange(changes, "Deadline", formatTime(before.Deadline), formatTime(after.Deadline))
+	changes = appendChange(changes, "CompletedAt", formatTime(before.CompletedAt), formatTime(after.CompletedAt))
+	changes = appendChange(changes, "Priority", before.Priority, after.Priority)
+	changes = appendChange(changes, "Tags", strings.Join(before.Tags, ","), strings.Join(after.Tags, ","))
+	changes = appendChange(changes, "Recurrence", before.Recurrence, after.Recurrence)
+	changes = appendChange(changes, "ParentID", before.ParentID, after.ParentID)
+	return changes
+}
+
+func diffList(before, after list.TaskList) []FieldChange {
+	return appendChange(nil, "Name", before.Name, after.Name)
+}
+
+func appendChange(changes []FieldChange, field, before, after string) []FieldChange {
+	if before == after {
+		return changes
+	}
+	return append(changes, FieldChange{Field: field, Before: before, After: after})
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
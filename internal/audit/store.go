@@ -0,0 +1,145 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+)
+
+// MemoryStore guarda as entradas em memória.
+type MemoryStore struct {
+	entries []Entry
+	mu      sync.RWMutex
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) Append(ctx context.Context, e Entry) (Entry, error) {
+	if err := ctx.Err(); err != nil {
+		return Entry{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e.Seq = int64(len(s.entries)) + 1
+	s.entries = append(s.entries, e)
+	return e, nil
+}
+
+func (s *MemoryStore) Entries(ctx context.Context, f Filter) ([]Entry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []Entry
+	for _, e := range s.entries {
+		if f.Match(e) {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+// FileStore grava uma entrada JSON por linha em um arquivo aberto apenas
+// para inclusão (O_APPEND). Entradas existentes nunca são reescritas.
+type FileStore struct {
+	path string
+	file *os.File
+	seq  int64
+	mu   sync.Mutex
+}
+
+var _ Store = (*FileStore)(nil)
+
+// OpenFileStore abre (ou cria) o arquivo de auditoria no caminho informado.
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path}
+	entries, err := s.read(Filter{})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if n := len(entries); n > 0 {
+		s.seq = entries[n-1].Seq
+	}
+
+	s.file, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close fecha o arquivo.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
+
+func (s *FileStore) Append(ctx context.Context, e Entry) (Entry, error) {
+	if err := ctx.Err(); err != nil {
+		return Entry{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e.Seq = s.seq + 1
+	line, err := json.Marshal(e)
+	if err != nil {
+		return Entry{}, err
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return Entry{}, err
+	}
+	if err := s.file.Sync(); err != nil {
+		return Entry{}, err
+	}
+	s.seq = e.Seq
+	return e, nil
+}
+
+func (s *FileStore) Entries(ctx context.Context, f Filter) ([]Entry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.read(f)
+}
+
+func (s *FileStore) read(f Filter) ([]Entry, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var result []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, err
+		}
+		if f.Match(e) {
+			result = append(result, e)
+		}
+	}
+	return result, scanner.Err()
+}
@@ -0,0 +1,277 @@
+// Package backup exporta todas as tarefas e listas de um backend para um
+// único arquivo versionado e restaura esse arquivo em qualquer backend.
+//
+// O arquivo é JSON comprimido com gzip. O campo Version identifica o formato;
+// Read recusa arquivos de versões mais novas que FormatVersion.
+package backup
+
+import (
+	"botasks/internal/list"
+	"botasks/internal/repository"
+	"botasks/internal/task"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FormatVersion é a versão do formato gravada por Write.
+const FormatVersion = 1
+
+// ErrUnsupportedVersion é retornado ao ler um arquivo de formato desconhecido.
+var ErrUnsupportedVersion = errors.New("unsupported backup version")
+
+// Archive é o conteúdo de um backup.
+type Archive struct {
+	Version   int         `json:"version"`
+	CreatedAt time.Time   `json:"created_at"`
+	Tasks     []task.Task `json:"tasks"`
+	Lists     []List      `json:"lists"`
+}
+
+// List é uma lista no backup. As tarefas associadas são guardadas apenas
+// pelo ID, na ordem da lista; os dados delas ficam em Archive.Tasks.
+type List struct {
+	List    list.TaskList `json:"list"` // sem o campo Tasks
+	TaskIDs []string      `json:"task_ids"`
+}
+
+// Mode define como Restore trata os dados já existentes no destino.
+type Mode int
+
+const (
+	// Merge mantém os dados do destino. Tarefas e listas do backup são
+	// criadas se não existirem e sobrescrevem as existentes apenas se forem
+	// mais recentes (UpdatedAt). As tarefas das listas são unidas.
+	Merge Mode = iota
+	// Replace apaga todas as tarefas e listas do destino antes de restaurar.
+	Replace
+)
+
+// ParseMode converte "merge" ou "replace" em Mode.
+func ParseMode(s string) (Mode, error) {
+	switch s {
+	case "merge":
+		return Merge, nil
+	case "replace":
+		return Replace, nil
+	}
+	return 0, fmt.Errorf("unknown restore mode %q", s)
+}
+
+// Export lê todas as tarefas e listas dos repositórios. Se taskLists
+// implementar repository.Snapshotter, a leitura é feita de uma só vez e o
+// backup é consistente mesmo com escritas concorrentes.
+func Export(ctx context.Context, tasks repository.TaskRepository, taskLists repository.TaskListRepository, now time.Time) (*Archive, error) {
+	var (
+		allTasks []task.Task
+		allLists []list.TaskList
+		err      error
+	)
+	if snapshotter, ok := taskLists.(repository.Snapshotter); ok {
+		allTasks, allLists, err = snapshotter.ReadAll(ctx)
+	} else {
+		allLists, err = taskLists.List(ctx)
+		if err == nil {
+			allTasks, err = tasks.List(ctx)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Archive{Version: FormatVersion, CreatedAt: now, Tasks: allTasks, Lists: make([]List, len(allLists))}
+	for i, l := range allLists {
+		taskIDs := make([]string, len(l.Tasks))
+		for j, t := range l.Tasks {
+			taskIDs[j] = t.ID
+		}
+		l.Tasks = nil
+		a.Lists[i] = List{List: l, TaskIDs: taskIDs}
+	}
+	return a, nil
+}
+
+// Restore grava o backup nos repositórios da unidade de trabalho criada por
+// uowf. Se alguma operação falhar, a unidade é desfeita e o destino fica
+// como estava.
+//
+// As versões do backup não são preservadas: cada backend atribui as suas.
+func Restore(ctx context.Context, uowf repository.UnitOfWorkFactory, a *Archive, mode Mode) error {
+	uow, err := uowf.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	if err := restore(ctx, uow, a, mode); err != nil {
+		if rbErr := uow.Rollback(); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+	return uow.Commit()
+}
+
+func restore(ctx context.Context, uow repository.UnitOfWork, a *Archive, mode Mode) error {
+	if mode == Replace {
+		if err := deleteAll(ctx, uow); err != nil {
+			return err
+		}
+	}
+	for _, t := range a.Tasks {
+		if err := restoreTask(ctx, uow.Tasks(), t); err != nil {
+			return fmt.Errorf("task %s: %w", t.ID, err)
+		}
+	}
+	for _, l := range a.Lists {
+		if err := restoreList(ctx, uow.TaskLists(), l); err != nil {
+			return fmt.Errorf("list %s: %w", l.List.ID, err)
+		}
+	}
+	return nil
+}
+
+func deleteAll(ctx context.Context, uow repository.UnitOfWork) error {
+	taskLists, err := uow.TaskLists().List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, l := range taskLists {
+		if err := uow.TaskLists().Delete(ctx, l.ID); err != nil {
+			return err
+		}
+	}
+	tasks, err := uow.Tasks().List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		if err := uow.Tasks().Delete(ctx, t.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func restoreTask(ctx context.Context, tasks repository.TaskRepository, t task.Task) error {
+	current, err := tasks.GetByID(ctx, t.ID)
+	if errors.Is(err, repository.ErrTaskNotFound) {
+		_, err = tasks.Create(ctx, t)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if !t.UpdatedAt.After(current.UpdatedAt) {
+		return nil
+	}
+	t.Version = current.Version
+	return tasks.Update(ctx, t)
+}
+
+func restoreList(ctx context.Context, taskLists repository.TaskListRepository, l List) error {
+	current, err := taskLists.GetByID(ctx, l.List.ID)
+	if errors.Is(err, repository.ErrTaskListNotFound) {
+		taskList := l.List
+		taskList.Tasks = make([]list.Task, len(l.TaskIDs))
+		for i, taskID := range l.TaskIDs {
+			taskList.Tasks[i].ID = taskID
+		}
+		_, err = taskLists.Create(ctx, taskList)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if l.List.UpdatedAt.After(current.UpdatedAt) {
+		taskList := l.List
+		taskList.Version = current.Version
+		if err := taskLists.Update(ctx, taskList); err != nil {
+			return err
+		}
+	}
+
+	present := make(map[string]bool, len(current.Tasks))
+	for _, t := range current.Tasks {
+		present[t.ID] = true
+	}
+	for _, taskID := range l.TaskIDs {
+		if present[taskID] {
+			continue
+		}
+		if err := taskLists.AddTaskToList(ctx, taskID, l.List.ID); err != nil {
+			return err
+		}
+		present[taskID] = true
+	}
+	return nil
+}
+
+// Write grava o backup em w.
+func Write(w io.Writer, a *Archive) error {
+	zw := gzip.NewWriter(w)
+	enc := json.NewEncoder(zw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(a); err != nil {
+		zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+// Read lê um backup gravado por Write.
+func Read(r io.Reader) (*Archive, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var a Archive
+	if err := json.NewDecoder(zr).Decode(&a); err != nil {
+		return nil, err
+	}
+	if a.Version < 1 || a.Version > FormatVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, a.Version)
+	}
+	return &a, nil
+}
+
+// WriteFile grava o backup no arquivo path. O arquivo é escrito em um
+// temporário e renomeado, para que um backup anterior não seja perdido se a
+// gravação falhar.
+func WriteFile(path string, a *Archive) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := Write(tmp, a); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ReadFile lê o backup gravado em path.
+func ReadFile(path string) (*Archive, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
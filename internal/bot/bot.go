@@ -0,0 +1,392 @@
+// Package bot interpreta comandos de chat (/add, /list, /done, ...) e os
+// executa no TaskListService. O núcleo não depende de nenhum mensageiro:
+// cada adaptador entrega as mensagens a Handle, ou implementa Transport e
+// usa Run.
+package bot
+
+import (
+	"botasks/internal/clock"
+	"botasks/internal/list"
+	"botasks/internal/quickadd"
+	"botasks/internal/service"
+	"botasks/internal/task"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const help = `Commands:
+/add <task> [when] [every ...] [!high] [#tag]  add a task to the selected list
+/list  show the tasks of the selected list
+/done <n>  complete task n of the last /list
+/remind <n> <when|1h before>  remind you of task n of the last /list
+/lists  show your lists
+/newlist <name>  create a list and select it
+/use <n>  select list n`
+
+// DefaultListName é o nome da lista criada no primeiro /add de um chat sem
+// listas.
+const DefaultListName = "Inbox"
+
+// Message é uma mensagem recebida de um chat.
+type Message struct {
+	ChatID string
+	User   string // autor das operações; "chat:<ChatID>" se vazio
+	Text   string
+}
+
+// Reply é a resposta a uma mensagem, em texto simples. Buttons, se houver,
+// são linhas de botões que o adaptador pode exibir; ao ser tocado, um botão
+// equivale a enviar Data como mensagem.
+type Reply struct {
+	ChatID  string
+	Text    string
+	Buttons [][]Button
+}
+
+// Button é um botão de resposta rápida.
+type Button struct {
+	Text string
+	Data string // comando enviado ao tocar, como "/complete <ID>"
+}
+
+// Service é a parte do service.TaskListService usada pelo bot.
+type Service interface {
+	CreateTaskList(ctx context.Context, name string) (string, error)
+	GetTaskList(ctx context.Context, taskListID string) (*list.TaskList, error)
+	AddTask(ctx context.Context, taskListID, title, description string, deadline time.Time, opts ...task.Option) (string, error)
+	GetTask(ctx context.Context, taskID string) (*task.Task, error)
+	GetTasksByTaskList(ctx context.Context, taskListID string) ([]task.Task, error)
+	UpdateTask(ctx context.Context, taskID, title, description string, deadline time.Time) error
+	CompleteTask(ctx context.Context, taskID string) error
+}
+
+// Bot atende as mensagens de vários chats. Mensagens de um mesmo chat são
+// processadas uma de cada vez; chats diferentes não se bloqueiam.
+type Bot struct {
+	svc    Service
+	states StateStore
+	clock  clock.Clock
+	snooze time.Duration
+
+	reminders       Reminders
+	reminderChannel string
+
+	mu    sync.Mutex
+	chats map[string]*sync.Mutex
+}
+
+// Option configura um Bot.
+type Option func(*Bot)
+
+// WithStateStore define onde o estado das conversas é guardado. O padrão
+// é a memória.
+func WithStateStore(store StateStore) Option {
+	return func(b *Bot) {
+		b.states = store
+	}
+}
+
+// WithClock define o relógio usado para interpretar "today", "18h" etc.
+func WithClock(c clock.Clock) Option {
+	return func(b *Bot) {
+		b.clock = c
+	}
+}
+
+// WithSnooze define quanto o botão de adiar empurra o prazo. O padrão é um
+// dia.
+func WithSnooze(d time.Duration) Option {
+	return func(b *Bot) {
+		b.snooze = d
+	}
+}
+
+func New(svc Service, opts ...Option) *Bot {
+	b := &Bot{
+		svc:    svc,
+		states: NewMemoryStateStore(),
+		clock:  clock.New(),
+		snooze: 24 * time.Hour,
+		chats:  make(map[string]*sync.Mutex),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Handle executa o comando da mensagem e retorna a resposta. Erros também
+// viram respostas, para que o usuário saiba o que houve.
+func (b *Bot) Handle(ctx context.Context, msg Message) Reply {
+	unlock := b.lock(msg.ChatID)
+	defer unlock()
+
+	actor := msg.User
+	if actor == "" {
+		actor = "chat:" + msg.ChatID
+	}
+	ctx = service.ContextWithActor(ctx, actor)
+
+	r, err := b.exec(ctx, msg)
+	if err != nil {
+		r = Reply{Text: "Error: " + err.Error()}
+	}
+	r.ChatID = msg.ChatID
+	return r
+}
+
+func (b *Bot) lock(chatID string) func() {
+	b.mu.Lock()
+	m, ok := b.chats[chatID]
+	if !ok {
+		m = &sync.Mutex{}
+		b.chats[chatID] = m
+	}
+	b.mu.Unlock()
+
+	m.Lock()
+	return m.Unlock
+}
+
+func (b *Bot) exec(ctx context.Context, msg Message) (Reply, error) {
+	cmd, arg, ok := parseCommand(msg.Text)
+	if !ok {
+		return Reply{Text: "Send /help to see the commands."}, nil
+	}
+
+	st, err := b.states.Load(ctx, msg.ChatID)
+	if err != nil {
+		return Reply{}, err
+	}
+	var r Reply
+	switch cmd {
+	case "start", "help":
+		return Reply{Text: help}, nil
+	case "lists":
+		r.Text, err = b.showLists(ctx, st)
+		return r, err
+	case "newlist":
+		r.Text, err = b.newList(ctx, &st, arg)
+	case "use":
+		r.Text, err = b.useList(ctx, &st, arg)
+	case "add":
+		r.Text, err = b.add(ctx, &st, arg)
+	case "list":
+		r, err = b.showTasks(ctx, &st)
+	case "done":
+		r.Text, err = b.done(ctx, st, arg)
+		return r, err
+	case "complete":
+		r.Text, err = b.complete(ctx, st, arg)
+		return r, err
+	case "snooze":
+		r.Text, err = b.snoozeTask(ctx, st, arg)
+		return r, err
+	case "remind":
+		r.Text, err = b.remind(ctx, msg.ChatID, st, arg)
+		return r, err
+	default:
+		return Reply{}, fmt.Errorf("unknown command /%s (try /help)", cmd)
+	}
+	if err != nil {
+		return Reply{}, err
+	}
+	return r, b.states.Save(ctx, msg.ChatID, st)
+}
+
+// parseCommand separa "/cmd@NomeDoBot argumentos" em "cmd" e "argumentos".
+func parseCommand(text string) (string, string, bool) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "/") {
+		return "", "", false
+	}
+	cmd, arg, _ := strings.Cut(text[1:], " ")
+	cmd, _, _ = strings.Cut(cmd, "@")
+	return strings.ToLower(cmd), strings.TrimSpace(arg), cmd != ""
+}
+
+func (b *Bot) showLists(ctx context.Context, st State) (string, error) {
+	var sb strings.Builder
+	for i, taskListID := range st.Lists {
+		taskList, err := b.svc.GetTaskList(ctx, taskListID)
+		if err != nil {
+			// Listas excluídas continuam numeradas para não mudar os índices
+			continue
+		}
+		marker := " "
+		if taskListID == st.Current {
+			marker = "*"
+		}
+		fmt.Fprintf(&sb, "%s%d. %s (%d tasks)\n", marker, i+1, taskList.Name, len(taskList.Tasks))
+	}
+	if sb.Len() == 0 {
+		return "You have no lists. Create one with /newlist <name>.", nil
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+func (b *Bot) newList(ctx context.Context, st *State, name string) (string, error) {
+	if name == "" {
+		return "", errors.New("usage: /newlist <name>")
+	}
+	taskListID, err := b.svc.CreateTaskList(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	st.Lists = append(st.Lists, taskListID)
+	st.Current, st.Shown = taskListID, nil
+	return fmt.Sprintf("Created list %d: %s", len(st.Lists), name), nil
+}
+
+func (b *Bot) useList(ctx context.Context, st *State, arg string) (string, error) {
+	n, err := index(arg, len(st.Lists))
+	if err != nil {
+		return "", err
+	}
+	taskList, err := b.svc.GetTaskList(ctx, st.Lists[n])
+	if err != nil {
+		return "", err
+	}
+	st.Current, st.Shown = taskList.ID, nil
+	return "Selected " + taskList.Name, nil
+}
+
+func (b *Bot) add(ctx context.Context, st *State, arg string) (string, error) {
+	now := b.clock.Now()
+	q := quickadd.Parse(arg, now)
+	if q.Title == "" {
+		return "", errors.New("usage: /add <task> [when] [every ...] [!high] [#tag]")
+	}
+	if st.Current == "" {
+		if _, err := b.newList(ctx, st, DefaultListName); err != nil {
+			return "", err
+		}
+	}
+	taskList, err := b.svc.GetTaskList(ctx, st.Current)
+	if err != nil {
+		return "", err
+	}
+	deadline := q.DeadlineOr(now.Add(24 * time.Hour))
+	if _, err := b.svc.AddTask(ctx, st.Current, q.Title, "", deadline, q.Options()...); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Added to %s: %s", taskList.Name, formatTask(task.Task{Title: q.Title, Deadline: deadline, Tags: q.Tags})), nil
+}
+
+// showTasks lista as tarefas da lista selecionada, com botões para
+// concluir ou adiar as pendentes.
+func (b *Bot) showTasks(ctx context.Context, st *State) (Reply, error) {
+	if st.Current == "" {
+		return Reply{}, errors.New("no list selected (use /newlist or /use)")
+	}
+	taskList, err := b.svc.GetTaskList(ctx, st.Current)
+	if err != nil {
+		return Reply{}, err
+	}
+	tasks, err := b.svc.GetTasksByTaskList(ctx, st.Current)
+	if err != nil {
+		return Reply{}, err
+	}
+	st.Shown = st.Shown[:0]
+	if len(tasks) == 0 {
+		return Reply{Text: taskList.Name + " is empty."}, nil
+	}
+	var r Reply
+	var sb strings.Builder
+	sb.WriteString(taskList.Name + ":")
+	for i, t := range tasks {
+		check := " "
+		if t.IsCompleted() {
+			check = "x"
+		} else {
+			r.Buttons = append(r.Buttons, []Button{
+				{Text: fmt.Sprintf("Done %d", i+1), Data: "/complete " + t.ID},
+				{Text: fmt.Sprintf("Snooze %d", i+1), Data: "/snooze " + t.ID},
+			})
+		}
+		fmt.Fprintf(&sb, "\n%d. [%s] %s", i+1, check, formatTask(t))
+		st.Shown = append(st.Shown, t.ID)
+	}
+	r.Text = sb.String()
+	return r, nil
+}
+
+func (b *Bot) done(ctx context.Context, st State, arg string) (string, error) {
+	if len(st.Shown) == 0 {
+		return "", errors.New("no tasks shown (use /list first)")
+	}
+	n, err := index(arg, len(st.Shown))
+	if err != nil {
+		return "", err
+	}
+	return b.complete(ctx, st, st.Shown[n])
+}
+
+func (b *Bot) complete(ctx context.Context, st State, taskID string) (string, error) {
+	t, err := b.ownTask(ctx, st, taskID)
+	if err != nil {
+		return "", err
+	}
+	if err := b.svc.CompleteTask(ctx, t.ID); err != nil {
+		return "", err
+	}
+	return "Done: " + t.Title, nil
+}
+
+// snoozeTask adia o prazo da tarefa; prazos vencidos passam a contar de
+// agora.
+func (b *Bot) snoozeTask(ctx context.Context, st State, taskID string) (string, error) {
+	t, err := b.ownTask(ctx, st, taskID)
+	if err != nil {
+		return "", err
+	}
+	deadline := t.Deadline
+	if now := b.clock.Now(); deadline.Before(now) {
+		deadline = now
+	}
+	deadline = deadline.Add(b.snooze)
+	if err := b.svc.UpdateTask(ctx, t.ID, "", "", deadline); err != nil {
+		return "", err
+	}
+	t.Deadline = deadline
+	return "Snoozed: " + formatTask(*t), nil
+}
+
+// ownTask busca a tarefa, desde que ela esteja em uma das listas do chat:
+// os IDs chegam pelos botões, mas podem ser digitados por qualquer um.
+func (b *Bot) ownTask(ctx context.Context, st State, taskID string) (*task.Task, error) {
+	for _, taskListID := range st.Lists {
+		taskList, err := b.svc.GetTaskList(ctx, taskListID)
+		if err != nil {
+			continue
+		}
+		for _, t := range taskList.Tasks {
+			if t.ID == taskID {
+				return b.svc.GetTask(ctx, taskID)
+			}
+		}
+	}
+	return nil, fmt.Errorf("task %q not found", taskID)
+}
+
+func formatTask(t task.Task) string {
+	s := fmt.Sprintf("%s (due %s)", t.Title, t.Deadline.Format("Mon Jan 2 15:04"))
+	for _, tag := range t.Tags {
+		s += " #" + tag
+	}
+	return s
+}
+
+// index converte um número exibido (a partir de 1) em índice de slice.
+func index(arg string, length int) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(arg))
+	if err != nil || n < 1 || n > length {
+		return 0, fmt.Errorf("invalid number %q", arg)
+	}
+	return n - 1, nil
+}
@@ -0,0 +1,97 @@
+package bot
+
+import (
+	"botasks/internal/quickadd"
+	"botasks/internal/reminder"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Reminders é a parte do reminder.Scheduler usada pelo /remind.
+type Reminders interface {
+	Add(ctx context.Context, r reminder.Reminder) (string, error)
+}
+
+// WithReminders habilita o /remind. Os lembretes são criados no canal
+// informado (o nome do notificador no reminder.Router, como "telegram"),
+// com o chat como destinatário.
+func WithReminders(r Reminders, channel string) Option {
+	return func(b *Bot) {
+		b.reminders, b.reminderChannel = r, channel
+	}
+}
+
+// Sender envia respostas a um chat; os Transports e o adaptador do
+// Telegram o implementam.
+type Sender interface {
+	Send(ctx context.Context, r Reply) error
+}
+
+// ReminderNotifier entrega os lembretes como mensagens no chat do Target,
+// com os botões de concluir e adiar.
+type ReminderNotifier struct {
+	sender Sender
+}
+
+var _ reminder.Notifier = (*ReminderNotifier)(nil)
+
+func NewReminderNotifier(s Sender) *ReminderNotifier {
+	return &ReminderNotifier{sender: s}
+}
+
+func (n *ReminderNotifier) Notify(ctx context.Context, notification reminder.Notification) error {
+	chatID := notification.Reminder.Target
+	if chatID == "" {
+		return errors.New("bot reminder has no chat")
+	}
+	taskID := notification.Task.ID
+	return n.sender.Send(ctx, Reply{
+		ChatID: chatID,
+		Text:   notification.Text(),
+		Buttons: [][]Button{{
+			{Text: "Done", Data: "/complete " + taskID},
+			{Text: "Snooze", Data: "/snooze " + taskID},
+		}},
+	})
+}
+
+// remind cria um lembrete para a tarefa n do último /list, em um instante
+// ("tomorrow 9am") ou com antecedência ("1h before").
+func (b *Bot) remind(ctx context.Context, chatID string, st State, arg string) (string, error) {
+	if b.reminders == nil {
+		return "", errors.New("reminders are not enabled")
+	}
+	n, when, _ := strings.Cut(arg, " ")
+	when = strings.TrimSpace(when)
+	if when == "" {
+		return "", errors.New("usage: /remind <n> <when|1h before>")
+	}
+	if len(st.Shown) == 0 {
+		return "", errors.New("no tasks shown (use /list first)")
+	}
+	i, err := index(n, len(st.Shown))
+	if err != nil {
+		return "", err
+	}
+	t, err := b.ownTask(ctx, st, st.Shown[i])
+	if err != nil {
+		return "", err
+	}
+
+	r := reminder.Reminder{TaskID: t.ID, Channel: b.reminderChannel, Target: chatID}
+	if before, ok := reminder.ParseBefore(when); ok {
+		r.Before = before
+	} else {
+		q := quickadd.Parse(when, b.clock.Now())
+		if q.Deadline.IsZero() || q.Title != "" {
+			return "", fmt.Errorf("can't tell when %q is", when)
+		}
+		r.At = q.Deadline
+	}
+	if _, err := b.reminders.Add(ctx, r); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("I'll remind you on %s: %s", r.DueAt(*t).Format("Mon Jan 2 15:04"), t.Title), nil
+}
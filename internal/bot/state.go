@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// State é o estado da conversa de um chat.
+type State struct {
+	Lists   []string // IDs das listas do chat, na ordem exibida
+	Current string   // ID da lista selecionada
+	Shown   []string // IDs das tarefas exibidas pelo último /list, na ordem
+}
+
+// StateStore guarda o estado de cada chat. Load retorna o estado zero para
+// chats desconhecidos.
+type StateStore interface {
+	Load(ctx context.Context, chatID string) (State, error)
+	Save(ctx context.Context, chatID string, st State) error
+}
+
+// MemoryStateStore guarda os estados em memória.
+type MemoryStateStore struct {
+	states map[string]State
+	mu     sync.RWMutex
+}
+
+var _ StateStore = (*MemoryStateStore)(nil)
+
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{states: make(map[string]State)}
+}
+
+func (s *MemoryStateStore) Load(ctx context.Context, chatID string) (State, error) {
+	if err := ctx.Err(); err != nil {
+		return State{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return cloneState(s.states[chatID]), nil
+}
+
+func (s *MemoryStateStore) Save(ctx context.Context, chatID string, st State) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.states[chatID] = cloneState(st)
+	return nil
+}
+
+func cloneState(st State) State {
+	st.Lists = slices.Clone(st.Lists)
+	st.Shown = slices.Clone(st.Shown)
+	return st
+}
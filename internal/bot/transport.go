@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// Transport leva as mensagens dos chats ao bot e as respostas de volta.
+// Receive retorna io.EOF quando não houver mais mensagens.
+type Transport interface {
+	Receive(ctx context.Context) (Message, error)
+	Send(ctx context.Context, r Reply) error
+}
+
+// Run atende as mensagens do transporte, uma de cada vez, até que ele se
+// encerre ou ocorra um erro de envio ou recebimento.
+func (b *Bot) Run(ctx context.Context, t Transport) error {
+	for {
+		msg, err := t.Receive(ctx)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := t.Send(ctx, b.Handle(ctx, msg)); err != nil {
+			return err
+		}
+	}
+}
+
+// Local é um transporte em memória, para testes e para conversar com o bot
+// no próprio processo.
+type Local struct {
+	in   chan Message
+	out  chan Reply
+	done chan struct{}
+	once sync.Once
+}
+
+var _ Transport = (*Local)(nil)
+
+func NewLocal() *Local {
+	return &Local{
+		in:   make(chan Message),
+		out:  make(chan Reply),
+		done: make(chan struct{}),
+	}
+}
+
+func (l *Local) Receive(ctx context.Context) (Message, error) {
+	select {
+	case msg := <-l.in:
+		return msg, nil
+	case <-l.done:
+		return Message{}, io.EOF
+	case <-ctx.Done():
+		return Message{}, ctx.Err()
+	}
+}
+
+func (l *Local) Send(ctx context.Context, r Reply) error {
+	select {
+	case l.out <- r:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Say envia uma mensagem e espera a resposta. As respostas chegam na ordem
+// das mensagens, então Say não deve ser chamado de várias goroutines ao
+// mesmo tempo.
+func (l *Local) Say(ctx context.Context, msg Message) (Reply, error) {
+	select {
+	case l.in <- msg:
+	case <-l.done:
+		return Reply{}, io.EOF
+	case <-ctx.Done():
+		return Reply{}, ctx.Err()
+	}
+	select {
+	case r := <-l.out:
+		return r, nil
+	case <-ctx.Done():
+		return Reply{}, ctx.Err()
+	}
+}
+
+// Close encerra o transporte; o Run que o usa retorna.
+func (l *Local) Close() {
+	l.once.Do(func() { close(l.done) })
+}
@@ -0,0 +1,537 @@
+// Package cli implementa o modo interativo do todoliist: lê comandos, um por
+// linha, e os executa no TaskListService.
+package cli
+
+import (
+	"botasks/internal/backup"
+	"botasks/internal/clock"
+	"botasks/internal/ical"
+	"botasks/internal/markdown"
+	"botasks/internal/migrate"
+	"botasks/internal/quickadd"
+	"botasks/internal/reminder"
+	"botasks/internal/service"
+	"botasks/internal/task"
+	"botasks/internal/taskcsv"
+	"botasks/internal/todotxt"
+	"botasks/internal/undo"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const help = `commands:
+  lists                 show all lists
+  newlist <name>        create a list and select it
+  use <n>               select list n
+  rename <name>         rename the selected list
+  rmlist                delete the selected list and its tasks
+  add <text>            add a task to the selected list; the text may say
+                        when and how often ("tomorrow 10am", "every
+                        month on the 5th"), !high and #tags; the
+                        default deadline is 24h from now
+  tasks                 show the tasks of the selected list
+  edit <n> <title>      change the title of task n
+  done <n>              complete task n
+  remind <n> <when>     remind of task n at a time ("tomorrow 9am") or
+                        ahead of its deadline ("1h before")
+  rm <n>                delete task n
+  undo [n]              undo the last n operations (default 1)
+  redo [n]              redo the last n undone operations (default 1)
+  export <file>         write a backup of all lists and tasks
+  import <file> [mode]  restore a backup; mode is merge (default) or replace
+  todo-export <file>    write all tasks in todo.txt format
+  todo-import <file>    add tasks from a todo.txt file; tasks without a
+                        +project go to the selected list
+  ics-export <file>     write the selected list as an iCalendar file
+  ics-import <file>     add the tasks of an iCalendar file to the selected
+                        list, or to a new list if none is selected
+  csv-export <file>     write the tasks of the selected list (or of all
+                        lists, if none is selected) as CSV
+  csv-import <file> [dry-run]
+                        add the rows of a CSV file to the selected list;
+                        dry-run only reports what would be imported
+  md-export <file>      write the selected list as a Markdown checklist
+  md-import <file>      add the items of a Markdown checklist to the
+                        selected list, or to a new list if none is selected
+  migrate <taskwarrior|trello> <file>
+                        import a Taskwarrior or Trello JSON export into new
+                        lists and report the fields left out
+  help                  show this help
+  quit                  exit`
+
+// CLI guarda o estado da sessão interativa.
+type CLI struct {
+	svc  *service.TaskListService
+	undo *undo.Manager
+	out  io.Writer
+
+	lists   []string // IDs das listas criadas na sessão, na ordem exibida
+	current string   // ID da lista selecionada
+	clock   clock.Clock
+
+	reminders *reminder.Scheduler
+}
+
+// Option configura uma sessão.
+type Option func(*CLI)
+
+// WithReminders habilita o comando remind. Os lembretes usam o canal
+// padrão do notificador do Scheduler.
+func WithReminders(s *reminder.Scheduler) Option {
+	return func(c *CLI) {
+		c.reminders = s
+	}
+}
+
+// WithClock define o relógio usado nos prazos do add e do remind e nas
+// importações e exportações.
+func WithClock(clk clock.Clock) Option {
+	return func(c *CLI) {
+		c.clock = clk
+	}
+}
+
+// New cria uma sessão sobre o serviço e o gerenciador de desfazer informados.
+func New(svc *service.TaskListService, undoManager *undo.Manager, out io.Writer, opts ...Option) *CLI {
+	c := &CLI{svc: svc, undo: undoManager, out: out, clock: clock.New()}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Run lê comandos de in até EOF ou "quit". O autor das operações deve estar
+// no contexto (service.ContextWithActor).
+func (c *CLI) Run(ctx context.Context, in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if line == "quit" || line == "exit" {
+			return nil
+		}
+		if err := c.Exec(ctx, line); err != nil {
+			fmt.Fprintf(c.out, "error: %v\n", err)
+		}
+	}
+	return scanner.Err()
+}
+
+// Exec executa um único comando.
+func (c *CLI) Exec(ctx context.Context, line string) error {
+	cmd, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch cmd {
+	case "help":
+		fmt.Fprintln(c.out, help)
+		return nil
+	case "lists":
+		return c.showLists(ctx)
+	case "newlist":
+		if arg == "" {
+			return errors.New("usage: newlist <name>")
+		}
+		taskListID, err := c.svc.CreateTaskList(ctx, arg)
+		if err != nil {
+			return err
+		}
+		c.lists = append(c.lists, taskListID)
+		c.current = taskListID
+		fmt.Fprintf(c.out, "created list %d: %s\n", len(c.lists), arg)
+		return nil
+	case "use":
+		n, err := index(arg, len(c.lists))
+		if err != nil {
+			return err
+		}
+		c.current = c.lists[n]
+		return nil
+	case "rename":
+		if err := c.requireList(); err != nil {
+			return err
+		}
+		return c.svc.UpdateTaskList(ctx, c.current, arg)
+	case "rmlist":
+		if err := c.requireList(); err != nil {
+			return err
+		}
+		return c.svc.DeleteTaskListAndTasks(ctx, c.current)
+	case "add":
+		if err := c.requireList(); err != nil {
+			return err
+		}
+		now := c.clock.Now()
+		q := quickadd.Parse(arg, now)
+		if q.Title == "" {
+			return errors.New("usage: add <text>")
+		}
+		_, err := c.svc.AddTask(ctx, c.current, q.Title, "", q.DeadlineOr(now.Add(24*time.Hour)), q.Options()...)
+		return err
+	case "remind":
+		return c.remind(ctx, arg)
+	case "tasks":
+		if err := c.requireList(); err != nil {
+			return err
+		}
+		return c.showTasks(ctx)
+	case "edit":
+		n, title, _ := strings.Cut(arg, " ")
+		t, err := c.taskAt(ctx, n)
+		if err != nil {
+			return err
+		}
+		return c.svc.UpdateTask(ctx, t.ID, strings.TrimSpace(title), "", time.Time{})
+	case "done":
+		t, err := c.taskAt(ctx, arg)
+		if err != nil {
+			return err
+		}
+		return c.svc.CompleteTask(ctx, t.ID)
+	case "rm":
+		t, err := c.taskAt(ctx, arg)
+		if err != nil {
+			return err
+		}
+		return c.svc.RemoveAndDeleteTask(ctx, t.ID, c.current)
+	case "undo", "redo":
+		times := 1
+		if arg != "" {
+			var err error
+			if times, err = strconv.Atoi(arg); err != nil || times < 1 {
+				return fmt.Errorf("usage: %s [n]", cmd)
+			}
+		}
+		for i := 0; i < times; i++ {
+			var err error
+			if cmd == "undo" {
+				err = c.undo.Undo(ctx)
+			} else {
+				err = c.undo.Redo(ctx)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case "export":
+		if arg == "" {
+			return errors.New("usage: export <file>")
+		}
+		a, err := c.svc.Export(ctx)
+		if err != nil {
+			return err
+		}
+		if err := backup.WriteFile(arg, a); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.out, "exported %d lists, %d tasks\n", len(a.Lists), len(a.Tasks))
+		return nil
+	case "import":
+		path, modeArg, _ := strings.Cut(arg, " ")
+		if path == "" {
+			return errors.New("usage: import <file> [merge|replace]")
+		}
+		mode := backup.Merge
+		if modeArg = strings.TrimSpace(modeArg); modeArg != "" {
+			var err error
+			if mode, err = backup.ParseMode(modeArg); err != nil {
+				return err
+			}
+		}
+		a, err := backup.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := c.svc.Restore(ctx, a, mode); err != nil {
+			return err
+		}
+		return c.reloadLists(ctx)
+	case "todo-export":
+		if arg == "" {
+			return errors.New("usage: todo-export <file>")
+		}
+		f, err := os.Create(arg)
+		if err != nil {
+			return err
+		}
+		if err := todotxt.Export(ctx, c.svc, f); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	case "todo-import":
+		if arg == "" {
+			return errors.New("usage: todo-import <file>")
+		}
+		f, err := os.Open(arg)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		n, err := todotxt.Import(ctx, c.svc, f, c.current, c.clock.Now())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.out, "imported %d tasks\n", n)
+		return c.reloadLists(ctx)
+	case "ics-export":
+		if err := c.requireList(); err != nil {
+			return err
+		}
+		if arg == "" {
+			return errors.New("usage: ics-export <file>")
+		}
+		f, err := os.Create(arg)
+		if err != nil {
+			return err
+		}
+		if err := ical.ExportList(ctx, c.svc, c.current, f, c.clock.Now()); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	case "ics-import":
+		if arg == "" {
+			return errors.New("usage: ics-import <file>")
+		}
+		f, err := os.Open(arg)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		taskListID, n, err := ical.ImportList(ctx, c.svc, f, c.current, c.clock.Now())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.out, "imported %d tasks\n", n)
+		if c.current == "" {
+			c.current = taskListID
+		}
+		return c.reloadLists(ctx)
+	case "csv-export":
+		if arg == "" {
+			return errors.New("usage: csv-export <file>")
+		}
+		f, err := os.Create(arg)
+		if err != nil {
+			return err
+		}
+		if err := taskcsv.Export(ctx, c.svc, f, c.current, task.Query{}); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	case "csv-import":
+		path, flag, _ := strings.Cut(arg, " ")
+		if path == "" || (flag != "" && flag != "dry-run") {
+			return errors.New("usage: csv-import <file> [dry-run]")
+		}
+		if err := c.requireList(); err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		rep, err := taskcsv.Import(ctx, c.svc, f, c.current, taskcsv.Options{DryRun: flag == "dry-run", Now: c.clock.Now()})
+		if err != nil {
+			return err
+		}
+		for i, t := range rep.Tasks {
+			fmt.Fprintf(c.out, "row %d: %s\n", rep.Rows[i], t.Title)
+		}
+		for _, rowErr := range rep.Errors {
+			fmt.Fprintf(c.out, "%v\n", rowErr)
+		}
+		fmt.Fprintf(c.out, "%d valid rows, %d errors, %d imported (dates as %s)\n", len(rep.Tasks), len(rep.Errors), rep.Imported, rep.DateFormat)
+		return nil
+	case "md-export":
+		if err := c.requireList(); err != nil {
+			return err
+		}
+		if arg == "" {
+			return errors.New("usage: md-export <file>")
+		}
+		f, err := os.Create(arg)
+		if err != nil {
+			return err
+		}
+		if err := markdown.ExportList(ctx, c.svc, c.current, f); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	case "md-import":
+		if arg == "" {
+			return errors.New("usage: md-import <file>")
+		}
+		f, err := os.Open(arg)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		taskListID, n, err := markdown.ImportList(ctx, c.svc, f, c.current, c.clock.Now())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.out, "imported %d tasks\n", n)
+		if c.current == "" {
+			c.current = taskListID
+		}
+		return c.reloadLists(ctx)
+	case "migrate":
+		format, path, _ := strings.Cut(arg, " ")
+		var read func(io.Reader, time.Time) (migrate.Result, error)
+		switch format {
+		case "taskwarrior":
+			read = migrate.ReadTaskwarrior
+		case "trello":
+			read = migrate.ReadTrello
+		}
+		if read == nil || path == "" {
+			return errors.New("usage: migrate <taskwarrior|trello> <file>")
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		res, err := read(f, c.clock.Now())
+		if err != nil {
+			return err
+		}
+		taskListIDs, n, err := migrate.Import(ctx, c.svc, res)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.out, "imported %d tasks into %d lists, skipped %d\n", n, len(taskListIDs), res.Skipped)
+		if len(res.Unmapped) > 0 {
+			fmt.Fprintf(c.out, "unmapped fields: %s\n", strings.Join(res.Unmapped, ", "))
+		}
+		return c.reloadLists(ctx)
+	}
+	return fmt.Errorf("unknown command %q (try help)", cmd)
+}
+
+func (c *CLI) remind(ctx context.Context, arg string) error {
+	if c.reminders == nil {
+		return errors.New("reminders are not enabled")
+	}
+	n, when, _ := strings.Cut(arg, " ")
+	when = strings.TrimSpace(when)
+	if when == "" {
+		return errors.New("usage: remind <n> <when|1h before>")
+	}
+	t, err := c.taskAt(ctx, n)
+	if err != nil {
+		return err
+	}
+	r := reminder.Reminder{TaskID: t.ID}
+	if before, ok := reminder.ParseBefore(when); ok {
+		r.Before = before
+	} else {
+		q := quickadd.Parse(when, c.clock.Now())
+		if q.Deadline.IsZero() || q.Title != "" {
+			return fmt.Errorf("can't tell when %q is", when)
+		}
+		r.At = q.Deadline
+	}
+	if _, err := c.reminders.Add(ctx, r); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "reminder set for %s\n", r.DueAt(t).Format("Mon Jan 2 15:04"))
+	return nil
+}
+
+func (c *CLI) requireList() error {
+	if c.current == "" {
+		return errors.New("no list selected (use newlist or use)")
+	}
+	return nil
+}
+
+// reloadLists passa a exibir todas as listas do serviço, como depois de
+// importar um backup. A lista selecionada é mantida se ainda existir.
+func (c *CLI) reloadLists(ctx context.Context) error {
+	taskLists, err := c.svc.ListTaskLists(ctx)
+	if err != nil {
+		return err
+	}
+	c.lists = c.lists[:0]
+	current := c.current
+	c.current = ""
+	for _, taskList := range taskLists {
+		c.lists = append(c.lists, taskList.ID)
+		if taskList.ID == current {
+			c.current = current
+		}
+	}
+	fmt.Fprintf(c.out, "%d lists\n", len(c.lists))
+	return nil
+}
+
+func (c *CLI) showLists(ctx context.Context) error {
+	for i, taskListID := range c.lists {
+		taskList, err := c.svc.GetTaskList(ctx, taskListID)
+		if err != nil {
+			// Listas excluídas continuam numeradas para não mudar os índices
+			continue
+		}
+		marker := " "
+		if taskListID == c.current {
+			marker = "*"
+		}
+		fmt.Fprintf(c.out, "%s%d. %s (%d tasks)\n", marker, i+1, taskList.Name, len(taskList.Tasks))
+	}
+	return nil
+}
+
+func (c *CLI) showTasks(ctx context.Context) error {
+	tasks, err := c.svc.GetTasksByTaskList(ctx, c.current)
+	if err != nil {
+		return err
+	}
+	for i, t := range tasks {
+		check := " "
+		if t.IsCompleted() {
+			check = "x"
+		}
+		fmt.Fprintf(c.out, "%d. [%s] %s (due %s)\n", i+1, check, t.Title, t.Deadline.Format("2006-01-02 15:04"))
+	}
+	return nil
+}
+
+func (c *CLI) taskAt(ctx context.Context, arg string) (task.Task, error) {
+	if err := c.requireList(); err != nil {
+		return task.Task{}, err
+	}
+	tasks, err := c.svc.GetTasksByTaskList(ctx, c.current)
+	if err != nil {
+		return task.Task{}, err
+	}
+	n, err := index(arg, len(tasks))
+	if err != nil {
+		return task.Task{}, err
+	}
+	return tasks[n], nil
+}
+
+// index converte um número exibido (a partir de 1) em índice de slice.
+func index(arg string, length int) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(arg))
+	if err != nil || n < 1 || n > length {
+		return 0, fmt.Errorf("invalid number %q", arg)
+	}
+	return n - 1, nil
+}
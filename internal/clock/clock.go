@@ -0,0 +1,60 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock abstrai a origem do horário atual para que a lógica de prazos possa
+// ser testada de forma determinística.
+type Clock interface {
+	Now() time.Time
+}
+
+// Real é o relógio do sistema.
+type Real struct{}
+
+// New retorna o relógio do sistema.
+func New() Clock {
+	return Real{}
+}
+
+// Now retorna o horário atual do sistema.
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// Fake é um relógio controlável manualmente, usado em testes.
+type Fake struct {
+	now time.Time
+	mu  sync.Mutex
+}
+
+// NewFake cria um relógio parado no instante informado.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now retorna o instante atual do relógio falso.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+// Set move o relógio para o instante informado.
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = now
+}
+
+// Advance avança o relógio pela duração informada.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+}
@@ -0,0 +1,290 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"runtime/debug"
+	"sync"
+)
+
+// ErrReentrantPublish é informado ao OnDrop quando um evento publicado de
+// dentro de um handler assíncrono encontra a fila de destino cheia.
+var ErrReentrantPublish = errors.New("event: queue full on publish from an async handler")
+
+// Handler recebe os eventos publicados no barramento.
+type Handler func(ctx context.Context, e Event)
+
+// Publisher publica eventos. O TaskListService depende apenas desta interface.
+type Publisher interface {
+	Publish(ctx context.Context, e Event)
+}
+
+// Bus é um barramento de eventos em processo.
+//
+// Assinantes síncronos são chamados na goroutine de Publish, na ordem de
+// inscrição. Assinantes assíncronos recebem os eventos por filas próprias;
+// eventos do mesmo agregado vão sempre para a mesma fila, o que garante a
+// ordem por agregado. Um panic em qualquer handler é recuperado e informado
+// ao OnPanic, sem afetar os demais assinantes.
+//
+// Um handler assíncrono que publica esperando vaga na própria fila (ou em
+// um ciclo de filas) nunca seria atendido. Por isso, publicações feitas com
+// o contexto recebido por um handler assíncrono não esperam: se a fila
+// estiver cheia, o evento é descartado e informado ao OnDrop. O handler
+// deve repassar o contexto que recebeu para que isso funcione.
+type Bus struct {
+	// OnPanic é chamado quando um handler entra em panic. O padrão registra no log.
+	OnPanic func(e Event, recovered interface{})
+	// OnDrop é chamado quando um evento é descartado. O padrão registra no log.
+	OnDrop func(e Event, err error)
+
+	subs   []*subscription
+	nextID int
+	closed bool
+	mu     sync.RWMutex
+	wg     sync.WaitGroup
+}
+
+var _ Publisher = (*Bus)(nil)
+
+type subscription struct {
+	id      int
+	handler Handler
+	queues  []chan envelope // nil para assinantes síncronos
+	done    chan struct{}   // fechado ao cancelar a inscrição
+	closed  bool
+	mu      sync.Mutex // protege closed
+}
+
+func newSubscription(h Handler, workers int) *subscription {
+	sub := &subscription{handler: h, done: make(chan struct{})}
+	if workers > 0 {
+		sub.queues = make([]chan envelope, workers)
+	}
+	return sub
+}
+
+// enqueue envia o evento para a fila do agregado, a menos que a inscrição
+// já tenha sido cancelada. Com wait false, não espera vaga e retorna false
+// se a fila estiver cheia. A espera não segura sub.mu: um cancelamento
+// durante ela fecha done e a libera, e o evento é descartado.
+func (sub *subscription) enqueue(env envelope, wait bool) bool {
+	sub.mu.Lock()
+	closed := sub.closed
+	sub.mu.Unlock()
+	if closed {
+		return true
+	}
+
+	q := sub.queues[shard(env.event.AggregateID(), len(sub.queues))]
+	if wait {
+		select {
+		case q <- env:
+		case <-sub.done:
+		}
+		return true
+	}
+	select {
+	case q <- env:
+		return true
+	case <-sub.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (sub *subscription) close() {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	close(sub.done)
+}
+
+// work atende a fila q até a inscrição ser cancelada e depois entrega o
+// que ainda estiver nela.
+func (sub *subscription) work(q chan envelope, dispatch func(env envelope)) {
+	for {
+		select {
+		case env := <-q:
+			dispatch(env)
+		case <-sub.done:
+			for {
+				select {
+				case env := <-q:
+					dispatch(env)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+type envelope struct {
+	ctx   context.Context
+	event Event
+}
+
+// asyncKey marca o contexto entregue aos handlers assíncronos.
+type asyncKey struct{}
+
+func inAsyncHandler(ctx context.Context) bool {
+	return ctx.Value(asyncKey{}) != nil
+}
+
+// NewBus cria um barramento vazio.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe inscreve um handler síncrono e retorna a função que cancela a inscrição.
+func (b *Bus) Subscribe(h Handler) (unsubscribe func()) {
+	return b.add(newSubscription(h, 0))
+}
+
+// SubscribeAsync inscreve um handler assíncrono atendido por workers
+// goroutines. Cada worker tem uma fila com capacidade buffer; quando a fila
+// está cheia, Publish aguarda, exceto quando chamado de um handler
+// assíncrono (veja Bus). A função retornada cancela a inscrição e espera as
+// filas do handler esvaziarem; por isso não pode ser chamada de dentro do
+// próprio handler, que ficaria esperando a si mesmo. Nesse caso, use
+// "go unsubscribe()".
+func (b *Bus) SubscribeAsync(h Handler, workers, buffer int) (unsubscribe func()) {
+	if workers < 1 {
+		workers = 1
+	}
+	sub := newSubscription(h, workers)
+	dispatch := func(env envelope) {
+		b.dispatch(sub.handler, context.WithValue(env.ctx, asyncKey{}, true), env.event)
+	}
+	var done sync.WaitGroup
+	for i := range sub.queues {
+		q := make(chan envelope, buffer)
+		sub.queues[i] = q
+		done.Add(1)
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			defer done.Done()
+			sub.work(q, dispatch)
+		}()
+	}
+
+	remove := b.add(sub)
+	return func() {
+		remove()
+		done.Wait()
+	}
+}
+
+func (b *Bus) add(sub *subscription) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		sub.close()
+		return func() {}
+	}
+	b.nextID++
+	sub.id = b.nextID
+	b.subs = append(b.subs, sub)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { b.remove(sub.id) })
+	}
+}
+
+func (b *Bus) remove(id int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, sub := range b.subs {
+		if sub.id == id {
+			b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+			sub.close()
+			return
+		}
+	}
+}
+
+// Publish entrega o evento a todos os assinantes. Retorna depois que os
+// handlers síncronos terminaram e o evento foi enfileirado para os assíncronos.
+// Os handlers assíncronos recebem um contexto que não é cancelado junto com ctx.
+//
+// Handlers síncronos podem publicar novos eventos e alterar inscrições.
+// Handlers assíncronos podem publicar, mas sem esperar vaga nas filas.
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	b.mu.RLock()
+	subs := b.subs
+	b.mu.RUnlock()
+
+	wait := !inAsyncHandler(ctx)
+	for _, sub := range subs {
+		if sub.queues == nil {
+			b.dispatch(sub.handler, ctx, e)
+			continue
+		}
+		if !sub.enqueue(envelope{ctx: context.WithoutCancel(ctx), event: e}, wait) {
+			b.drop(e, ErrReentrantPublish)
+		}
+	}
+}
+
+// Close cancela todas as inscrições e espera os handlers assíncronos
+// processarem os eventos já enfileirados. Publicações posteriores são
+// ignoradas. Como espera os handlers, não pode ser chamado de dentro de um
+// handler assíncrono.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	for _, sub := range b.subs {
+		sub.close()
+	}
+	b.subs = nil
+	b.mu.Unlock()
+
+	b.wg.Wait()
+}
+
+func (b *Bus) dispatch(h Handler, ctx context.Context, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			if b.OnPanic != nil {
+				b.OnPanic(e, r)
+				return
+			}
+			log.Printf("event: handler panic on %s %s: %v\n%s", e.Name(), e.AggregateID(), r, debug.Stack())
+		}
+	}()
+	h(ctx, e)
+}
+
+func (b *Bus) drop(e Event, err error) {
+	if b.OnDrop != nil {
+		b.OnDrop(e, err)
+		return
+	}
+	log.Printf("event: dropped %s %s: %v", e.Name(), e.AggregateID(), err)
+}
+
+func shard(aggregateID string, n int) int {
+	if n == 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	fmt.Fprint(h, aggregateID)
+	return int(h.Sum32() % uint32(n))
+}
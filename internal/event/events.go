@@ -0,0 +1,116 @@
+package event
+
+import (
+	"botasks/internal/list"
+	"botasks/internal/task"
+	"time"
+)
+
+// Event é um fato do domínio publicado pelo TaskListService depois que a
+// alteração correspondente foi gravada.
+type Event interface {
+	// Name identifica o tipo do evento, por exemplo "task.created".
+	Name() string
+	// AggregateID é o ID da tarefa ou lista afetada. Eventos com o mesmo
+	// AggregateID são entregues na ordem em que foram publicados.
+	AggregateID() string
+	Metadata() Meta
+}
+
+// Meta traz os dados comuns a todos os eventos. Eventos publicados pela
+// mesma chamada do serviço compartilham o OperationID.
+type Meta struct {
+	At          time.Time
+	Actor       string
+	OperationID string
+}
+
+func (m Meta) Metadata() Meta { return m }
+
+// TaskCreated é publicado quando uma tarefa é criada em uma lista.
+type TaskCreated struct {
+	Meta
+	Task   task.Task
+	ListID string
+}
+
+func (e TaskCreated) Name() string        { return "task.created" }
+func (e TaskCreated) AggregateID() string { return e.Task.ID }
+
+// TaskUpdated é publicado quando título, descrição ou prazo mudam.
+type TaskUpdated struct {
+	Meta
+	Before task.Task
+	After  task.Task
+}
+
+func (e TaskUpdated) Name() string        { return "task.updated" }
+func (e TaskUpdated) AggregateID() string { return e.After.ID }
+
+// TaskCompleted é publicado quando uma tarefa é concluída.
+type TaskCompleted struct {
+	Meta
+	Before task.Task
+	After  task.Task
+}
+
+func (e TaskCompleted) Name() string        { return "task.completed" }
+func (e TaskCompleted) AggregateID() string { return e.After.ID }
+
+// TaskDeleted é publicado quando uma tarefa é excluída.
+type TaskDeleted struct {
+	Meta
+	Task task.Task
+}
+
+func (e TaskDeleted) Name() string        { return "task.deleted" }
+func (e TaskDeleted) AggregateID() string { return e.Task.ID }
+
+// ListCreated é publicado quando uma lista é criada.
+type ListCreated struct {
+	Meta
+	List list.TaskList
+}
+
+func (e ListCreated) Name() string        { return "list.created" }
+func (e ListCreated) AggregateID() string { return e.List.ID }
+
+// ListRenamed é publicado quando o nome de uma lista muda.
+type ListRenamed struct {
+	Meta
+	Before list.TaskList
+	After  list.TaskList
+}
+
+func (e ListRenamed) Name() string        { return "list.renamed" }
+func (e ListRenamed) AggregateID() string { return e.After.ID }
+
+// ListDeleted é publicado quando uma lista é excluída. List.Tasks traz as
+// tarefas associadas no momento da exclusão.
+type ListDeleted struct {
+	Meta
+	List list.TaskList
+}
+
+func (e ListDeleted) Name() string        { return "list.deleted" }
+func (e ListDeleted) AggregateID() string { return e.List.ID }
+
+// TaskAddedToList é publicado quando uma tarefa existente é associada a uma lista.
+type TaskAddedToList struct {
+	Meta
+	TaskID string
+	ListID string
+}
+
+func (e TaskAddedToList) Name() string        { return "list.task_added" }
+func (e TaskAddedToList) AggregateID() string { return e.ListID }
+
+// TaskRemovedFromList é publicado quando uma tarefa deixa de fazer parte de uma lista.
+type TaskRemovedFromList struct {
+	Meta
+	TaskID string
+	ListID string
+}
+
+func (e TaskRemovedFromList) Name() string        { return "list.task_removed" }
+func (e TaskRemovedFromList) AggregateID() string { return e.ListID }
@@ -0,0 +1,176 @@
+package httpapi
+
+import (
+	"botasks/internal/ical"
+	"botasks/internal/task"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Feed define o conteúdo de um feed iCalendar: as tarefas de uma lista ou
+// as tarefas com uma tag em qualquer lista. Apenas um dos campos é usado.
+type Feed struct {
+	ListID string
+	Tag    string
+}
+
+// FeedStore associa tokens secretos aos feeds. O token é a única
+// autenticação do feed, pois clientes de calendário só aceitam uma URL.
+type FeedStore interface {
+	Lookup(ctx context.Context, token string) (Feed, bool, error)
+}
+
+// MemoryFeedStore guarda os feeds em memória, indexados pelo hash SHA-256
+// do token: o token em si só é conhecido por quem chamou Issue.
+type MemoryFeedStore struct {
+	feeds map[string]Feed
+	mu    sync.RWMutex
+}
+
+var _ FeedStore = (*MemoryFeedStore)(nil)
+
+func NewMemoryFeedStore() *MemoryFeedStore {
+	return &MemoryFeedStore{feeds: make(map[string]Feed)}
+}
+
+// Issue cria um token aleatório para o feed.
+func (s *MemoryFeedStore) Issue(feed Feed) (string, error) {
+	if (feed.ListID == "") == (feed.Tag == "") {
+		return "", errors.New("feed must have either a list or a tag")
+	}
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.feeds[hashToken(token)] = feed
+	return token, nil
+}
+
+// Revoke invalida o token. Assinaturas que o usam passam a receber 404.
+func (s *MemoryFeedStore) Revoke(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.feeds, hashToken(token))
+}
+
+func (s *MemoryFeedStore) Lookup(ctx context.Context, token string) (Feed, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return Feed{}, false, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	feed, ok := s.feeds[hashToken(token)]
+	return feed, ok, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// handleFeed serve GET /feeds/{token}.ics. O corpo é gerado de forma
+// determinística (DTSTAMP é o Last-Modified), então o ETag é o hash do
+// conteúdo e http.ServeContent responde 304 aos clientes que já o têm.
+//
+// Remover uma tarefa da lista não altera Last-Modified; clientes que só
+// enviam If-Modified-Since podem demorar a ver a remoção, mas o ETag muda.
+func (h *Handler) handleFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name, ok := pathID(r.URL.Path, "/feeds/")
+	token, isICS := strings.CutSuffix(name, ".ics")
+	if !ok || !isICS || h.feeds == nil {
+		http.NotFound(w, r)
+		return
+	}
+	feed, ok, err := h.feeds.Lookup(r.Context(), token)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	cal, err := h.feedCalendar(r.Context(), feed)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	lastModified := lastModified(cal.Tasks)
+
+	var body bytes.Buffer
+	if err := ical.Write(&body, cal, lastModified); err != nil {
+		writeError(w, err)
+		return
+	}
+	sum := sha256.Sum256(body.Bytes())
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	http.ServeContent(w, r, name, lastModified, bytes.NewReader(body.Bytes()))
+}
+
+func (h *Handler) feedCalendar(ctx context.Context, feed Feed) (ical.Calendar, error) {
+	if feed.ListID != "" {
+		taskList, err := h.service.GetTaskList(ctx, feed.ListID)
+		if err != nil {
+			return ical.Calendar{}, err
+		}
+		cal := ical.Calendar{Name: taskList.Name, Tasks: make([]task.Task, len(taskList.Tasks))}
+		for i, t := range taskList.Tasks {
+			cal.Tasks[i] = t.Task
+		}
+		return cal, nil
+	}
+
+	taskLists, err := h.service.ListTaskLists(ctx)
+	if err != nil {
+		return ical.Calendar{}, err
+	}
+	cal := ical.Calendar{Name: "#" + feed.Tag}
+	seen := make(map[string]bool)
+	for _, taskList := range taskLists {
+		for _, t := range taskList.Tasks {
+			if !seen[t.ID] && slices.Contains(t.Tags, feed.Tag) {
+				seen[t.ID] = true
+				cal.Tasks = append(cal.Tasks, t.Task)
+			}
+		}
+	}
+	return cal, nil
+}
+
+// lastModified retorna o UpdatedAt mais recente das tarefas, truncado para
+// segundos como nos cabeçalhos HTTP.
+func lastModified(tasks []task.Task) time.Time {
+	var latest time.Time
+	for _, t := range tasks {
+		if t.UpdatedAt.After(latest) {
+			latest = t.UpdatedAt
+		}
+	}
+	return latest.UTC().Truncate(time.Second)
+}
@@ -0,0 +1,271 @@
+package httpapi
+
+import (
+	"botasks/internal/clock"
+	"botasks/internal/quickadd"
+	"botasks/internal/repository"
+	"botasks/internal/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidIfMatch indica um cabeçalho If-Match que não é uma ETag emitida
+// pela API. A resposta é 400 Bad Request.
+var ErrInvalidIfMatch = errors.New("invalid If-Match header")
+
+// Handler expõe o TaskListService via HTTP/JSON.
+//
+//	GET /tasks/{id}   PUT /tasks/{id}
+//	GET /lists/{id}   PUT /lists/{id}
+//	POST /lists/{id}/tasks  {"text": "Pay rent every month on the 5th !high"}
+//	GET /feeds/{token}.ics  (com WithFeeds)
+//
+// As respostas de GET trazem a versão do registro no cabeçalho ETag. Um PUT
+// com If-Match só é aplicado se a versão ainda for a mesma; caso contrário a
+// resposta é 412 Precondition Failed.
+//
+// O POST em /lists/{id}/tasks interpreta o texto com quickadd; sem prazo no
+// texto, a tarefa vence em 24 horas.
+type Handler struct {
+	service *service.TaskListService
+	feeds   FeedStore
+	clock   clock.Clock
+	mux     *http.ServeMux
+}
+
+// Option configura um Handler.
+type Option func(*Handler)
+
+// WithFeeds publica os feeds iCalendar cujos tokens estão em store, para
+// que clientes de calendário assinem os prazos de uma lista ou tag.
+func WithFeeds(store FeedStore) Option {
+	return func(h *Handler) {
+		h.feeds = store
+	}
+}
+
+// WithClock define o relógio usado para interpretar datas relativas. O
+// padrão é o relógio do sistema.
+func WithClock(c clock.Clock) Option {
+	return func(h *Handler) {
+		h.clock = c
+	}
+}
+
+// NewHandler cria um Handler para o serviço informado.
+func NewHandler(s *service.TaskListService, opts ...Option) *Handler {
+	h := &Handler{service: s, clock: clock.New(), mux: http.NewServeMux()}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.mux.HandleFunc("/tasks/", h.handleTask)
+	h.mux.HandleFunc("/lists/", h.handleList)
+	h.mux.HandleFunc("/feeds/", h.handleFeed)
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+type updateTaskRequest struct {
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Deadline    time.Time `json:"deadline"`
+}
+
+type updateTaskListRequest struct {
+	Name string `json:"name"`
+}
+
+type addTaskRequest struct {
+	Text string `json:"text"`
+}
+
+func (h *Handler) handleTask(w http.ResponseWriter, r *http.Request) {
+	taskID, ok := pathID(r.URL.Path, "/tasks/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		t, err := h.service.GetTask(r.Context(), taskID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, t.Version, t)
+	case http.MethodPut:
+		version, err := parseIfMatch(r.Header.Get("If-Match"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		var req updateTaskRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		if err := h.service.UpdateTaskIfMatch(r.Context(), taskID, version, req.Title, req.Description, req.Deadline); err != nil {
+			writeError(w, err)
+			return
+		}
+		t, err := h.service.GetTask(r.Context(), taskID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, t.Version, t)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) handleList(w http.ResponseWriter, r *http.Request) {
+	if path, ok := strings.CutSuffix(r.URL.Path, "/tasks"); ok {
+		h.handleListTasks(w, r, path)
+		return
+	}
+	taskListID, ok := pathID(r.URL.Path, "/lists/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		l, err := h.service.GetTaskList(r.Context(), taskListID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, l.Version, l)
+	case http.MethodPut:
+		version, err := parseIfMatch(r.Header.Get("If-Match"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		var req updateTaskListRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		if err := h.service.UpdateTaskListIfMatch(r.Context(), taskListID, version, req.Name); err != nil {
+			writeError(w, err)
+			return
+		}
+		l, err := h.service.GetTaskList(r.Context(), taskListID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, l.Version, l)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) handleListTasks(w http.ResponseWriter, r *http.Request, path string) {
+	taskListID, ok := pathID(path, "/lists/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req addTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	now := h.clock.Now()
+	q := quickadd.Parse(req.Text, now)
+	if q.Title == "" {
+		http.Error(w, "task text has no title", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.service.GetTaskList(r.Context(), taskListID); err != nil {
+		writeError(w, err)
+		return
+	}
+	taskID, err := h.service.AddTask(r.Context(), taskListID, q.Title, "", q.DeadlineOr(now.Add(24*time.Hour)), q.Options()...)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	t, err := h.service.GetTask(r.Context(), taskID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Location", "/tasks/"+taskID)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", ETag(t.Version))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(t)
+}
+
+// pathID extrai o ID de caminhos no formato prefix + id.
+func pathID(path, prefix string) (string, bool) {
+	id := strings.TrimPrefix(path, prefix)
+	if id == "" || strings.Contains(id, "/") {
+		return "", false
+	}
+	return id, true
+}
+
+// ETag formata a versão de um registro como ETag forte.
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseIfMatch converte o cabeçalho If-Match na versão esperada. Um cabeçalho
+// vazio ou "*" retorna 0, que desativa a verificação de versão.
+func parseIfMatch(header string) (int, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	unquoted, err := strconv.Unquote(header)
+	if err != nil {
+		return 0, ErrInvalidIfMatch
+	}
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version <= 0 {
+		return 0, ErrInvalidIfMatch
+	}
+	return version, nil
+}
+
+func writeJSON(w http.ResponseWriter, version int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", ETag(version))
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrTaskNotFound), errors.Is(err, repository.ErrTaskListNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repository.ErrConflict):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case errors.Is(err, ErrInvalidIfMatch):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrInvalidTask), errors.Is(err, service.ErrInvalidParent):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
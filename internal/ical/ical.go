@@ -0,0 +1,136 @@
+// Package ical converte tarefas de e para componentes VTODO do iCalendar
+// (RFC 5545), para que aplicativos de calendário mostrem os prazos.
+//
+// Cada tarefa vira um VTODO com SUMMARY, DESCRIPTION, DUE, STATUS,
+// COMPLETED, PRIORITY, CATEGORIES (as tags) e RRULE (Task.Recurrence). As
+// prioridades "A" a "I" são mapeadas para 1 a 9; as demais letras também
+// viram 9, a mais baixa.
+package ical
+
+import (
+	"botasks/internal/task"
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	utcLayout      = "20060102T150405Z"
+	floatingLayout = "20060102T150405"
+	dateLayout     = "20060102"
+
+	prodID = "-//boTasks//boTasks//PT"
+
+	// maxLineLength é o tamanho máximo de uma linha em octetos, sem o CRLF.
+	maxLineLength = 75
+)
+
+// Calendar é o conteúdo de um arquivo .ics: o nome (X-WR-CALNAME) e as
+// tarefas dos VTODOs.
+type Calendar struct {
+	Name  string
+	Tasks []task.Task
+}
+
+// Write grava o calendário em w. DTSTAMP recebe o instante now.
+func Write(w io.Writer, cal Calendar, now time.Time) error {
+	e := &encoder{w: bufio.NewWriter(w)}
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", prodID)
+	if cal.Name != "" {
+		e.line("X-WR-CALNAME", escape(cal.Name))
+	}
+	for _, t := range cal.Tasks {
+		e.line("BEGIN", "VTODO")
+		e.line("UID", escape(t.ID))
+		e.line("DTSTAMP", now.UTC().Format(utcLayout))
+		if !t.CreatedAt.IsZero() {
+			e.line("CREATED", t.CreatedAt.UTC().Format(utcLayout))
+		}
+		if !t.UpdatedAt.IsZero() {
+			e.line("LAST-MODIFIED", t.UpdatedAt.UTC().Format(utcLayout))
+		}
+		e.line("SUMMARY", escape(t.Title))
+		if t.Description != "" {
+			e.line("DESCRIPTION", escape(t.Description))
+		}
+		if !t.Deadline.IsZero() {
+			e.line("DUE", t.Deadline.UTC().Format(utcLayout))
+		}
+		if t.IsCompleted() {
+			e.line("STATUS", "COMPLETED")
+			e.line("COMPLETED", t.CompletedAt.UTC().Format(utcLayout))
+		} else {
+			e.line("STATUS", "NEEDS-ACTION")
+		}
+		if p := priorityNumber(t.Priority); p != 0 {
+			e.line("PRIORITY", strconv.Itoa(p))
+		}
+		if len(t.Tags) > 0 {
+			tags := make([]string, len(t.Tags))
+			for i, tag := range t.Tags {
+				tags[i] = escape(tag)
+			}
+			e.line("CATEGORIES", strings.Join(tags, ","))
+		}
+		if t.Recurrence != "" {
+			e.line("RRULE", t.Recurrence)
+		}
+		e.line("END", "VTODO")
+	}
+	e.line("END", "VCALENDAR")
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+// line escreve "name:value" dobrando a linha a cada 75 octetos, sem
+// quebrar caracteres UTF-8 (RFC 5545, seção 3.1).
+func (e *encoder) line(name, value string) {
+	if e.err != nil {
+		return
+	}
+	s := name + ":" + value
+	limit := maxLineLength
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		if _, e.err = e.w.WriteString(s[:cut] + "\r\n "); e.err != nil {
+			return
+		}
+		s = s[cut:]
+		limit = maxLineLength - 1 // o espaço da continuação conta
+	}
+	_, e.err = e.w.WriteString(s + "\r\n")
+}
+
+// escape aplica o escape de valores TEXT.
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+func priorityNumber(p string) int {
+	if len(p) != 1 || p[0] < 'A' || p[0] > 'Z' {
+		return 0
+	}
+	return min(int(p[0]-'A')+1, 9)
+}
+
+func priorityLetter(n int) string {
+	if n < 1 || n > 9 {
+		return ""
+	}
+	return string(rune('A' + n - 1))
+}
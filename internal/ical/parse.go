@@ -0,0 +1,214 @@
+package ical
+
+import (
+	"botasks/internal/task"
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Read lê os VTODOs de um arquivo .ics. Outros componentes (VEVENT,
+// VALARM, ...) e propriedades desconhecidas são ignorados. Datas sem hora
+// e horários sem fuso ou com TZID desconhecido são lidos no fuso local. Um
+// VTODO com STATUS COMPLETED sem a propriedade COMPLETED é considerado
+// concluído em now.
+//
+// O UID não é preservado: as tarefas importadas recebem IDs novos.
+func Read(r io.Reader, now time.Time) (Calendar, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return Calendar{}, err
+	}
+
+	var (
+		cal       Calendar
+		stack     []string
+		current   *task.Task
+		completed bool
+	)
+	for _, l := range lines {
+		name, params, value, err := parseLine(l.text)
+		if err != nil {
+			return Calendar{}, fmt.Errorf("line %d: %w", l.number, err)
+		}
+
+		switch name {
+		case "BEGIN":
+			stack = append(stack, strings.ToUpper(value))
+			if strings.EqualFold(value, "VTODO") {
+				current, completed = &task.Task{}, false
+			}
+			continue
+		case "END":
+			if len(stack) == 0 || !strings.EqualFold(stack[len(stack)-1], value) {
+				return Calendar{}, fmt.Errorf("line %d: unexpected END:%s", l.number, value)
+			}
+			stack = stack[:len(stack)-1]
+			if strings.EqualFold(value, "VTODO") {
+				if completed && current.CompletedAt.IsZero() {
+					current.CompletedAt = now
+				}
+				cal.Tasks = append(cal.Tasks, *current)
+				current = nil
+			}
+			continue
+		}
+
+		if len(stack) == 0 {
+			return Calendar{}, fmt.Errorf("line %d: property %s outside of a component", l.number, name)
+		}
+		switch stack[len(stack)-1] {
+		case "VCALENDAR":
+			if name == "X-WR-CALNAME" {
+				cal.Name = unescape(value)
+			}
+		case "VTODO":
+			if err := setProperty(current, &completed, name, params, value); err != nil {
+				return Calendar{}, fmt.Errorf("line %d: %s: %w", l.number, name, err)
+			}
+		}
+	}
+	if len(stack) != 0 {
+		return Calendar{}, fmt.Errorf("missing END:%s", stack[len(stack)-1])
+	}
+	return cal, nil
+}
+
+func setProperty(t *task.Task, completed *bool, name string, params map[string]string, value string) error {
+	var err error
+	switch name {
+	case "SUMMARY":
+		t.Title = unescape(value)
+	case "DESCRIPTION":
+		t.Description = unescape(value)
+	case "DUE":
+		t.Deadline, err = parseTime(value, params)
+	case "CREATED":
+		t.CreatedAt, err = parseTime(value, params)
+	case "LAST-MODIFIED":
+		t.UpdatedAt, err = parseTime(value, params)
+	case "COMPLETED":
+		t.CompletedAt, err = parseTime(value, params)
+		*completed = true
+	case "STATUS":
+		*completed = strings.EqualFold(value, "COMPLETED")
+	case "PRIORITY":
+		var n int
+		n, err = strconv.Atoi(strings.TrimSpace(value))
+		t.Priority = priorityLetter(n)
+	case "CATEGORIES":
+		t.Tags = append(t.Tags, splitText(value)...)
+	case "RRULE":
+		t.Recurrence = value
+	}
+	return err
+}
+
+type contentLine struct {
+	number int // linha física onde a linha lógica começa
+	text   string
+}
+
+// unfold junta as linhas de continuação (iniciadas por espaço ou tab) à
+// linha anterior.
+func unfold(r io.Reader) ([]contentLine, error) {
+	var lines []contentLine
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if (strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1].text += text[1:]
+			continue
+		}
+		if text == "" {
+			continue
+		}
+		lines = append(lines, contentLine{number: n, text: text})
+	}
+	return lines, scanner.Err()
+}
+
+// parseLine separa "NOME;PARAM=valor:valor". Os dois-pontos dentro de
+// parâmetros entre aspas não encerram o nome.
+func parseLine(line string) (name string, params map[string]string, value string, err error) {
+	quoted := false
+	colon := -1
+	for i := 0; i < len(line) && colon < 0; i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				colon = i
+			}
+		}
+	}
+	if colon <= 0 {
+		return "", nil, "", fmt.Errorf("invalid content line %q", line)
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	params = make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(p, "=")
+		params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return strings.ToUpper(parts[0]), params, line[colon+1:], nil
+}
+
+func parseTime(value string, params map[string]string) (time.Time, error) {
+	if params["VALUE"] == "DATE" || len(value) == len(dateLayout) {
+		return time.ParseInLocation(dateLayout, value, time.Local)
+	}
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(utcLayout, value)
+	}
+	loc := time.Local
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	return time.ParseInLocation(floatingLayout, value, loc)
+}
+
+// unescape desfaz o escape de valores TEXT.
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// splitText separa uma lista de valores TEXT nas vírgulas sem escape.
+func splitText(s string) []string {
+	var values []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ',':
+			values = append(values, unescape(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(values, unescape(s[start:]))
+}
@@ -0,0 +1,70 @@
+package ical
+
+import (
+	"botasks/internal/list"
+	"botasks/internal/task"
+	"context"
+	"io"
+	"time"
+)
+
+// Service é a parte do service.TaskListService usada por ExportList e
+// ImportList.
+type Service interface {
+	WithinUnitOfWork(ctx context.Context, fn func(ctx context.Context) error) error
+	GetTaskList(ctx context.Context, taskListID string) (*list.TaskList, error)
+	CreateTaskList(ctx context.Context, name string) (string, error)
+	ImportTask(ctx context.Context, t task.Task, taskListIDs ...string) (string, error)
+}
+
+// ExportList grava a lista como um calendário com um VTODO por tarefa.
+func ExportList(ctx context.Context, svc Service, taskListID string, w io.Writer, now time.Time) error {
+	taskList, err := svc.GetTaskList(ctx, taskListID)
+	if err != nil {
+		return err
+	}
+	cal := Calendar{Name: taskList.Name, Tasks: make([]task.Task, len(taskList.Tasks))}
+	for i, t := range taskList.Tasks {
+		cal.Tasks[i] = t.Task
+	}
+	return Write(w, cal, now)
+}
+
+// ImportList cria as tarefas dos VTODOs na lista taskListID. Se ela for
+// vazia, uma lista nova é criada com o nome do calendário (ou "Imported").
+// Retorna o ID da lista e o número de tarefas importadas. VTODOs sem
+// SUMMARY são ignorados. Tudo é criado em uma única unidade de trabalho: se
+// alguma criação falhar, nada é criado, nem a lista nova.
+func ImportList(ctx context.Context, svc Service, r io.Reader, taskListID string, now time.Time) (string, int, error) {
+	cal, err := Read(r, now)
+	if err != nil {
+		return "", 0, err
+	}
+
+	n := 0
+	err = svc.WithinUnitOfWork(ctx, func(ctx context.Context) error {
+		if taskListID == "" {
+			name := cal.Name
+			if name == "" {
+				name = "Imported"
+			}
+			if taskListID, err = svc.CreateTaskList(ctx, name); err != nil {
+				return err
+			}
+		}
+		for _, t := range cal.Tasks {
+			if t.Title == "" {
+				continue
+			}
+			if _, err := svc.ImportTask(ctx, t, taskListID); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	return taskListID, n, nil
+}
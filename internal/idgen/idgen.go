@@ -0,0 +1,153 @@
+package idgen
+
+import (
+	"botasks/internal/clock"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/xid"
+)
+
+// Generator gera identificadores únicos para tarefas e listas.
+type Generator interface {
+	NewID() string
+}
+
+// XID gera identificadores no formato xid. É o gerador padrão.
+type XID struct{}
+
+// NewID retorna um novo xid.
+func (XID) NewID() string {
+	return xid.New().String()
+}
+
+// Default retorna o gerador padrão do projeto.
+func Default() Generator {
+	return XID{}
+}
+
+// UUIDv4 gera UUIDs aleatórios (RFC 9562, versão 4).
+type UUIDv4 struct {
+	// Rand é a fonte de aleatoriedade. Se nil, usa crypto/rand.
+	Rand io.Reader
+}
+
+// NewID retorna um novo UUIDv4.
+func (g UUIDv4) NewID() string {
+	var b [16]byte
+	readRandom(g.Rand, b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return formatUUID(b)
+}
+
+// UUIDv7 gera UUIDs ordenáveis pelo tempo (RFC 9562, versão 7).
+type UUIDv7 struct {
+	// Clock fornece o timestamp. Se nil, usa o relógio do sistema.
+	Clock clock.Clock
+	// Rand é a fonte de aleatoriedade. Se nil, usa crypto/rand.
+	Rand io.Reader
+}
+
+// NewID retorna um novo UUIDv7.
+func (g UUIDv7) NewID() string {
+	var b [16]byte
+	readRandom(g.Rand, b[6:])
+	ms := uint64(nowOrDefault(g.Clock).UnixMilli())
+	b[0] = byte(ms >> 40)
+	b[1] = byte(ms >> 32)
+	b[2] = byte(ms >> 24)
+	b[3] = byte(ms >> 16)
+	b[4] = byte(ms >> 8)
+	b[5] = byte(ms)
+	b[6] = (b[6] & 0x0f) | 0x70
+	b[8] = (b[8] & 0x3f) | 0x80
+	return formatUUID(b)
+}
+
+// ULID gera identificadores ULID: 48 bits de timestamp em milissegundos
+// seguidos de 80 bits aleatórios, codificados em base32 de Crockford.
+type ULID struct {
+	// Clock fornece o timestamp. Se nil, usa o relógio do sistema.
+	Clock clock.Clock
+	// Rand é a fonte de aleatoriedade. Se nil, usa crypto/rand.
+	Rand io.Reader
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewID retorna um novo ULID com 26 caracteres.
+func (g ULID) NewID() string {
+	var b [16]byte
+	readRandom(g.Rand, b[6:])
+	ms := uint64(nowOrDefault(g.Clock).UnixMilli())
+	binary.BigEndian.PutUint16(b[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(b[2:6], uint32(ms))
+
+	// 128 bits codificados em 26 símbolos de 5 bits (os 2 bits mais altos sobram)
+	hi := binary.BigEndian.Uint64(b[0:8])
+	lo := binary.BigEndian.Uint64(b[8:16])
+	out := make([]byte, 26)
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out)
+}
+
+// Sequential gera identificadores determinísticos e sequenciais, como
+// "task-1", "task-2", ... Útil em testes que precisam verificar IDs exatos.
+type Sequential struct {
+	prefix string
+	next   uint64
+	mu     sync.Mutex
+}
+
+// NewSequential cria um gerador sequencial com o prefixo informado.
+func NewSequential(prefix string) *Sequential {
+	return &Sequential{prefix: prefix}
+}
+
+// NewID retorna o próximo identificador da sequência.
+func (g *Sequential) NewID() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.next++
+	return fmt.Sprintf("%s%d", g.prefix, g.next)
+}
+
+func readRandom(r io.Reader, b []byte) {
+	if r == nil {
+		r = rand.Reader
+	}
+	if _, err := io.ReadFull(r, b); err != nil {
+		panic("idgen: falha ao ler bytes aleatórios: " + err.Error())
+	}
+}
+
+func nowOrDefault(c clock.Clock) time.Time {
+	if c == nil {
+		c = clock.New()
+	}
+	return c.Now()
+}
+
+func formatUUID(b [16]byte) string {
+	var sb strings.Builder
+	sb.Grow(36)
+	for i, part := range [][]byte{b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]} {
+		if i > 0 {
+			sb.WriteByte('-')
+		}
+		sb.WriteString(hex.EncodeToString(part))
+	}
+	return sb.String()
+}
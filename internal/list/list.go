@@ -1,9 +1,9 @@
 package list
 
 import (
+	"botasks/internal/idgen"
 	"botasks/internal/task"
-
-	"github.com/rs/xid"
+	"time"
 )
 
 type Task struct {
@@ -11,15 +11,40 @@ type Task struct {
 }
 
 type TaskList struct {
-	ID    string
-	Name  string
-	Tasks []Task
+	ID        string
+	Name      string
+	Tasks     []Task
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	CreatedBy string
+	UpdatedBy string
+	Version   int               // incrementada pelo repositório a cada Update
+	Metadata  map[string]string // dados de origem, como o ID em outra ferramenta
+}
+
+type options struct {
+	ids idgen.Generator
+}
+
+// Option configura a criação de uma lista de tarefas.
+type Option func(*options)
+
+// WithIDGenerator define o gerador de IDs da lista.
+func WithIDGenerator(g idgen.Generator) Option {
+	return func(o *options) {
+		o.ids = g
+	}
 }
 
 // CreateTaskList cria uma nova lista de tarefas com o nome especificado
-func CreateTaskList(name string) TaskList {
+func CreateTaskList(name string, opts ...Option) TaskList {
+	o := options{ids: idgen.Default()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	newList := TaskList{
-		ID:    xid.New().String(),
+		ID:    o.ids.NewID(),
 		Name:  name,
 		Tasks: []Task{},
 	}
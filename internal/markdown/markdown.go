@@ -0,0 +1,181 @@
+// Package markdown converte listas de tarefas de e para checklists
+// Markdown no estilo do GitHub:
+//
+//	# Casa
+//
+//	- [ ] Arrumar a cozinha — due 2024-05-10
+//	  - [x] Lavar a louça
+//	  - [ ] Varrer
+//	    Embaixo da mesa também.
+//
+// Subtarefas ficam indentadas sob a tarefa-mãe (Task.ParentID). Linhas de
+// texto indentadas sob um item formam a descrição dele.
+package markdown
+
+import (
+	"botasks/internal/task"
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	dateLayout     = "2006-01-02"
+	dateTimeLayout = "2006-01-02 15:04"
+	indentWidth    = 2
+)
+
+// Item é um item do checklist.
+type Item struct {
+	Task   task.Task
+	Parent int // índice do item pai em Checklist.Items, ou -1
+}
+
+// Checklist é um documento: o título (o primeiro cabeçalho) e os itens,
+// com cada pai antes dos seus filhos.
+type Checklist struct {
+	Title string
+	Items []Item
+}
+
+// Write grava o checklist. Os prazos são escritos no fuso local, só com a
+// data quando caem à meia-noite.
+func Write(w io.Writer, c Checklist) error {
+	bw := bufio.NewWriter(w)
+	if c.Title != "" {
+		fmt.Fprintf(bw, "# %s\n\n", c.Title)
+	}
+	depth := make([]int, len(c.Items))
+	for i, it := range c.Items {
+		if it.Parent >= 0 && it.Parent < i {
+			depth[i] = depth[it.Parent] + 1
+		}
+		indent := strings.Repeat(" ", depth[i]*indentWidth)
+
+		check := " "
+		if it.Task.IsCompleted() {
+			check = "x"
+		}
+		fmt.Fprintf(bw, "%s- [%s] %s", indent, check, oneLine(it.Task.Title))
+		if !it.Task.Deadline.IsZero() {
+			fmt.Fprintf(bw, " — due %s", formatDue(it.Task.Deadline))
+		}
+		bw.WriteString("\n")
+
+		for _, line := range strings.Split(it.Task.Description, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				fmt.Fprintf(bw, "%s%s%s\n", indent, strings.Repeat(" ", indentWidth), line)
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+var (
+	itemPattern    = regexp.MustCompile(`^([ \t]*)[-*+] \[([ xX])\] (.*)$`)
+	headingPattern = regexp.MustCompile(`^#{1,6} +(.*?)[ #]*$`)
+	duePattern     = regexp.MustCompile(`\s+(?:—|–|--|-)\s+due\s+(\d{4}-\d{2}-\d{2}(?: \d{2}:\d{2})?)\s*$`)
+)
+
+// Parse lê os itens de checklist do documento. Outras linhas são ignoradas,
+// exceto o primeiro cabeçalho, que vira o título, e as linhas de texto
+// indentadas sob um item, que formam a descrição dele. Itens marcados são
+// considerados concluídos em now. Tabs valem quatro espaços.
+func Parse(r io.Reader, now time.Time) (Checklist, error) {
+	var c Checklist
+	// stack guarda os índices dos itens abertos, do mais externo ao atual
+	var stack []int
+	indents := make(map[int]int)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := itemPattern.FindStringSubmatch(line); m != nil && strings.TrimSpace(m[3]) != "" {
+			indent := width(m[1])
+			for len(stack) > 0 && indents[stack[len(stack)-1]] >= indent {
+				stack = stack[:len(stack)-1]
+			}
+			it := Item{Parent: -1, Task: parseTitle(m[3])}
+			if len(stack) > 0 {
+				it.Parent = stack[len(stack)-1]
+			}
+			if m[2] != " " {
+				it.Task.CompletedAt = now
+			}
+			indents[len(c.Items)] = indent
+			stack = append(stack, len(c.Items))
+			c.Items = append(c.Items, it)
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if m := headingPattern.FindStringSubmatch(trimmed); m != nil && c.Title == "" && len(c.Items) == 0 {
+			c.Title = m[1]
+			continue
+		}
+		if trimmed == "" {
+			continue
+		}
+		// O texto pertence ao item aberto mais interno com indentação menor
+		indent := width(line[:len(line)-len(strings.TrimLeft(line, " \t"))])
+		for len(stack) > 0 && indents[stack[len(stack)-1]] >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			continue
+		}
+		t := &c.Items[stack[len(stack)-1]].Task
+		if t.Description != "" {
+			t.Description += "\n"
+		}
+		t.Description += trimmed
+	}
+	if err := scanner.Err(); err != nil {
+		return Checklist{}, err
+	}
+	return c, nil
+}
+
+func parseTitle(text string) task.Task {
+	var t task.Task
+	if m := duePattern.FindStringSubmatchIndex(text); m != nil {
+		due := text[m[2]:m[3]]
+		layout := dateLayout
+		if len(due) == len(dateTimeLayout) {
+			layout = dateTimeLayout
+		}
+		if d, err := time.ParseInLocation(layout, due, time.Local); err == nil {
+			t.Deadline = d
+			text = text[:m[0]]
+		}
+	}
+	t.Title = strings.TrimSpace(text)
+	return t
+}
+
+func formatDue(d time.Time) string {
+	d = d.In(time.Local)
+	if d.Hour() == 0 && d.Minute() == 0 {
+		return d.Format(dateLayout)
+	}
+	return d.Format(dateTimeLayout)
+}
+
+func width(indent string) int {
+	n := 0
+	for _, r := range indent {
+		if r == '\t' {
+			n += 4
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
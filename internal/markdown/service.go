@@ -0,0 +1,106 @@
+package markdown
+
+import (
+	"botasks/internal/list"
+	"botasks/internal/task"
+	"context"
+	"io"
+	"time"
+)
+
+// Service é a parte do service.TaskListService usada por ExportList e
+// ImportList.
+type Service interface {
+	WithinUnitOfWork(ctx context.Context, fn func(ctx context.Context) error) error
+	GetTaskList(ctx context.Context, taskListID string) (*list.TaskList, error)
+	CreateTaskList(ctx context.Context, name string) (string, error)
+	ImportTask(ctx context.Context, t task.Task, taskListIDs ...string) (string, error)
+}
+
+// ExportList grava a lista como checklist, com as subtarefas sob a
+// tarefa-mãe. Subtarefas cuja mãe não está na lista aparecem no primeiro
+// nível.
+func ExportList(ctx context.Context, svc Service, taskListID string, w io.Writer) error {
+	taskList, err := svc.GetTaskList(ctx, taskListID)
+	if err != nil {
+		return err
+	}
+
+	inList := make(map[string]bool, len(taskList.Tasks))
+	children := make(map[string][]task.Task)
+	for _, t := range taskList.Tasks {
+		inList[t.ID] = true
+	}
+	var roots []task.Task
+	for _, t := range taskList.Tasks {
+		if t.ParentID != "" && t.ParentID != t.ID && inList[t.ParentID] {
+			children[t.ParentID] = append(children[t.ParentID], t.Task)
+		} else {
+			roots = append(roots, t.Task)
+		}
+	}
+
+	c := Checklist{Title: taskList.Name}
+	visited := make(map[string]bool)
+	var add func(t task.Task, parent int)
+	add = func(t task.Task, parent int) {
+		if visited[t.ID] {
+			return
+		}
+		visited[t.ID] = true
+		c.Items = append(c.Items, Item{Task: t, Parent: parent})
+		index := len(c.Items) - 1
+		for _, child := range children[t.ID] {
+			add(child, index)
+		}
+	}
+	for _, t := range roots {
+		add(t, -1)
+	}
+	// Tarefas presas em um ciclo de ParentID não são alcançadas pelas raízes
+	for _, t := range taskList.Tasks {
+		add(t.Task, -1)
+	}
+	return Write(w, c)
+}
+
+// ImportList cria as tarefas do checklist na lista taskListID, ligando cada
+// subtarefa à sua mãe. Se taskListID for vazio, uma lista nova é criada com
+// o título do documento (ou "Checklist"). Retorna o ID da lista e o número
+// de tarefas criadas. Tudo é criado em uma única unidade de trabalho: se
+// alguma criação falhar, nada é criado, nem a lista nova.
+func ImportList(ctx context.Context, svc Service, r io.Reader, taskListID string, now time.Time) (string, int, error) {
+	c, err := Parse(r, now)
+	if err != nil {
+		return "", 0, err
+	}
+
+	err = svc.WithinUnitOfWork(ctx, func(ctx context.Context) error {
+		if taskListID == "" {
+			name := c.Title
+			if name == "" {
+				name = "Checklist"
+			}
+			var err error
+			if taskListID, err = svc.CreateTaskList(ctx, name); err != nil {
+				return err
+			}
+		}
+		ids := make([]string, len(c.Items))
+		for i, it := range c.Items {
+			t := it.Task
+			if it.Parent >= 0 {
+				t.ParentID = ids[it.Parent]
+			}
+			var err error
+			if ids[i], err = svc.ImportTask(ctx, t, taskListID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	return taskListID, len(c.Items), nil
+}
@@ -0,0 +1,111 @@
+// Package migrate lê as exportações JSON de outros gerenciadores de tarefas
+// (Taskwarrior, Trello) e as importa como listas e tarefas.
+//
+// Os IDs originais ficam em Metadata, com chaves prefixadas pela
+// ferramenta ("taskwarrior.uuid", "trello.card_id", ...). Os campos da
+// exportação que não têm correspondência são listados em Result.Unmapped,
+// para que se saiba o que ficou para trás.
+package migrate
+
+import (
+	"botasks/internal/list"
+	"botasks/internal/task"
+	"context"
+	"encoding/json"
+	"sort"
+)
+
+// Item é uma tarefa a importar.
+type Item struct {
+	Task   task.Task
+	Parent int // índice da tarefa-mãe em List.Items, ou -1
+}
+
+// List é uma lista a importar, com as tarefas na ordem de origem. Cada mãe
+// vem antes das suas subtarefas.
+type List struct {
+	List  list.TaskList // sem o campo Tasks
+	Items []Item
+}
+
+// Result é o conteúdo lido de uma exportação.
+type Result struct {
+	Lists []List
+	// Unmapped são os campos encontrados que não foram importados, como
+	// "task.urgency" ou "card.idMembers", sem repetição e em ordem.
+	Unmapped []string
+	// Skipped conta os registros ignorados: tarefas excluídas, cartões e
+	// listas arquivados, itens sem título.
+	Skipped int
+}
+
+// Service é a parte do service.TaskListService usada por Import.
+type Service interface {
+	WithinUnitOfWork(ctx context.Context, fn func(ctx context.Context) error) error
+	ImportTaskList(ctx context.Context, taskList list.TaskList) (string, error)
+	ImportTask(ctx context.Context, t task.Task, taskListIDs ...string) (string, error)
+}
+
+// Import cria as listas e tarefas do resultado, sempre em listas novas.
+// Retorna os IDs das listas criadas e o número de tarefas. Tudo é criado em
+// uma única unidade de trabalho: se alguma criação falhar, nada é criado.
+func Import(ctx context.Context, svc Service, res Result) ([]string, int, error) {
+	var taskListIDs []string
+	n := 0
+	err := svc.WithinUnitOfWork(ctx, func(ctx context.Context) error {
+		for _, l := range res.Lists {
+			taskListID, err := svc.ImportTaskList(ctx, l.List)
+			if err != nil {
+				return err
+			}
+			taskListIDs = append(taskListIDs, taskListID)
+
+			ids := make([]string, len(l.Items))
+			for i, it := range l.Items {
+				t := it.Task
+				if it.Parent >= 0 {
+					t.ParentID = ids[it.Parent]
+				}
+				if ids[i], err = svc.ImportTask(ctx, t, taskListID); err != nil {
+					return err
+				}
+				n++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return taskListIDs, n, nil
+}
+
+// unmapped registra os campos desconhecidos de objetos JSON.
+type unmapped map[string]bool
+
+// check adiciona as chaves de raw que não estão em known, prefixadas por
+// kind ("task.", "card.", ...).
+func (u unmapped) check(kind string, raw json.RawMessage, known ...string) {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(raw, &fields) != nil {
+		return
+	}
+	isKnown := make(map[string]bool, len(known))
+	for _, k := range known {
+		isKnown[k] = true
+	}
+	for k := range fields {
+		if !isKnown[k] {
+			u[kind+"."+k] = true
+		}
+	}
+}
+
+func (u unmapped) sorted() []string {
+	fields := make([]string, 0, len(u))
+	for f := range u {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	return fields
+}
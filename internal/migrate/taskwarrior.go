@@ -0,0 +1,156 @@
+package migrate
+
+import (
+	"botasks/internal/list"
+	"botasks/internal/task"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const taskwarriorTimeLayout = "20060102T150405Z"
+
+type taskwarriorTask struct {
+	UUID        string   `json:"uuid"`
+	Description string   `json:"description"`
+	Status      string   `json:"status"`
+	Entry       string   `json:"entry"`
+	End         string   `json:"end"`
+	Due         string   `json:"due"`
+	Project     string   `json:"project"`
+	Tags        []string `json:"tags"`
+	Priority    string   `json:"priority"`
+	Annotations []struct {
+		Description string `json:"description"`
+	} `json:"annotations"`
+}
+
+// taskwarriorKnown são os campos tratados. id, urgency e modified são
+// valores derivados ou controlados pelo próprio Taskwarrior e não são
+// reportados.
+var taskwarriorKnown = []string{
+	"uuid", "description", "status", "entry", "end", "due", "project", "tags",
+	"priority", "annotations", "id", "urgency", "modified",
+}
+
+// ReadTaskwarrior lê a saída de "task export": um array JSON ou, nas
+// versões antigas, um objeto por linha. Cada projeto vira uma lista, e as
+// tarefas sem projeto vão para a lista "Taskwarrior". Tarefas excluídas,
+// modelos de recorrência e tarefas sem descrição são ignorados. Tarefas concluídas sem "end" são
+// consideradas concluídas em now.
+func ReadTaskwarrior(r io.Reader, now time.Time) (Result, error) {
+	raws, err := readJSONObjects(r)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var res Result
+	u := make(unmapped)
+	byProject := make(map[string]int)
+	for i, raw := range raws {
+		var tw taskwarriorTask
+		if err := json.Unmarshal(raw, &tw); err != nil {
+			return Result{}, fmt.Errorf("task %d: %w", i+1, err)
+		}
+		u.check("task", raw, taskwarriorKnown...)
+		if tw.Status == "deleted" || tw.Status == "recurring" || strings.TrimSpace(tw.Description) == "" {
+			res.Skipped++
+			continue
+		}
+
+		t, err := tw.task(now)
+		if err != nil {
+			return Result{}, fmt.Errorf("task %s: %w", tw.UUID, err)
+		}
+		project := tw.Project
+		if project == "" {
+			project = "Taskwarrior"
+		}
+		li, ok := byProject[project]
+		if !ok {
+			li = len(res.Lists)
+			byProject[project] = li
+			res.Lists = append(res.Lists, List{List: list.TaskList{Name: project}})
+			if tw.Project != "" {
+				res.Lists[li].List.Metadata = map[string]string{"taskwarrior.project": tw.Project}
+			}
+		}
+		res.Lists[li].Items = append(res.Lists[li].Items, Item{Task: t, Parent: -1})
+	}
+	res.Unmapped = u.sorted()
+	return res, nil
+}
+
+func (tw taskwarriorTask) task(now time.Time) (task.Task, error) {
+	t := task.Task{
+		Title:    tw.Description,
+		Tags:     tw.Tags,
+		Metadata: map[string]string{"taskwarrior.uuid": tw.UUID},
+	}
+	switch tw.Priority {
+	case "H":
+		t.Priority = "A"
+	case "M":
+		t.Priority = "B"
+	case "L":
+		t.Priority = "C"
+	}
+	var notes []string
+	for _, a := range tw.Annotations {
+		notes = append(notes, a.Description)
+	}
+	t.Description = strings.Join(notes, "\n")
+
+	var err error
+	if t.CreatedAt, err = parseTaskwarriorTime(tw.Entry); err != nil {
+		return task.Task{}, err
+	}
+	if t.Deadline, err = parseTaskwarriorTime(tw.Due); err != nil {
+		return task.Task{}, err
+	}
+	if tw.Status == "completed" {
+		if t.CompletedAt, err = parseTaskwarriorTime(tw.End); err != nil {
+			return task.Task{}, err
+		}
+		if t.CompletedAt.IsZero() {
+			t.CompletedAt = now
+		}
+	}
+	return t, nil
+}
+
+func parseTaskwarriorTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(taskwarriorTimeLayout, s)
+}
+
+// readJSONObjects lê um array JSON de objetos ou uma sequência de objetos.
+func readJSONObjects(r io.Reader) ([]json.RawMessage, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var raws []json.RawMessage
+	if data[0] == '[' {
+		err := json.Unmarshal(data, &raws)
+		return raws, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		raws = append(raws, raw)
+	}
+	return raws, nil
+}
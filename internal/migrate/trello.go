@@ -0,0 +1,212 @@
+package migrate
+
+import (
+	"botasks/internal/list"
+	"botasks/internal/task"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+type trelloBoard struct {
+	ID         string            `json:"id"`
+	Lists      []json.RawMessage `json:"lists"`
+	Cards      []json.RawMessage `json:"cards"`
+	Checklists []json.RawMessage `json:"checklists"`
+}
+
+type trelloList struct {
+	ID     string  `json:"id"`
+	Name   string  `json:"name"`
+	Closed bool    `json:"closed"`
+	Pos    float64 `json:"pos"`
+}
+
+type trelloCard struct {
+	ID               string  `json:"id"`
+	Name             string  `json:"name"`
+	Desc             string  `json:"desc"`
+	Due              string  `json:"due"`
+	DueComplete      bool    `json:"dueComplete"`
+	Closed           bool    `json:"closed"`
+	IDList           string  `json:"idList"`
+	Pos              float64 `json:"pos"`
+	URL              string  `json:"url"`
+	DateLastActivity string  `json:"dateLastActivity"`
+	Labels           []struct {
+		Name  string `json:"name"`
+		Color string `json:"color"`
+	} `json:"labels"`
+}
+
+type trelloChecklist struct {
+	ID         string            `json:"id"`
+	IDCard     string            `json:"idCard"`
+	Pos        float64           `json:"pos"`
+	CheckItems []json.RawMessage `json:"checkItems"`
+}
+
+type trelloCheckItem struct {
+	ID    string  `json:"id"`
+	Name  string  `json:"name"`
+	State string  `json:"state"`
+	Pos   float64 `json:"pos"`
+}
+
+// Campos tratados de cada objeto. Os identificadores de ligação (idBoard,
+// idChecklists, ...) e pos só definem a estrutura e a ordem.
+var (
+	trelloBoardKnown     = []string{"id", "name", "lists", "cards", "checklists"}
+	trelloListKnown      = []string{"id", "name", "closed", "pos", "idBoard"}
+	trelloCardKnown      = []string{"id", "name", "desc", "due", "dueComplete", "closed", "idList", "pos", "url", "shortUrl", "dateLastActivity", "labels", "idBoard", "idChecklists", "idLabels"}
+	trelloChecklistKnown = []string{"id", "idCard", "name", "pos", "checkItems", "idBoard"}
+	trelloCheckItemKnown = []string{"id", "name", "state", "pos", "idChecklist"}
+)
+
+// ReadTrello lê a exportação JSON de um quadro do Trello. Cada lista aberta
+// vira uma lista, e os cartões viram tarefas na ordem do quadro. Os itens
+// dos checklists de um cartão viram subtarefas dele. Listas e cartões
+// arquivados, assim como cartões e itens sem nome, são ignorados; os itens
+// de um cartão ignorado também. Cartões com o prazo marcado como cumprido são
+// concluídos na última atividade, e itens marcados, em now.
+func ReadTrello(r io.Reader, now time.Time) (Result, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return Result{}, err
+	}
+	var board trelloBoard
+	if err := json.Unmarshal(raw, &board); err != nil {
+		return Result{}, err
+	}
+
+	var res Result
+	u := make(unmapped)
+	u.check("board", raw, trelloBoardKnown...)
+
+	var lists []trelloList
+	for _, rl := range board.Lists {
+		var l trelloList
+		if err := json.Unmarshal(rl, &l); err != nil {
+			return Result{}, fmt.Errorf("list: %w", err)
+		}
+		u.check("list", rl, trelloListKnown...)
+		lists = append(lists, l)
+	}
+	sort.SliceStable(lists, func(i, j int) bool { return lists[i].Pos < lists[j].Pos })
+
+	itemsByCard := make(map[string][]trelloCheckItem)
+	var checklists []trelloChecklist
+	for _, rc := range board.Checklists {
+		var c trelloChecklist
+		if err := json.Unmarshal(rc, &c); err != nil {
+			return Result{}, fmt.Errorf("checklist: %w", err)
+		}
+		u.check("checklist", rc, trelloChecklistKnown...)
+		checklists = append(checklists, c)
+	}
+	sort.SliceStable(checklists, func(i, j int) bool { return checklists[i].Pos < checklists[j].Pos })
+	for _, c := range checklists {
+		var items []trelloCheckItem
+		for _, ri := range c.CheckItems {
+			var it trelloCheckItem
+			if err := json.Unmarshal(ri, &it); err != nil {
+				return Result{}, fmt.Errorf("checklist %s: %w", c.ID, err)
+			}
+			u.check("checkItem", ri, trelloCheckItemKnown...)
+			items = append(items, it)
+		}
+		sort.SliceStable(items, func(i, j int) bool { return items[i].Pos < items[j].Pos })
+		itemsByCard[c.IDCard] = append(itemsByCard[c.IDCard], items...)
+	}
+
+	cardsByList := make(map[string][]trelloCard)
+	for _, rc := range board.Cards {
+		var c trelloCard
+		if err := json.Unmarshal(rc, &c); err != nil {
+			return Result{}, fmt.Errorf("card: %w", err)
+		}
+		u.check("card", rc, trelloCardKnown...)
+		cardsByList[c.IDList] = append(cardsByList[c.IDList], c)
+	}
+
+	for _, l := range lists {
+		cards := cardsByList[l.ID]
+		if l.Closed {
+			res.Skipped += 1 + len(cards)
+			continue
+		}
+		sort.SliceStable(cards, func(i, j int) bool { return cards[i].Pos < cards[j].Pos })
+
+		ml := List{List: list.TaskList{
+			Name:     l.Name,
+			Metadata: map[string]string{"trello.board_id": board.ID, "trello.list_id": l.ID},
+		}}
+		for _, c := range cards {
+			if c.Closed || strings.TrimSpace(c.Name) == "" {
+				res.Skipped += 1 + len(itemsByCard[c.ID])
+				continue
+			}
+			t, err := c.task()
+			if err != nil {
+				return Result{}, fmt.Errorf("card %s: %w", c.ID, err)
+			}
+			if c.DueComplete && t.CompletedAt.IsZero() {
+				t.CompletedAt = now
+			}
+			parent := len(ml.Items)
+			ml.Items = append(ml.Items, Item{Task: t, Parent: -1})
+			for _, it := range itemsByCard[c.ID] {
+				if strings.TrimSpace(it.Name) == "" {
+					res.Skipped++
+					continue
+				}
+				sub := task.Task{
+					Title:    it.Name,
+					Metadata: map[string]string{"trello.check_item_id": it.ID},
+				}
+				if it.State == "complete" {
+					sub.CompletedAt = now
+				}
+				ml.Items = append(ml.Items, Item{Task: sub, Parent: parent})
+			}
+		}
+		res.Lists = append(res.Lists, ml)
+	}
+	res.Unmapped = u.sorted()
+	return res, nil
+}
+
+func (c trelloCard) task() (task.Task, error) {
+	t := task.Task{
+		Title:       c.Name,
+		Description: c.Desc,
+		Metadata:    map[string]string{"trello.card_id": c.ID},
+	}
+	if c.URL != "" {
+		t.Metadata["trello.url"] = c.URL
+	}
+	for _, l := range c.Labels {
+		switch {
+		case l.Name != "":
+			t.Tags = append(t.Tags, l.Name)
+		case l.Color != "":
+			t.Tags = append(t.Tags, l.Color)
+		}
+	}
+
+	var err error
+	if c.Due != "" {
+		if t.Deadline, err = time.Parse(time.RFC3339, c.Due); err != nil {
+			return task.Task{}, err
+		}
+	}
+	if c.DueComplete && c.DateLastActivity != "" {
+		if t.CompletedAt, err = time.Parse(time.RFC3339, c.DateLastActivity); err != nil {
+			return task.Task{}, err
+		}
+	}
+	return t, nil
+}
@@ -0,0 +1,261 @@
+// Package quickadd interpreta o texto livre de uma tarefa, em inglês ou
+// português, como
+//
+//	Pay rent every month on the 5th !high #finance
+//	revisar PR amanhã às 10h
+//
+// separando do título o prazo, a repetição, a prioridade e as tags. A CLI,
+// o bot, o Slack e a API usam o mesmo parser.
+//
+// Datas relativas ("tomorrow", "amanhã", "next friday", "na sexta",
+// "in 2 hours", "daqui a 3 dias"), dias do mês ("on the 5th", "dia 5"),
+// datas ("May 10", "10 de maio", "2024-05-10") e horas ("at 3pm", "18:30",
+// "às 10h") podem aparecer em qualquer ponto do texto. Palavras que não
+// formam uma dessas expressões ficam no título.
+package quickadd
+
+import (
+	"botasks/internal/task"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Result é o texto interpretado.
+type Result struct {
+	Title      string
+	Deadline   time.Time // zero se o texto não tiver data nem hora
+	Recurrence string    // regra RRULE, como em task.Task.Recurrence
+	Priority   string    // "A" a "Z"
+	Tags       []string
+}
+
+// Options retorna as opções de AddTask para prioridade, tags e repetição.
+func (r Result) Options() []task.Option {
+	return []task.Option{
+		task.WithPriority(r.Priority),
+		task.WithTags(r.Tags...),
+		task.WithRecurrence(r.Recurrence),
+	}
+}
+
+// DeadlineOr retorna o prazo, ou def se o texto não tiver nenhum.
+func (r Result) DeadlineOr(def time.Time) time.Time {
+	if r.Deadline.IsZero() {
+		return def
+	}
+	return r.Deadline
+}
+
+// Parse interpreta o texto em relação a now, cujo fuso vale para as datas
+// e horas. Sem hora, o prazo é o fim do dia (23:59); só com a hora, a
+// próxima ocorrência dela. Um dia da semana ou do mês sem outra indicação
+// é o próximo a partir de hoje.
+func Parse(text string, now time.Time) Result {
+	p := &parser{now: now, today: midnight(now), rest: make(map[int]bool)}
+	for _, raw := range strings.Fields(text) {
+		p.words = append(p.words, word{raw: raw, norm: normalize(raw)})
+	}
+
+	var title []string
+	for p.i < len(p.words) {
+		if n := p.match(); n > 0 {
+			p.i += n
+			continue
+		}
+		title = append(title, p.words[p.i].raw)
+		p.i++
+	}
+	p.res.Title = strings.TrimRight(strings.Join(title, " "), " ,;:-")
+	p.res.Deadline = p.deadline()
+	p.res.Recurrence = p.recurrenceRule()
+	return p.res
+}
+
+type word struct {
+	raw  string
+	norm string // minúsculas, sem acentos e sem pontuação no fim
+}
+
+// anchor indica como avançar um prazo que já passou: para a próxima semana,
+// o próximo mês ou o próximo ano.
+type anchor int
+
+const (
+	noAnchor anchor = iota
+	weekAnchor
+	monthAnchor
+	yearAnchor
+)
+
+type parser struct {
+	now, today time.Time
+	words      []word
+	i          int
+
+	date    time.Time // meia-noite do dia, se hasDate
+	hasDate bool
+	anchor  anchor
+	hour    int
+	minute  int
+	hasTime bool
+	exact   time.Time // instante completo, como em "in 2 hours"
+
+	rule rule
+	res  Result
+
+	rest map[int]bool // resultados de onlyExpressions por posição
+}
+
+// at retorna a palavra normalizada na posição i+k, ou "".
+func (p *parser) at(k int) string {
+	if p.i+k < len(p.words) {
+		return p.words[p.i+k].norm
+	}
+	return ""
+}
+
+// match tenta reconhecer uma expressão a partir da palavra atual e retorna
+// quantas palavras ela ocupa.
+func (p *parser) match() int {
+	for _, m := range []func() int{
+		p.tagOrPriority,
+		p.recurrence,
+		p.relative,
+		p.dayWord,
+		p.partOfDay,
+		p.weekday,
+		p.monthDate,
+		p.dayOfMonth,
+		p.isoDate,
+		p.clock,
+	} {
+		if n := m(); n > 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+func (p *parser) tagOrPriority() int {
+	raw := strings.TrimRight(p.words[p.i].raw, ",.;:")
+	if tag, ok := strings.CutPrefix(raw, "#"); ok && tag != "" {
+		p.res.Tags = append(p.res.Tags, tag)
+		return 1
+	}
+	level, ok := strings.CutPrefix(raw, "!")
+	if !ok || level == "" {
+		return 0
+	}
+	if priority, ok := priorities[normalize(level)]; ok {
+		p.res.Priority = priority
+		return 1
+	}
+	if len(level) == 1 && strings.ToUpper(level) >= "A" && strings.ToUpper(level) <= "Z" {
+		p.res.Priority = strings.ToUpper(level)
+		return 1
+	}
+	return 0
+}
+
+// deadline combina o dia e a hora reconhecidos.
+func (p *parser) deadline() time.Time {
+	if !p.exact.IsZero() {
+		return p.exact
+	}
+	if !p.hasDate && !p.hasTime && p.rule.freq == "" {
+		return time.Time{}
+	}
+	day := p.today
+	if p.hasDate {
+		day = p.date
+	}
+	hour, minute := 23, 59
+	if p.hasTime {
+		hour, minute = p.hour, p.minute
+	}
+	d := at(day, hour, minute)
+	if len(p.rule.byDay) > 0 && !p.hasDate {
+		// A primeira ocorrência é o próximo dia da regra
+		for i := 0; i < 8 && !(d.After(p.now) && slices.Contains(p.rule.byDay, dayCodes[d.Weekday()])); i++ {
+			d = d.AddDate(0, 0, 1)
+		}
+		return d
+	}
+	if d.After(p.now) {
+		return d
+	}
+	switch {
+	case !p.hasDate:
+		return d.AddDate(0, 0, 1)
+	case p.anchor == weekAnchor:
+		return d.AddDate(0, 0, 7)
+	case p.anchor == monthAnchor:
+		next, _ := nextMonthDay(p.today.AddDate(0, 0, 1), day.Day())
+		return at(next, hour, minute)
+	case p.anchor == yearAnchor:
+		return d.AddDate(1, 0, 0)
+	}
+	return d
+}
+
+// recurrenceRule completa a regra com o dia reconhecido em outro ponto do
+// texto, como em "on the 5th every month".
+func (p *parser) recurrenceRule() string {
+	r := p.rule
+	switch {
+	case r.freq == "WEEKLY" && len(r.byDay) == 0 && p.anchor == weekAnchor:
+		r.byDay = []string{dayCodes[p.date.Weekday()]}
+	case r.freq == "MONTHLY" && r.byMonthDay == 0 && p.anchor == monthAnchor:
+		r.byMonthDay = p.date.Day()
+	}
+	return r.String()
+}
+
+func (p *parser) setDate(d time.Time, a anchor) {
+	p.date, p.hasDate, p.anchor = d, true, a
+}
+
+func (p *parser) setTime(hour, minute int) {
+	p.hour, p.minute, p.hasTime = hour, minute, true
+}
+
+func midnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+func at(day time.Time, hour, minute int) time.Time {
+	y, m, d := day.Date()
+	return time.Date(y, m, d, hour, minute, 0, 0, day.Location())
+}
+
+// nextMonthDay retorna a primeira data a partir de from com o dia do mês
+// informado, pulando os meses que não o têm.
+func nextMonthDay(from time.Time, day int) (time.Time, bool) {
+	if day < 1 || day > 31 {
+		return time.Time{}, false
+	}
+	y, m, _ := from.Date()
+	for i := 0; i < 13; i++ {
+		d := time.Date(y, m+time.Month(i), day, 0, 0, 0, 0, from.Location())
+		if d.Day() == day && !d.Before(from) {
+			return d, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// nextWeekday retorna o próximo dia da semana a partir de from (inclusive).
+func nextWeekday(from time.Time, wd time.Weekday) time.Time {
+	return from.AddDate(0, 0, (int(wd)-int(from.Weekday())+7)%7)
+}
+
+var unaccent = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "é", "e", "ê", "e", "í", "i",
+	"ó", "o", "ô", "o", "õ", "o", "ú", "u", "ü", "u", "ç", "c",
+)
+
+func normalize(s string) string {
+	return strings.TrimRight(unaccent.Replace(strings.ToLower(s)), ",.;:!?")
+}
@@ -0,0 +1,554 @@
+package quickadd
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var priorities = map[string]string{
+	"high": "A", "urgent": "A", "alta": "A", "urgente": "A",
+	"medium": "B", "normal": "B", "media": "B",
+	"low": "C", "baixa": "C",
+}
+
+type unit int
+
+const (
+	minuteUnit unit = iota + 1
+	hourUnit
+	dayUnit
+	weekUnit
+	monthUnit
+	yearUnit
+)
+
+var units = map[string]unit{
+	"min": minuteUnit, "mins": minuteUnit, "minute": minuteUnit, "minutes": minuteUnit, "minuto": minuteUnit, "minutos": minuteUnit,
+	"h": hourUnit, "hr": hourUnit, "hrs": hourUnit, "hour": hourUnit, "hours": hourUnit, "hora": hourUnit, "horas": hourUnit,
+	"day": dayUnit, "days": dayUnit, "dia": dayUnit, "dias": dayUnit,
+	"week": weekUnit, "weeks": weekUnit, "semana": weekUnit, "semanas": weekUnit,
+	"month": monthUnit, "months": monthUnit, "mes": monthUnit, "meses": monthUnit,
+	"year": yearUnit, "years": yearUnit, "ano": yearUnit, "anos": yearUnit,
+}
+
+var frequencies = map[unit]string{
+	minuteUnit: "MINUTELY", hourUnit: "HOURLY", dayUnit: "DAILY",
+	weekUnit: "WEEKLY", monthUnit: "MONTHLY", yearUnit: "YEARLY",
+}
+
+// weekdays são os nomes completos dos dias. "segunda" fica de fora porque
+// também quer dizer "segundo" ("segunda via"); veja lookupWeekday.
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+	"domingo": time.Sunday, "terca": time.Tuesday, "quarta": time.Wednesday,
+	"quinta": time.Thursday, "sexta": time.Friday, "sabado": time.Saturday,
+}
+
+var months = map[string]time.Month{
+	"january": time.January, "february": time.February, "march": time.March, "april": time.April,
+	"may": time.May, "june": time.June, "july": time.July, "august": time.August,
+	"september": time.September, "october": time.October, "november": time.November, "december": time.December,
+	"janeiro": time.January, "fevereiro": time.February, "marco": time.March, "abril": time.April,
+	"maio": time.May, "junho": time.June, "julho": time.July, "agosto": time.August,
+	"setembro": time.September, "outubro": time.October, "novembro": time.November, "dezembro": time.December,
+}
+
+var smallNumbers = map[string]int{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"um": 1, "uma": 1, "dois": 2, "duas": 2, "tres": 3, "quatro": 4, "cinco": 5,
+}
+
+// lookupWeekday reconhece o nome com ou sem "-feira" e no plural ("todas
+// as sextas"). "segunda" só vale com "-feira" ou quando não está solta
+// (bare), como em "na segunda".
+func lookupWeekday(w string, bare bool) (time.Weekday, bool) {
+	if base, ok := strings.CutSuffix(w, "-feira"); ok {
+		w, bare = base, false
+	}
+	if wd, ok := weekdays[w]; ok {
+		return wd, true
+	}
+	if w == "segunda" && !bare {
+		return time.Monday, true
+	}
+	if s, ok := strings.CutSuffix(w, "s"); ok && s != "" {
+		return lookupWeekday(s, bare)
+	}
+	return 0, false
+}
+
+func number(w string) (int, bool) {
+	if n, ok := smallNumbers[w]; ok {
+		return n, true
+	}
+	n, err := strconv.Atoi(w)
+	return n, err == nil && n > 0
+}
+
+// rule é a regra de repetição sendo montada.
+type rule struct {
+	freq       string
+	interval   int
+	byDay      []string
+	byMonthDay int
+}
+
+func (r rule) String() string {
+	if r.freq == "" {
+		return ""
+	}
+	s := "FREQ=" + r.freq
+	if r.interval > 1 {
+		s += ";INTERVAL=" + strconv.Itoa(r.interval)
+	}
+	if len(r.byDay) > 0 {
+		s += ";BYDAY=" + strings.Join(r.byDay, ",")
+	}
+	if r.byMonthDay > 0 {
+		s += ";BYMONTHDAY=" + strconv.Itoa(r.byMonthDay)
+	}
+	return s
+}
+
+var dayCodes = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// recurrence reconhece "daily", "every 2 weeks", "every monday",
+// "todo mês", "a cada 3 dias", "todo dia 5", "todas as sextas" etc.
+func (p *parser) recurrence() int {
+	switch p.at(0) {
+	case "daily", "diariamente":
+		p.rule.freq = "DAILY"
+		return 1
+	case "weekly", "semanalmente":
+		p.rule.freq = "WEEKLY"
+		return 1
+	case "monthly", "mensalmente":
+		p.rule.freq = "MONTHLY"
+		return 1
+	case "yearly", "annually", "anualmente":
+		p.rule.freq = "YEARLY"
+		return 1
+	}
+
+	k := 0
+	switch {
+	case p.at(0) == "every" || p.at(0) == "each" || p.at(0) == "cada":
+		k = 1
+	case p.at(0) == "a" && p.at(1) == "cada":
+		k = 2
+	case p.at(0) == "todo" || p.at(0) == "toda" || p.at(0) == "todos" || p.at(0) == "todas":
+		k = 1
+		if p.at(1) == "os" || p.at(1) == "as" {
+			k = 2
+		}
+	default:
+		return 0
+	}
+
+	if n, ok := number(p.at(k)); ok {
+		if u, ok := units[p.at(k+1)]; ok {
+			p.rule.freq, p.rule.interval = frequencies[u], n
+			return k + 2
+		}
+		return 0
+	}
+	if p.at(k) == "other" {
+		if u, ok := units[p.at(k+1)]; ok {
+			p.rule.freq, p.rule.interval = frequencies[u], 2
+			return k + 2
+		}
+		return 0
+	}
+	if p.at(k) == "weekday" || p.at(k) == "weekdays" || (p.at(k) == "dias" && p.at(k+1) == "uteis") {
+		p.rule.freq, p.rule.byDay = "WEEKLY", []string{"MO", "TU", "WE", "TH", "FR"}
+		if p.at(k) == "dias" {
+			k++
+		}
+		return k + 1
+	}
+	if wd, ok := lookupWeekday(p.at(k), false); ok {
+		// "every monday and friday", "toda segunda, quarta e sexta"
+		p.rule.freq, p.rule.byDay = "WEEKLY", []string{dayCodes[wd]}
+		n := k + 1
+		for {
+			sep := 0
+			if p.at(n) == "and" || p.at(n) == "e" {
+				sep = 1
+			}
+			wd, ok := lookupWeekday(p.at(n+sep), false)
+			if !ok {
+				return n
+			}
+			p.rule.byDay = append(p.rule.byDay, dayCodes[wd])
+			n += sep + 1
+		}
+	}
+	if u, ok := units[p.at(k)]; ok {
+		// "todo dia 5" é mensal, não diário
+		if u == dayUnit && p.at(k) == "dia" {
+			if n, err := strconv.Atoi(p.at(k + 1)); err == nil {
+				if d, ok := nextMonthDay(p.today, n); ok {
+					p.rule.freq, p.rule.byMonthDay = "MONTHLY", n
+					p.setDate(d, monthAnchor)
+					return k + 2
+				}
+			}
+		}
+		p.rule.freq = frequencies[u]
+		return k + 1
+	}
+	return 0
+}
+
+// relative reconhece "in 2 hours", "em 3 dias", "daqui a uma semana".
+func (p *parser) relative() int {
+	k := 0
+	switch {
+	case p.at(0) == "in" || p.at(0) == "em" || p.at(0) == "within":
+		k = 1
+	case p.at(0) == "daqui" && p.at(1) == "a":
+		k = 2
+	case p.at(0) == "dentro" && p.at(1) == "de":
+		k = 2
+	default:
+		return 0
+	}
+	n, ok := number(p.at(k))
+	if !ok {
+		return 0
+	}
+	u, ok := units[p.at(k+1)]
+	if !ok {
+		return 0
+	}
+	switch u {
+	case minuteUnit:
+		p.exact = p.now.Add(time.Duration(n) * time.Minute)
+	case hourUnit:
+		p.exact = p.now.Add(time.Duration(n) * time.Hour)
+	case dayUnit:
+		p.setDate(p.today.AddDate(0, 0, n), noAnchor)
+	case weekUnit:
+		p.setDate(p.today.AddDate(0, 0, 7*n), noAnchor)
+	case monthUnit:
+		p.setDate(p.today.AddDate(0, n, 0), noAnchor)
+	case yearUnit:
+		p.setDate(p.today.AddDate(n, 0, 0), noAnchor)
+	}
+	return k + 2
+}
+
+// dayWord reconhece hoje, amanhã, depois de amanhã e a próxima semana ou
+// o próximo mês.
+func (p *parser) dayWord() int {
+	k := 0
+	if p.at(0) == "na" || p.at(0) == "no" {
+		k = 1
+	}
+	w0, w1, w2 := p.at(k), p.at(k+1), p.at(k+2)
+	switch {
+	case k == 0 && (w0 == "today" || w0 == "hoje"):
+		p.setDate(p.today, noAnchor)
+		return 1
+	case k == 0 && w0 == "tonight":
+		p.setDate(p.today, noAnchor)
+		p.defaultTime(20)
+		return 1
+	case k == 0 && (w0 == "tomorrow" || w0 == "amanha"):
+		p.setDate(p.today.AddDate(0, 0, 1), noAnchor)
+		return 1
+	case k == 0 && (w0 == "day" && w1 == "after" && w2 == "tomorrow" || w0 == "depois" && w1 == "de" && w2 == "amanha"):
+		p.setDate(p.today.AddDate(0, 0, 2), noAnchor)
+		return 3
+	case k == 0 && (w0 == "next" && w1 == "week" || w0 == "proxima" && w1 == "semana"):
+		p.setDate(p.today.AddDate(0, 0, 7), noAnchor)
+		return 2
+	case k == 0 && (w0 == "next" && w1 == "month" || w0 == "proximo" && w1 == "mes"):
+		p.setDate(p.today.AddDate(0, 1, 0), noAnchor)
+		return 2
+	case w0 == "semana" && w1 == "que" && w2 == "vem":
+		p.setDate(p.today.AddDate(0, 0, 7), noAnchor)
+		return k + 3
+	case w0 == "mes" && w1 == "que" && w2 == "vem":
+		p.setDate(p.today.AddDate(0, 1, 0), noAnchor)
+		return k + 3
+	}
+	return 0
+}
+
+var (
+	periodsPT = map[string]int{"manha": 9, "tarde": 15, "noite": 20}
+	periodsEN = map[string]int{"morning": 9, "afternoon": 15, "evening": 20}
+)
+
+// partOfDay reconhece "de manhã", "à tarde", "in the evening" etc., que
+// valem como hora se nenhuma outra for informada.
+func (p *parser) partOfDay() int {
+	w0, w1, w2 := p.at(0), p.at(1), p.at(2)
+	switch {
+	case (w0 == "de" || w0 == "a" || w0 == "pela") && periodsPT[w1] > 0:
+		p.defaultTime(periodsPT[w1])
+		return 2
+	case w0 == "in" && w1 == "the" && periodsEN[w2] > 0:
+		p.defaultTime(periodsEN[w2])
+		return 3
+	case w0 == "this" && periodsEN[w1] > 0:
+		p.defaultTime(periodsEN[w1])
+		return 2
+	case w0 == "at" && w1 == "night":
+		p.defaultTime(20)
+		return 2
+	}
+	return 0
+}
+
+func (p *parser) defaultTime(hour int) {
+	if !p.hasTime {
+		p.setTime(hour, 0)
+	}
+}
+
+// weekday reconhece "friday", "on friday", "next friday", "na sexta",
+// "sexta-feira", "sexta que vem".
+func (p *parser) weekday() int {
+	k, after := 0, true
+	switch p.at(0) {
+	case "on", "next", "na", "no", "proxima", "proximo":
+		k = 1
+	case "this", "nesta", "neste", "esta", "este":
+		k, after = 1, false
+	}
+	wd, ok := lookupWeekday(p.at(k), k == 0)
+	if !ok {
+		return 0
+	}
+	n := k + 1
+	if p.at(n) == "que" && p.at(n+1) == "vem" {
+		n += 2
+	}
+	from := p.today
+	if after {
+		from = from.AddDate(0, 0, 1)
+	}
+	p.setDate(nextWeekday(from, wd), weekAnchor)
+	return n
+}
+
+var (
+	ordinalPattern = regexp.MustCompile(`^(\d{1,2})(?:st|nd|rd|th|º|o)?$`)
+	suffixPattern  = regexp.MustCompile(`^\d{1,2}(?:st|nd|rd|th)$`)
+)
+
+func dayNumber(w string) (int, bool) {
+	m := ordinalPattern.FindStringSubmatch(w)
+	if m == nil {
+		return 0, false
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n, n >= 1 && n <= 31
+}
+
+// dayOfMonth reconhece "on the 5th", "the 5th", "dia 5" e "no dia 5".
+func (p *parser) dayOfMonth() int {
+	k := 0
+	switch {
+	case p.at(0) == "on" && p.at(1) == "the":
+		k = 2
+	case p.at(0) == "the":
+		k = 1
+	case p.at(0) == "no" && p.at(1) == "dia":
+		k = 2
+	case p.at(0) == "dia":
+		k = 1
+	default:
+		return 0
+	}
+	// Em inglês, "the 5" sem o ordinal é ambíguo demais
+	if p.at(k-1) == "the" && !suffixPattern.MatchString(p.at(k)) {
+		return 0
+	}
+	n, ok := dayNumber(p.at(k))
+	if !ok {
+		return 0
+	}
+	d, ok := nextMonthDay(p.today, n)
+	if !ok {
+		return 0
+	}
+	p.setDate(d, monthAnchor)
+	return k + 1
+}
+
+// monthDate reconhece "May 10", "on May 10th, 2025", "10 May",
+// "10 de maio" e "dia 10 de maio de 2025".
+func (p *parser) monthDate() int {
+	k := 0
+	switch p.at(0) {
+	case "on", "em", "no":
+		k = 1
+	}
+	if p.at(k) == "dia" {
+		k++
+	}
+	var day, n int
+	var month time.Month
+	if m, ok := months[p.at(k)]; ok {
+		d, ok := dayNumber(p.at(k + 1))
+		if !ok {
+			return 0
+		}
+		month, day, n = m, d, k+2
+	} else if d, ok := dayNumber(p.at(k)); ok {
+		j := k + 1
+		if p.at(j) == "de" || p.at(j) == "of" {
+			j++
+		}
+		m, ok := months[p.at(j)]
+		if !ok {
+			return 0
+		}
+		month, day, n = m, d, j+1
+	} else {
+		return 0
+	}
+
+	year, explicit := p.today.Year(), false
+	j := n
+	if p.at(j) == "de" {
+		j++
+	}
+	if y, err := strconv.Atoi(p.at(j)); err == nil && len(p.at(j)) == 4 {
+		year, explicit, n = y, true, j+1
+	}
+	d := time.Date(year, month, day, 0, 0, 0, 0, p.today.Location())
+	if d.Day() != day {
+		return 0
+	}
+	a := yearAnchor
+	if explicit {
+		a = noAnchor
+	} else if d.Before(p.today) {
+		d = d.AddDate(1, 0, 0)
+	}
+	p.setDate(d, a)
+	return n
+}
+
+func (p *parser) isoDate() int {
+	k := 0
+	if p.at(0) == "on" || p.at(0) == "em" {
+		k = 1
+	}
+	d, err := time.ParseInLocation("2006-01-02", p.at(k), p.today.Location())
+	if err != nil {
+		return 0
+	}
+	p.setDate(d, noAnchor)
+	return k + 1
+}
+
+var (
+	meridiemPattern = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)$`)
+	colonPattern    = regexp.MustCompile(`^(\d{1,2}):(\d{2})$`)
+	hourPattern     = regexp.MustCompile(`^(\d{1,2})h(\d{2})?$`)
+)
+
+// clock reconhece "3pm", "at 3 pm", "10:30", "18h", "às 10h30", "noon",
+// "meio-dia", e também "3 da tarde". Um número sozinho, como em "at 10",
+// só é hora se depois dele vierem apenas outras expressões, como em "at 10
+// tomorrow", para que "Look at 3 options" e "I ate 2 apples" não ganhem
+// prazo.
+func (p *parser) clock() int {
+	k := 0
+	switch p.at(0) {
+	case "at", "@", "as", "by", "ate":
+		k = 1
+	}
+	w := p.at(k)
+	hour, minute, n := -1, 0, k+1
+	switch {
+	case w == "noon" || w == "meio-dia" || w == "meiodia":
+		hour = 12
+	case w == "midnight" || w == "meia-noite":
+		hour = 0
+	default:
+		if m := meridiemPattern.FindStringSubmatch(w); m != nil {
+			hour, minute = meridiem(m[1], m[2], m[3])
+		} else if m := colonPattern.FindStringSubmatch(w); m != nil {
+			hour, _ = strconv.Atoi(m[1])
+			minute, _ = strconv.Atoi(m[2])
+		} else if m := hourPattern.FindStringSubmatch(w); m != nil {
+			hour, _ = strconv.Atoi(m[1])
+			minute, _ = strconv.Atoi(m[2])
+		} else if h, err := strconv.Atoi(w); err == nil && (k > 0 && p.onlyExpressions(k+1) || p.qualifiesHour(k+1)) {
+			// Um número só é hora antes de "pm" ou "da tarde", ou depois de
+			// "at" ou "às" se o resto do texto não tiver título
+			hour = h
+		}
+		if hour < 0 {
+			return 0
+		}
+		switch next := p.at(n); {
+		case next == "am" || next == "pm":
+			hour, _ = meridiem(strconv.Itoa(hour), "", next)
+			n++
+		case next == "da" && (p.at(n+1) == "tarde" || p.at(n+1) == "noite"):
+			if hour < 12 {
+				hour += 12
+			}
+			n += 2
+		case next == "da" && p.at(n+1) == "manha":
+			n += 2
+		}
+	}
+	if hour < 0 || hour > 23 || minute > 59 {
+		return 0
+	}
+	p.setTime(hour, minute)
+	return n
+}
+
+// onlyExpressions informa se as palavras a partir de k formam apenas
+// expressões reconhecidas por match, sem nada que fique no título. A
+// verificação é feita em uma cópia do parser, e o resultado de cada posição
+// fica guardado, já que cada "at N" do resto do texto também a faz.
+func (p *parser) onlyExpressions(k int) bool {
+	start := p.i + k
+	if ok, seen := p.rest[start]; seen {
+		return ok
+	}
+	q := *p
+	q.i = start
+	ok := true
+	for q.i < len(q.words) {
+		n := q.match()
+		if n == 0 {
+			ok = false
+			break
+		}
+		q.i += n
+	}
+	p.rest[start] = ok
+	return ok
+}
+
+func (p *parser) qualifiesHour(k int) bool {
+	next := p.at(k)
+	return next == "am" || next == "pm" || next == "da" && periodsPT[p.at(k+1)] > 0
+}
+
+func meridiem(h, m, ampm string) (int, int) {
+	hour, _ := strconv.Atoi(h)
+	minute, _ := strconv.Atoi(m)
+	if hour > 12 {
+		return -1, 0
+	}
+	hour %= 12
+	if ampm == "pm" {
+		hour += 12
+	}
+	return hour, minute
+}
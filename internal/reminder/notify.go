@@ -0,0 +1,189 @@
+package reminder
+
+import (
+	"botasks/internal/task"
+	"botasks/internal/webhook"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// EventName identifica as entregas do WebhookNotifier no cabeçalho
+// webhook.EventHeader.
+const EventName = "reminder.due"
+
+// Notification é um lembrete vencido, pronto para ser entregue.
+type Notification struct {
+	Reminder Reminder
+	Task     task.Task
+	DueAt    time.Time
+	// Late indica um lembrete entregue com atraso, como os que venceram
+	// enquanto o processo estava parado.
+	Late bool
+}
+
+// Text é o texto da notificação, como
+// "Reminder: Pay rent (due Wed Jun 5 23:59)".
+func (n Notification) Text() string {
+	prefix := "Reminder"
+	if n.Late {
+		prefix = "Missed reminder"
+	}
+	return fmt.Sprintf("%s: %s (due %s)", prefix, n.Task.Title, n.Task.Deadline.Format("Mon Jan 2 15:04"))
+}
+
+// Notifier entrega notificações.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// NotifierFunc adapta uma função a Notifier.
+type NotifierFunc func(ctx context.Context, n Notification) error
+
+func (f NotifierFunc) Notify(ctx context.Context, n Notification) error {
+	return f(ctx, n)
+}
+
+// Router escolhe o notificador pelo Channel do lembrete. Lembretes sem
+// canal, ou com um canal sem notificador, vão para o de chave "".
+type Router map[string]Notifier
+
+func (r Router) Notify(ctx context.Context, n Notification) error {
+	notifier, ok := r[n.Reminder.Channel]
+	if !ok {
+		notifier, ok = r[""]
+	}
+	if !ok {
+		return fmt.Errorf("no notifier for channel %q", n.Reminder.Channel)
+	}
+	return notifier.Notify(ctx, n)
+}
+
+// WriterNotifier escreve uma linha por notificação, como na saída padrão.
+type WriterNotifier struct {
+	w  io.Writer
+	mu sync.Mutex
+}
+
+func NewWriterNotifier(w io.Writer) *WriterNotifier {
+	return &WriterNotifier{w: w}
+}
+
+func (n *WriterNotifier) Notify(ctx context.Context, notification Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	_, err := fmt.Fprintln(n.w, notification.Text())
+	return err
+}
+
+// EmailNotifier grava cada notificação como uma mensagem .eml no diretório
+// informado, para ser enviada por outro processo (um pickup do servidor de
+// e-mail, por exemplo). O destinatário é o Target do lembrete.
+type EmailNotifier struct {
+	dir  string
+	from string
+}
+
+func NewEmailNotifier(dir, from string) *EmailNotifier {
+	return &EmailNotifier{dir: dir, from: from}
+}
+
+func (n *EmailNotifier) Notify(ctx context.Context, notification Notification) error {
+	r := notification.Reminder
+	if r.Target == "" {
+		return errors.New("email reminder has no recipient")
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", r.Target)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", notification.Text()))
+	fmt.Fprintf(&msg, "Date: %s\r\n", notification.DueAt.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(notification.Text() + "\r\n")
+	if d := notification.Task.Description; d != "" {
+		msg.WriteString("\r\n" + d + "\r\n")
+	}
+
+	// O arquivo só aparece com o nome final depois de completo
+	name := fmt.Sprintf("%s-%d.eml", r.ID, notification.DueAt.Unix())
+	tmp := filepath.Join(n.dir, "."+name)
+	if err := os.WriteFile(tmp, msg.Bytes(), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(n.dir, name))
+}
+
+// WebhookPayload é o corpo JSON enviado pelo WebhookNotifier.
+type WebhookPayload struct {
+	ReminderID string    `json:"reminder_id"`
+	TaskID     string    `json:"task_id"`
+	Title      string    `json:"title"`
+	Deadline   time.Time `json:"deadline"`
+	DueAt      time.Time `json:"due_at"`
+	Late       bool      `json:"late"`
+	Target     string    `json:"target,omitempty"`
+}
+
+// WebhookNotifier envia cada notificação em um POST assinado como os
+// eventos do pacote webhook (cabeçalho webhook.SignatureHeader). Respostas
+// fora de 2xx são erros, e o lembrete é tentado de novo na próxima
+// verificação.
+type WebhookNotifier struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewWebhookNotifier cria o notificador. Se client for nil, usa um cliente
+// com timeout de 10s.
+func NewWebhookNotifier(url, secret string, client *http.Client) *WebhookNotifier {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &WebhookNotifier{url: url, secret: secret, client: client}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, notification Notification) error {
+	body, err := json.Marshal(WebhookPayload{
+		ReminderID: notification.Reminder.ID,
+		TaskID:     notification.Task.ID,
+		Title:      notification.Task.Title,
+		Deadline:   notification.Task.Deadline,
+		DueAt:      notification.DueAt,
+		Late:       notification.Late,
+		Target:     notification.Reminder.Target,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.EventHeader, EventName)
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(n.secret, body))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+// Package reminder agenda lembretes de tarefas e os entrega por
+// notificadores plugáveis (saída padrão, mensagem de bot, arquivo de e-mail,
+// webhook).
+//
+// Um lembrete vence em um instante fixo (At) ou com antecedência em relação
+// ao prazo da tarefa (Before), recalculada a cada verificação: se o prazo
+// mudar, o lembrete acompanha. Os lembretes ficam em um Store; o Scheduler
+// os verifica periodicamente e, ao iniciar, entrega os que venceram enquanto
+// o processo estava parado.
+package reminder
+
+import (
+	"botasks/internal/task"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrReminderNotFound = errors.New("reminder not found")
+
+// Reminder é um lembrete de uma tarefa.
+type Reminder struct {
+	ID     string
+	TaskID string
+	At     time.Time     // instante fixo; zero se for relativo ao prazo
+	Before time.Duration // antecedência em relação a Task.Deadline, se At for zero
+	// Channel escolhe o notificador em um Router; vazio usa o padrão.
+	Channel string
+	// Target é o destinatário no canal: o chat, o e-mail etc.
+	Target    string
+	CreatedBy string
+	// FiredFor é o vencimento do último lembrete entregue; zero se ainda
+	// não foi entregue.
+	FiredFor time.Time
+	// MissingSince é quando a tarefa deixou de ser encontrada; zero se ela
+	// existe.
+	MissingSince time.Time
+}
+
+// DueAt retorna quando o lembrete vence para a tarefa.
+func (r Reminder) DueAt(t task.Task) time.Time {
+	if !r.At.IsZero() {
+		return r.At
+	}
+	return t.Deadline.Add(-r.Before)
+}
+
+// fired informa se o lembrete já foi entregue para o vencimento due.
+func (r Reminder) fired(due time.Time) bool {
+	return !r.FiredFor.IsZero() && r.FiredFor.Equal(due)
+}
+
+// Store guarda os lembretes.
+type Store interface {
+	// Save inclui o lembrete ou substitui o de mesmo ID.
+	Save(ctx context.Context, r Reminder) error
+	// Update substitui o lembrete de mesmo ID, retornando
+	// ErrReminderNotFound se ele não existir mais.
+	Update(ctx context.Context, r Reminder) error
+	Delete(ctx context.Context, reminderID string) error
+	List(ctx context.Context) ([]Reminder, error)
+}
+
+// MemoryStore é um Store em memória.
+type MemoryStore struct {
+	reminders []Reminder
+	mu        sync.Mutex
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) Save(ctx context.Context, r Reminder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reminders = upsert(s.reminders, r)
+	return nil
+}
+
+func (s *MemoryStore) Update(ctx context.Context, r Reminder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !replace(s.reminders, r) {
+		return ErrReminderNotFound
+	}
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, reminderID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ok bool
+	s.reminders, ok = remove(s.reminders, reminderID)
+	if !ok {
+		return ErrReminderNotFound
+	}
+	return nil
+}
+
+func (s *MemoryStore) List(ctx context.Context) ([]Reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Reminder(nil), s.reminders...), nil
+}
+
+// FileStore guarda os lembretes em um arquivo JSON, reescrito a cada
+// alteração. A gravação passa por um arquivo temporário renomeado, para que
+// uma queda no meio dela não perca os lembretes.
+type FileStore struct {
+	path      string
+	reminders []Reminder
+	mu        sync.Mutex
+}
+
+var _ Store = (*FileStore)(nil)
+
+// OpenFileStore lê os lembretes do arquivo, se ele existir.
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.reminders); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileStore) Save(ctx context.Context, r Reminder) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.write(upsert(append([]Reminder(nil), s.reminders...), r))
+}
+
+func (s *FileStore) Update(ctx context.Context, r Reminder) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reminders := append([]Reminder(nil), s.reminders...)
+	if !replace(reminders, r) {
+		return ErrReminderNotFound
+	}
+	return s.write(reminders)
+}
+
+func (s *FileStore) Delete(ctx context.Context, reminderID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reminders, ok := remove(append([]Reminder(nil), s.reminders...), reminderID)
+	if !ok {
+		return ErrReminderNotFound
+	}
+	return s.write(reminders)
+}
+
+func (s *FileStore) List(ctx context.Context) ([]Reminder, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Reminder(nil), s.reminders...), nil
+}
+
+// write grava os lembretes e, se der certo, passa a usá-los. Deve ser
+// chamado com s.mu travado.
+func (s *FileStore) write(reminders []Reminder) error {
+	data, err := json.MarshalIndent(reminders, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+	s.reminders = reminders
+	return nil
+}
+
+func upsert(reminders []Reminder, r Reminder) []Reminder {
+	if replace(reminders, r) {
+		return reminders
+	}
+	return append(reminders, r)
+}
+
+// replace substitui o lembrete de mesmo ID e informa se ele existia.
+func replace(reminders []Reminder, r Reminder) bool {
+	for i := range reminders {
+		if reminders[i].ID == r.ID {
+			reminders[i] = r
+			return true
+		}
+	}
+	return false
+}
+
+func remove(reminders []Reminder, reminderID string) ([]Reminder, bool) {
+	for i, r := range reminders {
+		if r.ID == reminderID {
+			return append(reminders[:i:i], reminders[i+1:]...), true
+		}
+	}
+	return reminders, false
+}
+
+var beforePattern = regexp.MustCompile(`^(\d+)\s*(m|min|mins|minutes?|minutos?|h|hours?|horas?|d|days?|dias?)\s+(before|antes)$`)
+
+// ParseBefore interpreta antecedências como "1h before", "30 min before" ou
+// "2 dias antes".
+func ParseBefore(text string) (time.Duration, bool) {
+	m := beforePattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(text)))
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, false
+	}
+	unit := time.Minute
+	switch m[2][0] {
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = 24 * time.Hour
+	}
+	return time.Duration(n) * unit, true
+}
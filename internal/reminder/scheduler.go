@@ -0,0 +1,278 @@
+package reminder
+
+import (
+	"botasks/internal/clock"
+	"botasks/internal/event"
+	"botasks/internal/idgen"
+	"botasks/internal/repository"
+	"botasks/internal/service"
+	"botasks/internal/task"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Service é a parte do service.TaskListService usada pelo Scheduler.
+type Service interface {
+	GetTask(ctx context.Context, taskID string) (*task.Task, error)
+}
+
+// ErrNoDeadline é retornado por Add para um lembrete relativo (Before) de
+// uma tarefa sem prazo.
+var ErrNoDeadline = errors.New("task has no deadline")
+
+// Scheduler verifica os lembretes a cada intervalo e entrega os vencidos.
+// Um lembrete é entregue uma vez por vencimento; se a entrega falhar, ele
+// é tentado de novo na verificação seguinte. Lembretes de tarefas
+// concluídas são removidos na verificação; os de tarefas excluídas, ao
+// receber o event.TaskDeleted (veja Subscribe). Um lembrete cuja tarefa
+// não é encontrada, como uma excluída com o processo parado, é mantido
+// durante a tolerância de WithMissingTaskGrace e depois removido; o erro
+// é informado só na primeira verificação.
+type Scheduler struct {
+	// OnError é chamado com os erros das verificações feitas por Run e das
+	// remoções feitas por Handle. O padrão registra no log.
+	OnError func(err error)
+
+	svc      Service
+	store    Store
+	notifier Notifier
+	clock    clock.Clock
+	ids      idgen.Generator
+	interval time.Duration
+	grace    time.Duration
+
+	mu sync.Mutex // serializa as verificações
+}
+
+// Option configura um Scheduler.
+type Option func(*Scheduler)
+
+// WithClock define o relógio usado para decidir quais lembretes venceram.
+func WithClock(c clock.Clock) Option {
+	return func(s *Scheduler) { s.clock = c }
+}
+
+// WithIDGenerator define o gerador de IDs dos lembretes.
+func WithIDGenerator(g idgen.Generator) Option {
+	return func(s *Scheduler) { s.ids = g }
+}
+
+// WithInterval define o intervalo entre as verificações de Run. O padrão
+// é 30s.
+func WithInterval(d time.Duration) Option {
+	return func(s *Scheduler) { s.interval = d }
+}
+
+// WithMissingTaskGrace define por quanto tempo um lembrete é mantido
+// depois que a sua tarefa deixa de ser encontrada. O padrão é 24h.
+func WithMissingTaskGrace(d time.Duration) Option {
+	return func(s *Scheduler) { s.grace = d }
+}
+
+func New(svc Service, store Store, notifier Notifier, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		svc:      svc,
+		store:    store,
+		notifier: notifier,
+		clock:    clock.New(),
+		ids:      idgen.Default(),
+		interval: 30 * time.Second,
+		grace:    24 * time.Hour,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Add agenda o lembrete e retorna o seu ID. O autor vem do contexto, como
+// no TaskListService.
+func (s *Scheduler) Add(ctx context.Context, r Reminder) (string, error) {
+	if r.TaskID == "" || (r.At.IsZero() && r.Before < 0) {
+		return "", errors.New("invalid reminder parameters")
+	}
+	t, err := s.svc.GetTask(ctx, r.TaskID)
+	if err != nil {
+		return "", err
+	}
+	if r.At.IsZero() && t.Deadline.IsZero() {
+		return "", ErrNoDeadline
+	}
+	r.ID = s.ids.NewID()
+	r.CreatedBy = service.ActorFromContext(ctx)
+	r.FiredFor = time.Time{}
+	if err := s.store.Save(ctx, r); err != nil {
+		return "", err
+	}
+	return r.ID, nil
+}
+
+// Remove cancela um lembrete.
+func (s *Scheduler) Remove(ctx context.Context, reminderID string) error {
+	return s.store.Delete(ctx, reminderID)
+}
+
+// Reminders retorna os lembretes da tarefa, do que vence primeiro ao
+// último.
+func (s *Scheduler) Reminders(ctx context.Context, taskID string) ([]Reminder, error) {
+	t, err := s.svc.GetTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	all, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var result []Reminder
+	for _, r := range all {
+		if r.TaskID == taskID {
+			result = append(result, r)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].DueAt(*t).Before(result[j].DueAt(*t))
+	})
+	return result, nil
+}
+
+// Tick entrega os lembretes vencidos e retorna quantos foram entregues.
+// Os erros de cada lembrete são reunidos sem interromper os demais.
+func (s *Scheduler) Tick(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reminders, err := s.store.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	now := s.clock.Now()
+	sent := 0
+	var errs []error
+	for _, r := range reminders {
+		ok, err := s.fire(ctx, r, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("reminder %s: %w", r.ID, err))
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, errors.Join(errs...)
+}
+
+func (s *Scheduler) fire(ctx context.Context, r Reminder, now time.Time) (bool, error) {
+	t, err := s.svc.GetTask(ctx, r.TaskID)
+	if errors.Is(err, repository.ErrTaskNotFound) {
+		return false, s.missing(ctx, r, now)
+	}
+	if err != nil {
+		return false, err
+	}
+	if !r.MissingSince.IsZero() {
+		r.MissingSince = time.Time{}
+		if err := s.store.Update(ctx, r); err != nil {
+			return false, ignoreNotFound(err)
+		}
+	}
+	if t.IsCompleted() {
+		return false, ignoreNotFound(s.store.Delete(ctx, r.ID))
+	}
+	due := r.DueAt(*t)
+	if due.After(now) || r.fired(due) {
+		return false, nil
+	}
+
+	n := Notification{Reminder: r, Task: *t, DueAt: due, Late: now.Sub(due) > s.interval}
+	if err := s.notifier.Notify(ctx, n); err != nil {
+		return false, err
+	}
+	// Update, e não Save, para não recriar um lembrete removido durante a
+	// entrega
+	r.FiredFor = due
+	return true, ignoreNotFound(s.store.Update(ctx, r))
+}
+
+// missing trata um lembrete cuja tarefa não foi encontrada: na primeira
+// vez registra quando e retorna o erro; passada a tolerância, o remove.
+func (s *Scheduler) missing(ctx context.Context, r Reminder, now time.Time) error {
+	if r.MissingSince.IsZero() {
+		r.MissingSince = now
+		if err := s.store.Update(ctx, r); err != nil {
+			return ignoreNotFound(err)
+		}
+		return fmt.Errorf("task %s not found, removing the reminder after %s", r.TaskID, s.grace)
+	}
+	if now.Sub(r.MissingSince) < s.grace {
+		return nil
+	}
+	return ignoreNotFound(s.store.Delete(ctx, r.ID))
+}
+
+// ignoreNotFound descarta o ErrReminderNotFound de um lembrete removido
+// durante a verificação.
+func ignoreNotFound(err error) error {
+	if errors.Is(err, ErrReminderNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Subscribe inscreve o Scheduler no barramento para remover os lembretes
+// das tarefas excluídas.
+func (s *Scheduler) Subscribe(bus *event.Bus) (unsubscribe func()) {
+	return bus.Subscribe(s.Handle)
+}
+
+// Handle remove os lembretes da tarefa de um event.TaskDeleted. Desfazer a
+// exclusão não os traz de volta.
+func (s *Scheduler) Handle(ctx context.Context, e event.Event) {
+	deleted, ok := e.(event.TaskDeleted)
+	if !ok {
+		return
+	}
+	reminders, err := s.store.List(ctx)
+	if err != nil {
+		s.reportError(err)
+		return
+	}
+	for _, r := range reminders {
+		if r.TaskID != deleted.Task.ID {
+			continue
+		}
+		if err := ignoreNotFound(s.store.Delete(ctx, r.ID)); err != nil {
+			s.reportError(err)
+		}
+	}
+}
+
+// Run verifica os lembretes imediatamente, o que entrega os que venceram
+// com o processo parado, e depois a cada intervalo, até o contexto ser
+// cancelado.
+func (s *Scheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Tick(ctx); err != nil && ctx.Err() == nil {
+			s.reportError(err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Scheduler) reportError(err error) {
+	if s.OnError != nil {
+		s.OnError(err)
+		return
+	}
+	log.Printf("reminder scheduler: %v", err)
+}
@@ -0,0 +1,13 @@
+package repository
+
+import "errors"
+
+var (
+	ErrTaskNotFound     = errors.New("Task not found")
+	ErrTaskListNotFound = errors.New("TaskList not found")
+
+	// ErrConflict indica que a versão enviada em Update não corresponde à
+	// versão armazenada, porque outro cliente alterou o registro antes, ou
+	// que Create recebeu o ID de um registro que já existe.
+	ErrConflict = errors.New("version conflict")
+)
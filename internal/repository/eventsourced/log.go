@@ -0,0 +1,320 @@
+package eventsourced
+
+import (
+	"botasks/internal/list"
+	"botasks/internal/task"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Tipos de registro gravados no log.
+const (
+	TaskCreated     = "task.created"
+	TaskUpdated     = "task.updated"
+	TaskDeleted     = "task.deleted"
+	ListCreated     = "list.created"
+	ListUpdated     = "list.updated"
+	ListDeleted     = "list.deleted"
+	ListTaskAdded   = "list.task_added"
+	ListTaskRemoved = "list.task_removed"
+)
+
+// Record é uma entrada do log. Task e List trazem o estado completo após a
+// alteração; TaskID e ListID identificam os registros afetados.
+type Record struct {
+	Seq    int64
+	At     time.Time
+	Type   string
+	TaskID string         `json:",omitempty"`
+	ListID string         `json:",omitempty"`
+	Task   *task.Task     `json:",omitempty"`
+	List   *list.TaskList `json:",omitempty"`
+}
+
+// Log guarda os registros apenas por inclusão.
+type Log interface {
+	Append(ctx context.Context, r Record) error
+	// Read chama fn para cada registro com Seq > after, em ordem.
+	Read(ctx context.Context, after int64, fn func(Record) error) error
+}
+
+// Snapshot é o estado completo do armazenamento após o registro Seq.
+type Snapshot struct {
+	Seq   int64
+	At    time.Time
+	Tasks map[string]task.Task
+	Lists map[string]ListState
+}
+
+// ListState é uma lista com os IDs das tarefas associadas, na ordem de inclusão.
+type ListState struct {
+	List    list.TaskList
+	TaskIDs []string
+}
+
+// SnapshotStore guarda os snapshots usados para acelerar a inicialização.
+type SnapshotStore interface {
+	Save(ctx context.Context, s Snapshot) error
+	// Latest retorna o snapshot mais recente, ou ok=false se não houver.
+	Latest(ctx context.Context) (s Snapshot, ok bool, err error)
+}
+
+// MemoryLog é um Log em memória.
+type MemoryLog struct {
+	records []Record
+	mu      sync.RWMutex
+}
+
+var _ Log = (*MemoryLog)(nil)
+
+func NewMemoryLog() *MemoryLog {
+	return &MemoryLog{}
+}
+
+func (l *MemoryLog) Append(ctx context.Context, r Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.records = append(l.records, r)
+	return nil
+}
+
+func (l *MemoryLog) Read(ctx context.Context, after int64, fn func(Record) error) error {
+	l.mu.RLock()
+	records := l.records
+	l.mu.RUnlock()
+
+	for _, r := range records {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if r.Seq <= after {
+			continue
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FileLog grava um registro JSON por linha em um arquivo aberto com O_APPEND.
+type FileLog struct {
+	path string
+	file *os.File
+	mu   sync.Mutex
+}
+
+var _ Log = (*FileLog)(nil)
+
+// OpenFileLog abre (ou cria) o log no caminho informado. Um último registro
+// incompleto, deixado por uma queda no meio de Append, é descartado; um
+// registro inválido antes dele é erro.
+func OpenFileLog(path string) (*FileLog, error) {
+	if err := repairTail(path); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileLog{path: path, file: file}, nil
+}
+
+// repairTail trunca o arquivo no fim do último registro válido, se o
+// último estiver incompleto ou não puder ser lido.
+func repairTail(path string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var good int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) == 0 {
+				return nil
+			}
+			break // sem a quebra de linha, o registro foi cortado
+		}
+		if err != nil {
+			return err
+		}
+		var r Record
+		if err := json.Unmarshal(line, &r); err != nil {
+			if _, peekErr := reader.Peek(1); peekErr != io.EOF {
+				return fmt.Errorf("%s: corrupt record at offset %d: %w", path, good, err)
+			}
+			break
+		}
+		good += int64(len(line))
+	}
+	return os.Truncate(path, good)
+}
+
+// Close fecha o arquivo.
+func (l *FileLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.file.Close()
+}
+
+func (l *FileLog) Append(ctx context.Context, r Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return l.file.Sync()
+}
+
+func (l *FileLog) Read(ctx context.Context, after int64, fn func(Record) error) error {
+	file, err := os.Open(l.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return err
+		}
+		if r.Seq <= after {
+			continue
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// MemorySnapshotStore guarda apenas o último snapshot, em memória.
+type MemorySnapshotStore struct {
+	latest *Snapshot
+	mu     sync.Mutex
+}
+
+var _ SnapshotStore = (*MemorySnapshotStore)(nil)
+
+func NewMemorySnapshotStore() *MemorySnapshotStore {
+	return &MemorySnapshotStore{}
+}
+
+func (s *MemorySnapshotStore) Save(ctx context.Context, snap Snapshot) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latest = &snap
+	return nil
+}
+
+func (s *MemorySnapshotStore) Latest(ctx context.Context) (Snapshot, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return Snapshot{}, false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.latest == nil {
+		return Snapshot{}, false, nil
+	}
+	return *s.latest, true, nil
+}
+
+// FileSnapshotStore guarda o último snapshot em um arquivo JSON, substituído
+// de forma atômica (gravação em arquivo temporário seguida de rename).
+type FileSnapshotStore struct {
+	path string
+}
+
+var _ SnapshotStore = (*FileSnapshotStore)(nil)
+
+func NewFileSnapshotStore(path string) *FileSnapshotStore {
+	return &FileSnapshotStore{path: path}
+}
+
+func (s *FileSnapshotStore) Save(ctx context.Context, snap Snapshot) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *FileSnapshotStore) Latest(ctx context.Context) (Snapshot, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return Snapshot{}, false, err
+	}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Snapshot{}, false, nil
+	}
+	if err != nil {
+		return Snapshot{}, false, err
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return Snapshot{}, false, err
+	}
+	return snap, true, nil
+}
@@ -0,0 +1,470 @@
+// Package eventsourced é um backend de repositórios que guarda um log de
+// alterações apenas por inclusão e reconstrói o estado de tarefas e listas
+// reaplicando esse log. Snapshots periódicos limitam o trabalho feito ao abrir
+// o armazenamento, e AsOf permite consultar o estado em qualquer instante.
+package eventsourced
+
+import (
+	"botasks/internal/clock"
+	"botasks/internal/list"
+	"botasks/internal/repository"
+	"botasks/internal/task"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// state é o estado materializado a partir do log.
+type state struct {
+	seq   int64
+	tasks map[string]task.Task
+	lists map[string]ListState
+}
+
+func newState() *state {
+	return &state{tasks: make(map[string]task.Task), lists: make(map[string]ListState)}
+}
+
+func stateFromSnapshot(snap Snapshot) *state {
+	s := newState()
+	s.seq = snap.Seq
+	for id, t := range snap.Tasks {
+		s.tasks[id] = t
+	}
+	for id, l := range snap.Lists {
+		l.TaskIDs = append([]string(nil), l.TaskIDs...)
+		s.lists[id] = l
+	}
+	return s
+}
+
+func (s *state) snapshot(at time.Time) Snapshot {
+	snap := Snapshot{
+		Seq:   s.seq,
+		At:    at,
+		Tasks: make(map[string]task.Task, len(s.tasks)),
+		Lists: make(map[string]ListState, len(s.lists)),
+	}
+	for id, t := range s.tasks {
+		snap.Tasks[id] = t
+	}
+	for id, l := range s.lists {
+		l.TaskIDs = append([]string(nil), l.TaskIDs...)
+		snap.Lists[id] = l
+	}
+	return snap
+}
+
+// apply altera o estado conforme o registro. Os registros já foram
+// validados quando gravados, então apply não falha.
+func (s *state) apply(r Record) {
+	s.seq = r.Seq
+	switch r.Type {
+	case TaskCreated, TaskUpdated:
+		s.tasks[r.TaskID] = *r.Task
+	case TaskDeleted:
+		delete(s.tasks, r.TaskID)
+	case ListCreated:
+		l := *r.List
+		taskIDs := make([]string, 0, len(l.Tasks))
+		for _, t := range l.Tasks {
+			taskIDs = append(taskIDs, t.ID)
+		}
+		l.Tasks = nil
+		s.lists[r.ListID] = ListState{List: l, TaskIDs: taskIDs}
+	case ListUpdated:
+		stored := s.lists[r.ListID]
+		l := *r.List
+		l.Tasks = nil
+		stored.List = l
+		s.lists[r.ListID] = stored
+	case ListDeleted:
+		delete(s.lists, r.ListID)
+	case ListTaskAdded:
+		stored := s.lists[r.ListID]
+		stored.TaskIDs = append(stored.TaskIDs[:len(stored.TaskIDs):len(stored.TaskIDs)], r.TaskID)
+		s.lists[r.ListID] = stored
+	case ListTaskRemoved:
+		stored := s.lists[r.ListID]
+		taskIDs := make([]string, 0, len(stored.TaskIDs))
+		removed := false
+		for _, id := range stored.TaskIDs {
+			if id == r.TaskID && !removed {
+				removed = true
+				continue
+			}
+			taskIDs = append(taskIDs, id)
+		}
+		stored.TaskIDs = taskIDs
+		s.lists[r.ListID] = stored
+	}
+}
+
+func (s *state) listWithTasks(taskListID string) (*list.TaskList, error) {
+	stored, ok := s.lists[taskListID]
+	if !ok {
+		return nil, repository.ErrTaskListNotFound
+	}
+	l := stored.List
+	l.Tasks = s.tasksOf(stored)
+	return &l, nil
+}
+
+func (s *state) allTasks() []task.Task {
+	tasks := make([]task.Task, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		tasks = append(tasks, t)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks
+}
+
+func (s *state) allLists() []list.TaskList {
+	taskLists := make([]list.TaskList, 0, len(s.lists))
+	for _, stored := range s.lists {
+		l := stored.List
+		l.Tasks = s.tasksOf(stored)
+		taskLists = append(taskLists, l)
+	}
+	sort.Slice(taskLists, func(i, j int) bool { return taskLists[i].ID < taskLists[j].ID })
+	return taskLists
+}
+
+func (s *state) tasksOf(stored ListState) []list.Task {
+	tasks := make([]list.Task, 0, len(stored.TaskIDs))
+	for _, id := range stored.TaskIDs {
+		if t, ok := s.tasks[id]; ok {
+			tasks = append(tasks, list.Task{Task: t})
+		}
+	}
+	return tasks
+}
+
+// Store é o armazenamento orientado a eventos. Tasks e TaskLists expõem os
+// repositórios; ambos compartilham o mesmo estado e o mesmo log.
+type Store struct {
+	log           Log
+	snapshots     SnapshotStore
+	snapshotEvery int64
+	clock         clock.Clock
+
+	state *state
+	mu    sync.RWMutex
+}
+
+var _ repository.UnitOfWorkFactory = (*Store)(nil)
+
+// Option configura um Store.
+type Option func(*Store)
+
+// WithSnapshots salva um snapshot no store informado a cada every registros.
+func WithSnapshots(snapshots SnapshotStore, every int) Option {
+	return func(s *Store) {
+		s.snapshots = snapshots
+		s.snapshotEvery = int64(every)
+	}
+}
+
+// WithClock define o relógio usado para marcar os registros.
+func WithClock(c clock.Clock) Option {
+	return func(s *Store) { s.clock = c }
+}
+
+// Open carrega o snapshot mais recente, se houver, e reaplica os registros
+// gravados depois dele.
+func Open(ctx context.Context, log Log, opts ...Option) (*Store, error) {
+	s := &Store{log: log, clock: clock.New(), state: newState()}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.snapshots != nil {
+		snap, ok, err := s.snapshots.Latest(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("eventsourced: loading snapshot: %w", err)
+		}
+		if ok {
+			s.state = stateFromSnapshot(snap)
+		}
+	}
+	err := log.Read(ctx, s.state.seq, func(r Record) error {
+		s.state.apply(r)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("eventsourced: replaying log: %w", err)
+	}
+	return s, nil
+}
+
+// Tasks retorna o repositório de tarefas.
+func (s *Store) Tasks() repository.TaskRepository {
+	return &taskRepository{store: s}
+}
+
+// TaskLists retorna o repositório de listas.
+func (s *Store) TaskLists() repository.TaskListRepository {
+	return &taskListRepository{store: s}
+}
+
+// Begin inicia uma unidade de trabalho que compensa as operações em caso de
+// Rollback (veja repository.JournalUnitOfWorkFactory).
+func (s *Store) Begin(ctx context.Context) (repository.UnitOfWork, error) {
+	return repository.NewJournalUnitOfWorkFactory(s.Tasks(), s.TaskLists()).Begin(ctx)
+}
+
+// write valida e grava um registro. validate recebe o estado atual sob lock
+// exclusivo e devolve o registro a gravar; Seq e At são preenchidos aqui.
+func (s *Store) write(ctx context.Context, validate func(*state) (Record, error)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := validate(s.state)
+	if err != nil {
+		return err
+	}
+	r.Seq = s.state.seq + 1
+	r.At = s.clock.Now()
+	if err := s.log.Append(ctx, r); err != nil {
+		return err
+	}
+	s.state.apply(r)
+
+	if s.snapshots != nil && s.snapshotEvery > 0 && r.Seq%s.snapshotEvery == 0 {
+		// Uma falha ao salvar o snapshot não invalida o registro gravado:
+		// o próximo Open apenas reaplica mais registros.
+		s.snapshots.Save(context.WithoutCancel(ctx), s.state.snapshot(r.At))
+	}
+	return nil
+}
+
+func (s *Store) read(ctx context.Context, fn func(*state) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return fn(s.state)
+}
+
+// View é o estado do armazenamento em um instante passado. É somente leitura.
+type View struct {
+	state *state
+}
+
+// AsOf reconstrói o estado considerando apenas os registros gravados até at,
+// inclusive.
+func (s *Store) AsOf(ctx context.Context, at time.Time) (*View, error) {
+	st := newState()
+	err := s.log.Read(ctx, 0, func(r Record) error {
+		if !r.At.After(at) {
+			st.apply(r)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &View{state: st}, nil
+}
+
+// Task retorna a tarefa como estava no instante da View.
+func (v *View) Task(taskID string) (*task.Task, error) {
+	t, ok := v.state.tasks[taskID]
+	if !ok {
+		return nil, repository.ErrTaskNotFound
+	}
+	return &t, nil
+}
+
+// TaskList retorna a lista, com as suas tarefas, como estava no instante da View.
+func (v *View) TaskList(taskListID string) (*list.TaskList, error) {
+	return v.state.listWithTasks(taskListID)
+}
+
+type taskRepository struct {
+	store *Store
+}
+
+var _ repository.TaskRepository = (*taskRepository)(nil)
+
+func (r *taskRepository) Create(ctx context.Context, t task.Task) (string, error) {
+	err := r.store.write(ctx, func(st *state) (Record, error) {
+		if _, exists := st.tasks[t.ID]; exists {
+			return Record{}, repository.ErrConflict
+		}
+		t.Version = 1
+		return Record{Type: TaskCreated, TaskID: t.ID, Task: &t}, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return t.ID, nil
+}
+
+func (r *taskRepository) GetByID(ctx context.Context, taskID string) (*task.Task, error) {
+	var result *task.Task
+	err := r.store.read(ctx, func(st *state) error {
+		t, ok := st.tasks[taskID]
+		if !ok {
+			return repository.ErrTaskNotFound
+		}
+		result = &t
+		return nil
+	})
+	return result, err
+}
+
+func (r *taskRepository) Update(ctx context.Context, t task.Task) error {
+	return r.store.write(ctx, func(st *state) (Record, error) {
+		stored, ok := st.tasks[t.ID]
+		if !ok {
+			return Record{}, repository.ErrTaskNotFound
+		}
+		if stored.Version != t.Version {
+			return Record{}, repository.ErrConflict
+		}
+		t.Version++
+		return Record{Type: TaskUpdated, TaskID: t.ID, Task: &t}, nil
+	})
+}
+
+func (r *taskRepository) Delete(ctx context.Context, taskID string) error {
+	return r.store.write(ctx, func(st *state) (Record, error) {
+		if _, ok := st.tasks[taskID]; !ok {
+			return Record{}, repository.ErrTaskNotFound
+		}
+		return Record{Type: TaskDeleted, TaskID: taskID}, nil
+	})
+}
+
+func (r *taskRepository) List(ctx context.Context) ([]task.Task, error) {
+	var result []task.Task
+	err := r.store.read(ctx, func(st *state) error {
+		result = st.allTasks()
+		return nil
+	})
+	return result, err
+}
+
+type taskListRepository struct {
+	store *Store
+}
+
+var (
+	_ repository.TaskListRepository = (*taskListRepository)(nil)
+	_ repository.Snapshotter        = (*taskListRepository)(nil)
+)
+
+func (r *taskListRepository) Create(ctx context.Context, taskList list.TaskList) (string, error) {
+	err := r.store.write(ctx, func(st *state) (Record, error) {
+		if _, exists := st.lists[taskList.ID]; exists {
+			return Record{}, repository.ErrConflict
+		}
+		taskList.Version = 1
+		return Record{Type: ListCreated, ListID: taskList.ID, List: &taskList}, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return taskList.ID, nil
+}
+
+func (r *taskListRepository) GetByID(ctx context.Context, taskListID string) (*list.TaskList, error) {
+	var result *list.TaskList
+	err := r.store.read(ctx, func(st *state) error {
+		var err error
+		result, err = st.listWithTasks(taskListID)
+		return err
+	})
+	return result, err
+}
+
+func (r *taskListRepository) Update(ctx context.Context, taskList list.TaskList) error {
+	return r.store.write(ctx, func(st *state) (Record, error) {
+		stored, ok := st.lists[taskList.ID]
+		if !ok {
+			return Record{}, repository.ErrTaskListNotFound
+		}
+		if stored.List.Version != taskList.Version {
+			return Record{}, repository.ErrConflict
+		}
+		taskList.Version++
+		taskList.Tasks = nil
+		return Record{Type: ListUpdated, ListID: taskList.ID, List: &taskList}, nil
+	})
+}
+
+func (r *taskListRepository) Delete(ctx context.Context, taskListID string) error {
+	return r.store.write(ctx, func(st *state) (Record, error) {
+		if _, ok := st.lists[taskListID]; !ok {
+			return Record{}, repository.ErrTaskListNotFound
+		}
+		return Record{Type: ListDeleted, ListID: taskListID}, nil
+	})
+}
+
+func (r *taskListRepository) AddTaskToList(ctx context.Context, taskID, taskListID string) error {
+	return r.store.write(ctx, func(st *state) (Record, error) {
+		if _, ok := st.lists[taskListID]; !ok {
+			return Record{}, repository.ErrTaskListNotFound
+		}
+		return Record{Type: ListTaskAdded, ListID: taskListID, TaskID: taskID}, nil
+	})
+}
+
+func (r *taskListRepository) RemoveTaskFromList(ctx context.Context, taskID, taskListID string) error {
+	return r.store.write(ctx, func(st *state) (Record, error) {
+		stored, ok := st.lists[taskListID]
+		if !ok {
+			return Record{}, repository.ErrTaskListNotFound
+		}
+		for _, id := range stored.TaskIDs {
+			if id == taskID {
+				return Record{Type: ListTaskRemoved, ListID: taskListID, TaskID: taskID}, nil
+			}
+		}
+		return Record{}, repository.ErrTaskNotFound
+	})
+}
+
+func (r *taskListRepository) GetTasksByList(ctx context.Context, taskListID string) ([]list.Task, error) {
+	var result []list.Task
+	err := r.store.read(ctx, func(st *state) error {
+		stored, ok := st.lists[taskListID]
+		if !ok {
+			return repository.ErrTaskListNotFound
+		}
+		result = st.tasksOf(stored)
+		return nil
+	})
+	return result, err
+}
+
+func (r *taskListRepository) List(ctx context.Context) ([]list.TaskList, error) {
+	var result []list.TaskList
+	err := r.store.read(ctx, func(st *state) error {
+		result = st.allLists()
+		return nil
+	})
+	return result, err
+}
+
+// ReadAll lê tarefas e listas sob o mesmo lock do Store.
+func (r *taskListRepository) ReadAll(ctx context.Context) ([]task.Task, []list.TaskList, error) {
+	var tasks []task.Task
+	var taskLists []list.TaskList
+	err := r.store.read(ctx, func(st *state) error {
+		tasks, taskLists = st.allTasks(), st.allLists()
+		return nil
+	})
+	return tasks, taskLists, err
+}
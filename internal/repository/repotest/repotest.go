@@ -0,0 +1,358 @@
+// Package repotest contém a suíte de conformidade dos repositórios. Todo
+// backend de TaskRepository/TaskListRepository deve passar por Run:
+//
+//	func TestMeuBackend(t *testing.T) {
+//		repotest.Run(t, func(t *testing.T) (repository.TaskRepository, repository.TaskListRepository) {
+//			return novoBackend(t)
+//		})
+//	}
+package repotest
+
+import (
+	"botasks/internal/list"
+	"botasks/internal/repository"
+	"botasks/internal/task"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory cria um par de repositórios vazios e ligados entre si: as tarefas
+// retornadas pelo repositório de listas vêm do repositório de tarefas.
+type Factory func(t *testing.T) (repository.TaskRepository, repository.TaskListRepository)
+
+// Run executa a suíte de conformidade contra os repositórios criados por newRepos.
+// Cada subteste recebe repositórios novos.
+func Run(t *testing.T, newRepos Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, tasks repository.TaskRepository, taskLists repository.TaskListRepository)
+	}{
+		{"TaskCRUD", testTaskCRUD},
+		{"TaskNotFound", testTaskNotFound},
+		{"TaskVersionConflict", testTaskVersionConflict},
+		{"CreateExistingID", testCreateExistingID},
+		{"TaskListCRUD", testTaskListCRUD},
+		{"TaskListNotFound", testTaskListNotFound},
+		{"TaskListVersionConflict", testTaskListVersionConflict},
+		{"TasksKeepInsertionOrder", testTasksKeepInsertionOrder},
+		{"RemoveTaskFromList", testRemoveTaskFromList},
+		{"DeleteListKeepsTasks", testDeleteListKeepsTasks},
+		{"DeleteTaskLeavesLists", testDeleteTaskLeavesLists},
+		{"List", testList},
+		{"CancelledContext", testCancelledContext},
+		{"ConcurrentAccess", testConcurrentAccess},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, taskLists := newRepos(t)
+			tt.fn(t, tasks, taskLists)
+		})
+	}
+}
+
+func testTaskCRUD(t *testing.T, tasks repository.TaskRepository, _ repository.TaskListRepository) {
+	ctx := context.Background()
+
+	taskID, err := tasks.Create(ctx, task.Task{ID: "t1", Title: "Tarefa", Description: "Descrição"})
+	require.NoError(t, err)
+	assert.Equal(t, "t1", taskID)
+
+	stored, err := tasks.GetByID(ctx, "t1")
+	require.NoError(t, err)
+	assert.Equal(t, "Tarefa", stored.Title)
+	assert.Equal(t, "Descrição", stored.Description)
+	assert.Equal(t, 1, stored.Version)
+
+	stored.Title = "Alterada"
+	require.NoError(t, tasks.Update(ctx, *stored))
+
+	updated, err := tasks.GetByID(ctx, "t1")
+	require.NoError(t, err)
+	assert.Equal(t, "Alterada", updated.Title)
+	assert.Equal(t, 2, updated.Version)
+
+	require.NoError(t, tasks.Delete(ctx, "t1"))
+	_, err = tasks.GetByID(ctx, "t1")
+	assert.ErrorIs(t, err, repository.ErrTaskNotFound)
+}
+
+func testTaskNotFound(t *testing.T, tasks repository.TaskRepository, _ repository.TaskListRepository) {
+	ctx := context.Background()
+
+	_, err := tasks.GetByID(ctx, "missing")
+	assert.ErrorIs(t, err, repository.ErrTaskNotFound)
+	assert.ErrorIs(t, tasks.Update(ctx, task.Task{ID: "missing", Version: 1}), repository.ErrTaskNotFound)
+	assert.ErrorIs(t, tasks.Delete(ctx, "missing"), repository.ErrTaskNotFound)
+}
+
+func testTaskVersionConflict(t *testing.T, tasks repository.TaskRepository, _ repository.TaskListRepository) {
+	ctx := context.Background()
+
+	_, err := tasks.Create(ctx, task.Task{ID: "t1", Title: "Tarefa"})
+	require.NoError(t, err)
+	stale, err := tasks.GetByID(ctx, "t1")
+	require.NoError(t, err)
+
+	fresh := *stale
+	fresh.Title = "Primeira"
+	require.NoError(t, tasks.Update(ctx, fresh))
+
+	stale.Title = "Segunda"
+	assert.ErrorIs(t, tasks.Update(ctx, *stale), repository.ErrConflict)
+
+	stored, err := tasks.GetByID(ctx, "t1")
+	require.NoError(t, err)
+	assert.Equal(t, "Primeira", stored.Title)
+}
+
+func testCreateExistingID(t *testing.T, tasks repository.TaskRepository, taskLists repository.TaskListRepository) {
+	ctx := context.Background()
+
+	_, err := tasks.Create(ctx, task.Task{ID: "t1", Title: "Original"})
+	require.NoError(t, err)
+	_, err = tasks.Create(ctx, task.Task{ID: "t1", Title: "Outra"})
+	assert.ErrorIs(t, err, repository.ErrConflict)
+	stored, err := tasks.GetByID(ctx, "t1")
+	require.NoError(t, err)
+	assert.Equal(t, "Original", stored.Title)
+
+	_, err = taskLists.Create(ctx, list.TaskList{ID: "l1", Name: "Original"})
+	require.NoError(t, err)
+	_, err = taskLists.Create(ctx, list.TaskList{ID: "l1", Name: "Outra"})
+	assert.ErrorIs(t, err, repository.ErrConflict)
+	storedList, err := taskLists.GetByID(ctx, "l1")
+	require.NoError(t, err)
+	assert.Equal(t, "Original", storedList.Name)
+}
+
+func testTaskListCRUD(t *testing.T, _ repository.TaskRepository, taskLists repository.TaskListRepository) {
+	ctx := context.Background()
+
+	taskListID, err := taskLists.Create(ctx, list.TaskList{ID: "l1", Name: "Lista"})
+	require.NoError(t, err)
+	assert.Equal(t, "l1", taskListID)
+
+	stored, err := taskLists.GetByID(ctx, "l1")
+	require.NoError(t, err)
+	assert.Equal(t, "Lista", stored.Name)
+	assert.Equal(t, 1, stored.Version)
+	assert.Empty(t, stored.Tasks)
+
+	stored.Name = "Renomeada"
+	require.NoError(t, taskLists.Update(ctx, *stored))
+
+	updated, err := taskLists.GetByID(ctx, "l1")
+	require.NoError(t, err)
+	assert.Equal(t, "Renomeada", updated.Name)
+	assert.Equal(t, 2, updated.Version)
+
+	require.NoError(t, taskLists.Delete(ctx, "l1"))
+	_, err = taskLists.GetByID(ctx, "l1")
+	assert.ErrorIs(t, err, repository.ErrTaskListNotFound)
+}
+
+func testTaskListNotFound(t *testing.T, _ repository.TaskRepository, taskLists repository.TaskListRepository) {
+	ctx := context.Background()
+
+	_, err := taskLists.GetByID(ctx, "missing")
+	assert.ErrorIs(t, err, repository.ErrTaskListNotFound)
+	assert.ErrorIs(t, taskLists.Update(ctx, list.TaskList{ID: "missing", Version: 1}), repository.ErrTaskListNotFound)
+	assert.ErrorIs(t, taskLists.Delete(ctx, "missing"), repository.ErrTaskListNotFound)
+	assert.ErrorIs(t, taskLists.AddTaskToList(ctx, "t1", "missing"), repository.ErrTaskListNotFound)
+	assert.ErrorIs(t, taskLists.RemoveTaskFromList(ctx, "t1", "missing"), repository.ErrTaskListNotFound)
+	_, err = taskLists.GetTasksByList(ctx, "missing")
+	assert.ErrorIs(t, err, repository.ErrTaskListNotFound)
+}
+
+func testTaskListVersionConflict(t *testing.T, _ repository.TaskRepository, taskLists repository.TaskListRepository) {
+	ctx := context.Background()
+
+	_, err := taskLists.Create(ctx, list.TaskList{ID: "l1", Name: "Lista"})
+	require.NoError(t, err)
+	stale, err := taskLists.GetByID(ctx, "l1")
+	require.NoError(t, err)
+
+	fresh := *stale
+	fresh.Name = "Primeira"
+	require.NoError(t, taskLists.Update(ctx, fresh))
+
+	stale.Name = "Segunda"
+	assert.ErrorIs(t, taskLists.Update(ctx, *stale), repository.ErrConflict)
+}
+
+func testTasksKeepInsertionOrder(t *testing.T, tasks repository.TaskRepository, taskLists repository.TaskListRepository) {
+	ctx := context.Background()
+
+	_, err := taskLists.Create(ctx, list.TaskList{ID: "l1", Name: "Lista"})
+	require.NoError(t, err)
+
+	// IDs em ordem não alfabética para não depender de ordenação por chave
+	ids := []string{"c", "a", "d", "b"}
+	for _, id := range ids {
+		_, err := tasks.Create(ctx, task.Task{ID: id, Title: id})
+		require.NoError(t, err)
+		require.NoError(t, taskLists.AddTaskToList(ctx, id, "l1"))
+	}
+
+	listTasks, err := taskLists.GetTasksByList(ctx, "l1")
+	require.NoError(t, err)
+	require.Len(t, listTasks, len(ids))
+	for i, id := range ids {
+		assert.Equal(t, id, listTasks[i].ID)
+	}
+
+	stored, err := taskLists.GetByID(ctx, "l1")
+	require.NoError(t, err)
+	require.Len(t, stored.Tasks, len(ids))
+	for i, id := range ids {
+		assert.Equal(t, id, stored.Tasks[i].ID)
+	}
+}
+
+func testRemoveTaskFromList(t *testing.T, tasks repository.TaskRepository, taskLists repository.TaskListRepository) {
+	ctx := context.Background()
+
+	_, err := taskLists.Create(ctx, list.TaskList{ID: "l1", Name: "Lista"})
+	require.NoError(t, err)
+	for _, id := range []string{"t1", "t2"} {
+		_, err := tasks.Create(ctx, task.Task{ID: id})
+		require.NoError(t, err)
+		require.NoError(t, taskLists.AddTaskToList(ctx, id, "l1"))
+	}
+
+	require.NoError(t, taskLists.RemoveTaskFromList(ctx, "t1", "l1"))
+	assert.ErrorIs(t, taskLists.RemoveTaskFromList(ctx, "t1", "l1"), repository.ErrTaskNotFound)
+
+	listTasks, err := taskLists.GetTasksByList(ctx, "l1")
+	require.NoError(t, err)
+	require.Len(t, listTasks, 1)
+	assert.Equal(t, "t2", listTasks[0].ID)
+
+	// A tarefa removida da lista continua existindo
+	_, err = tasks.GetByID(ctx, "t1")
+	assert.NoError(t, err)
+}
+
+func testDeleteListKeepsTasks(t *testing.T, tasks repository.TaskRepository, taskLists repository.TaskListRepository) {
+	ctx := context.Background()
+
+	_, err := taskLists.Create(ctx, list.TaskList{ID: "l1", Name: "Lista"})
+	require.NoError(t, err)
+	_, err = tasks.Create(ctx, task.Task{ID: "t1"})
+	require.NoError(t, err)
+	require.NoError(t, taskLists.AddTaskToList(ctx, "t1", "l1"))
+
+	require.NoError(t, taskLists.Delete(ctx, "l1"))
+
+	_, err = tasks.GetByID(ctx, "t1")
+	assert.NoError(t, err)
+}
+
+func testDeleteTaskLeavesLists(t *testing.T, tasks repository.TaskRepository, taskLists repository.TaskListRepository) {
+	ctx := context.Background()
+
+	_, err := taskLists.Create(ctx, list.TaskList{ID: "l1", Name: "Lista"})
+	require.NoError(t, err)
+	for _, id := range []string{"t1", "t2"} {
+		_, err := tasks.Create(ctx, task.Task{ID: id})
+		require.NoError(t, err)
+		require.NoError(t, taskLists.AddTaskToList(ctx, id, "l1"))
+	}
+
+	require.NoError(t, tasks.Delete(ctx, "t1"))
+
+	// Tarefas excluídas deixam de aparecer nas listas, que continuam válidas
+	listTasks, err := taskLists.GetTasksByList(ctx, "l1")
+	require.NoError(t, err)
+	require.Len(t, listTasks, 1)
+	assert.Equal(t, "t2", listTasks[0].ID)
+}
+
+func testList(t *testing.T, tasks repository.TaskRepository, taskLists repository.TaskListRepository) {
+	ctx := context.Background()
+
+	allTasks, err := tasks.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, allTasks)
+
+	for _, id := range []string{"t2", "t1", "t3"} {
+		_, err := tasks.Create(ctx, task.Task{ID: id, Title: id})
+		require.NoError(t, err)
+	}
+	for _, id := range []string{"l2", "l1"} {
+		_, err := taskLists.Create(ctx, list.TaskList{ID: id, Name: id})
+		require.NoError(t, err)
+	}
+	require.NoError(t, taskLists.AddTaskToList(ctx, "t3", "l1"))
+	require.NoError(t, taskLists.AddTaskToList(ctx, "t1", "l1"))
+
+	allTasks, err = tasks.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, allTasks, 3)
+	assert.Equal(t, []string{"t1", "t2", "t3"}, []string{allTasks[0].ID, allTasks[1].ID, allTasks[2].ID})
+
+	allLists, err := taskLists.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, allLists, 2)
+	assert.Equal(t, "l1", allLists[0].ID)
+	assert.Equal(t, "l2", allLists[1].ID)
+	require.Len(t, allLists[0].Tasks, 2)
+	assert.Equal(t, "t3", allLists[0].Tasks[0].ID)
+	assert.Equal(t, "t1", allLists[0].Tasks[1].ID)
+	assert.Empty(t, allLists[1].Tasks)
+
+	if snapshotter, ok := taskLists.(repository.Snapshotter); ok {
+		snapTasks, snapLists, err := snapshotter.ReadAll(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, allTasks, snapTasks)
+		assert.Equal(t, allLists, snapLists)
+	}
+}
+
+func testCancelledContext(t *testing.T, tasks repository.TaskRepository, taskLists repository.TaskListRepository) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := tasks.Create(ctx, task.Task{ID: "t1"})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = taskLists.Create(ctx, list.TaskList{ID: "l1"})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = tasks.GetByID(context.Background(), "t1")
+	assert.ErrorIs(t, err, repository.ErrTaskNotFound)
+}
+
+func testConcurrentAccess(t *testing.T, tasks repository.TaskRepository, taskLists repository.TaskListRepository) {
+	ctx := context.Background()
+
+	_, err := taskLists.Create(ctx, list.TaskList{ID: "l1", Name: "Lista"})
+	require.NoError(t, err)
+
+	const workers, perWorker = 8, 25
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				taskID := fmt.Sprintf("w%d-%d", w, i)
+				_, err := tasks.Create(ctx, task.Task{ID: taskID})
+				assert.NoError(t, err)
+				assert.NoError(t, taskLists.AddTaskToList(ctx, taskID, "l1"))
+				_, err = taskLists.GetTasksByList(ctx, "l1")
+				assert.NoError(t, err)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	listTasks, err := taskLists.GetTasksByList(ctx, "l1")
+	require.NoError(t, err)
+	assert.Len(t, listTasks, workers*perWorker)
+}
@@ -2,60 +2,76 @@ package repository
 
 import (
 	"botasks/internal/task"
-	"errors"
+	"context"
+	"sort"
 	"sync"
 )
 
 type TaskRepository interface {
-	Create(task task.Task) (string, error)
-	GetByID(taskID string) (*task.Task, error)
-	Update(task task.Task) error
-	Delete(taskID string) error
+	Create(ctx context.Context, task task.Task) (string, error)
+	GetByID(ctx context.Context, taskID string) (*task.Task, error)
+	Update(ctx context.Context, task task.Task) error
+	Delete(ctx context.Context, taskID string) error
+	List(ctx context.Context) ([]task.Task, error)
 }
 
-type MemoryTask struct {
-	ID          string
-	Title       string
-	Description string
-}
+var _ TaskRepository = (*MemoryTaskRepository)(nil)
 
+// MemoryTaskRepository armazena tarefas em memória. Leituras usam RLock e
+// podem acontecer em paralelo; escritas são exclusivas.
 type MemoryTaskRepository struct {
-	tasks map[string]MemoryTask
-	mu    sync.Mutex
+	tasks map[string]task.Task
+	mu    sync.RWMutex
 }
 
-
 func NewMemoryTaskRepository() *MemoryTaskRepository {
 	return &MemoryTaskRepository{
-		tasks: make(map[string]MemoryTask),
+		tasks: make(map[string]task.Task),
 	}
 }
 
-func (r *MemoryTaskRepository) Create(task MemoryTask) (string, error) {
+// Create armazena a tarefa com a versão inicial 1.
+func (r *MemoryTaskRepository) Create(ctx context.Context, task task.Task) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	taskID := task.ID
+	if _, exists := r.tasks[taskID]; exists {
+		return "", ErrConflict
+	}
+	task.Version = 1
 	r.tasks[taskID] = task
 	return taskID, nil
 }
 
-func (r *MemoryTaskRepository) GetByID(taskID string) (*MemoryTask, error) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+func (r *MemoryTaskRepository) GetByID(ctx context.Context, taskID string) (*task.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
 	task, exists := r.tasks[taskID]
 	if !exists {
-		return nil, errors.New("Task not found")
+		return nil, ErrTaskNotFound
 	}
 	return &task, nil
 }
 
-func (r *MemoryTaskRepository) GetTasksByIDs(taskIDs []string) ([]MemoryTask, error) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+func (r *MemoryTaskRepository) GetTasksByIDs(ctx context.Context, taskIDs []string) ([]task.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-	tasks := make([]MemoryTask, 0, len(taskIDs))
+	tasks := make([]task.Task, 0, len(taskIDs))
 	for _, taskID := range taskIDs {
 		task, exists := r.tasks[taskID]
 		if exists {
@@ -66,26 +82,61 @@ func (r *MemoryTaskRepository) GetTasksByIDs(taskIDs []string) ([]MemoryTask, er
 	return tasks, nil
 }
 
-func (r *MemoryTaskRepository) Update(task MemoryTask) error {
+// List retorna todas as tarefas ordenadas pelo ID.
+func (r *MemoryTaskRepository) List(ctx context.Context) ([]task.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.listLocked(), nil
+}
+
+func (r *MemoryTaskRepository) listLocked() []task.Task {
+	tasks := make([]task.Task, 0, len(r.tasks))
+	for _, t := range r.tasks {
+		tasks = append(tasks, t)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks
+}
+
+// Update substitui a tarefa armazenada se task.Version for igual à versão
+// atual, retornando ErrConflict caso contrário. A versão é incrementada.
+func (r *MemoryTaskRepository) Update(ctx context.Context, task task.Task) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	_, exists := r.tasks[task.ID]
+	stored, exists := r.tasks[task.ID]
 	if !exists {
-		return errors.New("Task not found")
+		return ErrTaskNotFound
+	}
+	if stored.Version != task.Version {
+		return ErrConflict
 	}
 
+	task.Version++
 	r.tasks[task.ID] = task
 	return nil
 }
 
-func (r *MemoryTaskRepository) Delete(taskID string) error {
+func (r *MemoryTaskRepository) Delete(ctx context.Context, taskID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	_, exists := r.tasks[taskID]
 	if !exists {
-		return errors.New("Task not found")
+		return ErrTaskNotFound
 	}
 
 	delete(r.tasks, taskID)
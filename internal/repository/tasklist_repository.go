@@ -2,114 +2,273 @@ package repository
 
 import (
 	"botasks/internal/list"
-	"errors"
+	"botasks/internal/task"
+	"context"
+	"sort"
 	"sync"
 )
 
 type TaskListRepository interface {
-	Create(taskList list.TaskList) (string, error)
-	GetByID(taskListID string) (*list.TaskList, error)
-	Update(taskList list.TaskList) error
-	Delete(taskListID string) error
-	AddTaskToList(taskID, taskListID string) error
-	GetTasksByList(taskListID string) ([]list.Task, error)
+	Create(ctx context.Context, taskList list.TaskList) (string, error)
+	GetByID(ctx context.Context, taskListID string) (*list.TaskList, error)
+	Update(ctx context.Context, taskList list.TaskList) error
+	Delete(ctx context.Context, taskListID string) error
+	AddTaskToList(ctx context.Context, taskID, taskListID string) error
+	RemoveTaskFromList(ctx context.Context, taskID, taskListID string) error
+	GetTasksByList(ctx context.Context, taskListID string) ([]list.Task, error)
+	List(ctx context.Context) ([]list.TaskList, error)
 }
 
-type MemoryTaskList struct {
-	ID    string
-	Name  string
-	Tasks []string // IDs das tarefas associadas a esta lista
+// Snapshotter é implementado pelos repositórios de listas capazes de ler
+// todas as tarefas e listas de uma só vez, sem escritas intercaladas. Sem
+// ele, quem precisa de uma cópia consistente (como um backup) só consegue
+// chamar List em cada repositório separadamente.
+type Snapshotter interface {
+	ReadAll(ctx context.Context) ([]task.Task, []list.TaskList, error)
 }
 
+var (
+	_ TaskListRepository = (*MemoryTaskListRepository)(nil)
+	_ UnitOfWorkFactory  = (*MemoryTaskListRepository)(nil)
+	_ Snapshotter        = (*MemoryTaskListRepository)(nil)
+)
+
+// memoryTaskList guarda os dados da lista separados das tarefas associadas,
+// que ficam no MemoryTaskRepository e são referenciadas apenas pelo ID.
+//
+// taskIDs nunca é alterado no lugar: AddTaskToList só acrescenta depois do
+// fim e RemoveTaskFromList cria um novo slice. Assim um leitor pode copiar o
+// slice sob RLock e percorrê-lo depois de liberar o lock.
+type memoryTaskList struct {
+	taskList list.TaskList // sem o campo Tasks
+	taskIDs  []string      // IDs das tarefas associadas a esta lista
+}
+
+// MemoryTaskListRepository armazena listas em memória e busca as tarefas no
+// MemoryTaskRepository associado.
+//
+// Ordem dos locks: nenhum método mantém r.mu enquanto chama o taskRepo. As
+// leituras copiam os IDs sob r.mu.RLock, liberam o lock e só então consultam
+// o taskRepo. A única exceção é ReadAll, que segura r.mu e depois
+// taskRepo.mu, sempre nessa ordem; nenhum método faz o caminho inverso.
 type MemoryTaskListRepository struct {
-	taskLists map[string]MemoryTaskList
+	taskLists map[string]memoryTaskList
 	taskRepo  *MemoryTaskRepository // Adicione uma referência ao MemoryTaskRepository
-	mu        sync.Mutex
+	mu        sync.RWMutex
 }
 
-
 // Ao criar um novo MemoryTaskListRepository, inicialize-o com uma referência a um MemoryTaskRepository
 func NewMemoryTaskListRepository(taskRepo *MemoryTaskRepository) *MemoryTaskListRepository {
 	return &MemoryTaskListRepository{
-		taskLists: make(map[string]MemoryTaskList),
+		taskLists: make(map[string]memoryTaskList),
 		taskRepo:  taskRepo, // Inicialize o campo taskRepo
 	}
 }
 
-func (r *MemoryTaskListRepository) Create(taskList MemoryTaskList) (string, error) {
+// Create armazena a lista com a versão inicial 1.
+func (r *MemoryTaskListRepository) Create(ctx context.Context, taskList list.TaskList) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if _, exists := r.taskLists[taskList.ID]; exists {
+		return "", ErrConflict
+	}
+	taskIDs := make([]string, 0, len(taskList.Tasks))
+	for _, t := range taskList.Tasks {
+		taskIDs = append(taskIDs, t.ID)
+	}
+	taskList.Tasks = nil
+	taskList.Version = 1
+
 	taskListID := taskList.ID
-	r.taskLists[taskListID] = taskList
+	r.taskLists[taskListID] = memoryTaskList{taskList: taskList, taskIDs: taskIDs}
 	return taskListID, nil
 }
 
-func (r *MemoryTaskListRepository) GetByID(taskListID string) (*MemoryTaskList, error) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+// GetByID retorna a lista com o campo Tasks preenchido a partir do repositório de tarefas.
+func (r *MemoryTaskListRepository) GetByID(ctx context.Context, taskListID string) (*list.TaskList, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-	taskList, exists := r.taskLists[taskListID]
-	if !exists {
-		return nil, errors.New("TaskList not found")
+	stored, err := r.snapshot(taskListID)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := r.taskRepo.GetTasksByIDs(ctx, stored.taskIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	taskList := stored.taskList
+	taskList.Tasks = make([]list.Task, len(tasks))
+	for i, t := range tasks {
+		taskList.Tasks[i] = list.Task{Task: t}
 	}
 	return &taskList, nil
 }
 
-func (r *MemoryTaskListRepository) Update(taskList MemoryTaskList) error {
+// Update substitui os dados da lista se taskList.Version for igual à versão
+// atual, retornando ErrConflict caso contrário. As tarefas associadas são
+// mantidas; use AddTaskToList para alterá-las.
+func (r *MemoryTaskListRepository) Update(ctx context.Context, taskList list.TaskList) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	_, exists := r.taskLists[taskList.ID]
+	stored, exists := r.taskLists[taskList.ID]
 	if !exists {
-		return errors.New("TaskList not found")
+		return ErrTaskListNotFound
+	}
+	if stored.taskList.Version != taskList.Version {
+		return ErrConflict
 	}
 
-	r.taskLists[taskList.ID] = taskList
+	taskList.Tasks = nil
+	taskList.Version++
+	stored.taskList = taskList
+	r.taskLists[taskList.ID] = stored
 	return nil
 }
 
-func (r *MemoryTaskListRepository) Delete(taskListID string) error {
+func (r *MemoryTaskListRepository) Delete(ctx context.Context, taskListID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	_, exists := r.taskLists[taskListID]
 	if !exists {
-		return errors.New("TaskList not found")
+		return ErrTaskListNotFound
 	}
 
 	delete(r.taskLists, taskListID)
 	return nil
 }
 
-func (r *MemoryTaskListRepository) AddTaskToList(taskID, taskListID string) error {
+func (r *MemoryTaskListRepository) AddTaskToList(ctx context.Context, taskID, taskListID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	taskList, exists := r.taskLists[taskListID]
+	stored, exists := r.taskLists[taskListID]
 	if !exists {
-		return errors.New("TaskList not found")
+		return ErrTaskListNotFound
 	}
 
-	taskList.Tasks = append(taskList.Tasks, taskID)
-	r.taskLists[taskListID] = taskList
+	stored.taskIDs = append(stored.taskIDs, taskID)
+	r.taskLists[taskListID] = stored
 	return nil
 }
 
-func (r *MemoryTaskListRepository) GetTasksByList(taskListID string) ([]MemoryTask, error) {
+// RemoveTaskFromList desassocia a tarefa da lista. A tarefa não é excluída.
+func (r *MemoryTaskListRepository) RemoveTaskFromList(ctx context.Context, taskID, taskListID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	taskList, exists := r.taskLists[taskListID]
+	stored, exists := r.taskLists[taskListID]
 	if !exists {
-		return nil, errors.New("TaskList not found")
+		return ErrTaskListNotFound
+	}
+
+	for i, id := range stored.taskIDs {
+		if id == taskID {
+			stored.taskIDs = append(stored.taskIDs[:i:i], stored.taskIDs[i+1:]...)
+			r.taskLists[taskListID] = stored
+			return nil
+		}
 	}
+	return ErrTaskNotFound
+}
 
-	taskIDs := taskList.Tasks
-	tasks, err := r.taskRepo.GetTasksByIDs(taskIDs) // Use o método GetTasksByIDs do taskRepo
+func (r *MemoryTaskListRepository) GetTasksByList(ctx context.Context, taskListID string) ([]list.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	stored, err := r.snapshot(taskListID)
 	if err != nil {
 		return nil, err
 	}
 
-	return tasks, nil
+	tasks, err := r.taskRepo.GetTasksByIDs(ctx, stored.taskIDs) // Use o método GetTasksByIDs do taskRepo
+	if err != nil {
+		return nil, err
+	}
+
+	listTasks := make([]list.Task, len(tasks))
+	for i, t := range tasks {
+		listTasks[i] = list.Task{Task: t}
+	}
+	return listTasks, nil
+}
+
+// List retorna todas as listas, com o campo Tasks preenchido, ordenadas pelo ID.
+func (r *MemoryTaskListRepository) List(ctx context.Context) ([]list.TaskList, error) {
+	_, taskLists, err := r.ReadAll(ctx)
+	return taskLists, err
+}
+
+// ReadAll retorna todas as tarefas e listas em um mesmo instante, segurando
+// os locks dos dois repositórios durante a leitura.
+func (r *MemoryTaskListRepository) ReadAll(ctx context.Context) ([]task.Task, []list.TaskList, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	r.taskRepo.mu.RLock()
+	defer r.taskRepo.mu.RUnlock()
+
+	taskLists := make([]list.TaskList, 0, len(r.taskLists))
+	for _, stored := range r.taskLists {
+		taskList := stored.taskList
+		taskList.Tasks = make([]list.Task, 0, len(stored.taskIDs))
+		for _, taskID := range stored.taskIDs {
+			if t, exists := r.taskRepo.tasks[taskID]; exists {
+				taskList.Tasks = append(taskList.Tasks, list.Task{Task: t})
+			}
+		}
+		taskLists = append(taskLists, taskList)
+	}
+	sort.Slice(taskLists, func(i, j int) bool { return taskLists[i].ID < taskLists[j].ID })
+	return r.taskRepo.listLocked(), taskLists, nil
+}
+
+// snapshot copia a lista armazenada sob RLock. O slice taskIDs copiado pode
+// ser lido depois que o lock é liberado (veja memoryTaskList).
+func (r *MemoryTaskListRepository) snapshot(taskListID string) (memoryTaskList, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, exists := r.taskLists[taskListID]
+	if !exists {
+		return memoryTaskList{}, ErrTaskListNotFound
+	}
+	return stored, nil
+}
+
+// Begin inicia uma unidade de trabalho sobre este repositório e o
+// MemoryTaskRepository associado. Como o armazenamento em memória não tem
+// transações, as operações são compensadas em caso de Rollback.
+func (r *MemoryTaskListRepository) Begin(ctx context.Context) (UnitOfWork, error) {
+	return NewJournalUnitOfWorkFactory(r.taskRepo, r).Begin(ctx)
 }
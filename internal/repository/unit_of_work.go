@@ -0,0 +1,301 @@
+package repository
+
+import (
+	"botasks/internal/list"
+	"botasks/internal/task"
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrUnitOfWorkDone é retornado ao usar uma unidade de trabalho que já foi
+// confirmada ou desfeita.
+var ErrUnitOfWorkDone = errors.New("unit of work already finished")
+
+// UnitOfWork agrupa operações sobre tarefas e listas que devem ser aplicadas
+// de forma atômica. As operações feitas pelos repositórios retornados por
+// Tasks e TaskLists só são definitivas após Commit; Rollback as desfaz.
+//
+// O isolamento depende da implementação. A JournalUnitOfWorkFactory garante
+// apenas atomicidade: as escritas ficam visíveis para os demais leitores
+// assim que feitas, antes do Commit (read uncommitted), e um Rollback as
+// desfaz depois. Quem precisar de isolamento deve usar um backend com
+// transações, como um banco SQL.
+type UnitOfWork interface {
+	Tasks() TaskRepository
+	TaskLists() TaskListRepository
+	Commit() error
+	Rollback() error
+}
+
+// UnitOfWorkFactory inicia unidades de trabalho. Cada backend fornece a sua
+// implementação: um backend SQL usaria uma transação do banco.
+type UnitOfWorkFactory interface {
+	Begin(ctx context.Context) (UnitOfWork, error)
+}
+
+// JournalUnitOfWorkFactory cria unidades de trabalho que aplicam as operações
+// imediatamente e registram uma ação de compensação para cada uma. Rollback
+// executa as compensações em ordem inversa. Serve para backends sem
+// transações nativas, como o de memória. Não há isolamento: outros leitores
+// veem as escritas ainda não confirmadas até que o Rollback as compense.
+type JournalUnitOfWorkFactory struct {
+	tasks     TaskRepository
+	taskLists TaskListRepository
+}
+
+// NewJournalUnitOfWorkFactory cria uma fábrica sobre os repositórios informados.
+func NewJournalUnitOfWorkFactory(tasks TaskRepository, taskLists TaskListRepository) *JournalUnitOfWorkFactory {
+	return &JournalUnitOfWorkFactory{tasks: tasks, taskLists: taskLists}
+}
+
+func (f *JournalUnitOfWorkFactory) Begin(ctx context.Context) (UnitOfWork, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	u := &journalUnitOfWork{ctx: context.WithoutCancel(ctx)}
+	u.tasks = &journalTaskRepository{inner: f.tasks, uow: u}
+	u.taskLists = &journalTaskListRepository{inner: f.taskLists, uow: u}
+	return u, nil
+}
+
+type journalUnitOfWork struct {
+	ctx       context.Context
+	tasks     *journalTaskRepository
+	taskLists *journalTaskListRepository
+	undo      []func(ctx context.Context) error
+	done      bool
+	mu        sync.Mutex
+}
+
+func (u *journalUnitOfWork) Tasks() TaskRepository         { return u.tasks }
+func (u *journalUnitOfWork) TaskLists() TaskListRepository { return u.taskLists }
+
+func (u *journalUnitOfWork) record(undo func(ctx context.Context) error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.undo = append(u.undo, undo)
+}
+
+func (u *journalUnitOfWork) check() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.done {
+		return ErrUnitOfWorkDone
+	}
+	return nil
+}
+
+func (u *journalUnitOfWork) Commit() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.done {
+		return ErrUnitOfWorkDone
+	}
+	u.done = true
+	u.undo = nil
+	return nil
+}
+
+// Rollback executa as compensações em ordem inversa. Todas são tentadas
+// mesmo que alguma falhe; os erros são combinados. As compensações ignoram
+// o cancelamento do contexto da operação original, para que uma requisição
+// cancelada não deixe o rollback pela metade.
+func (u *journalUnitOfWork) Rollback() error {
+	u.mu.Lock()
+	if u.done {
+		u.mu.Unlock()
+		return ErrUnitOfWorkDone
+	}
+	u.done = true
+	undo := u.undo
+	u.undo = nil
+	u.mu.Unlock()
+
+	var errs []error
+	for i := len(undo) - 1; i >= 0; i-- {
+		if err := undo[i](u.ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type journalTaskRepository struct {
+	inner TaskRepository
+	uow   *journalUnitOfWork
+}
+
+func (r *journalTaskRepository) Create(ctx context.Context, t task.Task) (string, error) {
+	if err := r.uow.check(); err != nil {
+		return "", err
+	}
+	taskID, err := r.inner.Create(ctx, t)
+	if err != nil {
+		return "", err
+	}
+	r.uow.record(func(ctx context.Context) error { return r.inner.Delete(ctx, taskID) })
+	return taskID, nil
+}
+
+func (r *journalTaskRepository) GetByID(ctx context.Context, taskID string) (*task.Task, error) {
+	if err := r.uow.check(); err != nil {
+		return nil, err
+	}
+	return r.inner.GetByID(ctx, taskID)
+}
+
+func (r *journalTaskRepository) Update(ctx context.Context, t task.Task) error {
+	if err := r.uow.check(); err != nil {
+		return err
+	}
+	prev, err := r.inner.GetByID(ctx, t.ID)
+	if err != nil {
+		return err
+	}
+	if err := r.inner.Update(ctx, t); err != nil {
+		return err
+	}
+	r.uow.record(func(ctx context.Context) error {
+		current, err := r.inner.GetByID(ctx, prev.ID)
+		if err != nil {
+			return err
+		}
+		restored := *prev
+		restored.Version = current.Version
+		return r.inner.Update(ctx, restored)
+	})
+	return nil
+}
+
+func (r *journalTaskRepository) Delete(ctx context.Context, taskID string) error {
+	if err := r.uow.check(); err != nil {
+		return err
+	}
+	prev, err := r.inner.GetByID(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	if err := r.inner.Delete(ctx, taskID); err != nil {
+		return err
+	}
+	r.uow.record(func(ctx context.Context) error {
+		_, err := r.inner.Create(ctx, *prev)
+		return err
+	})
+	return nil
+}
+
+func (r *journalTaskRepository) List(ctx context.Context) ([]task.Task, error) {
+	if err := r.uow.check(); err != nil {
+		return nil, err
+	}
+	return r.inner.List(ctx)
+}
+
+type journalTaskListRepository struct {
+	inner TaskListRepository
+	uow   *journalUnitOfWork
+}
+
+func (r *journalTaskListRepository) Create(ctx context.Context, taskList list.TaskList) (string, error) {
+	if err := r.uow.check(); err != nil {
+		return "", err
+	}
+	taskListID, err := r.inner.Create(ctx, taskList)
+	if err != nil {
+		return "", err
+	}
+	r.uow.record(func(ctx context.Context) error { return r.inner.Delete(ctx, taskListID) })
+	return taskListID, nil
+}
+
+func (r *journalTaskListRepository) GetByID(ctx context.Context, taskListID string) (*list.TaskList, error) {
+	if err := r.uow.check(); err != nil {
+		return nil, err
+	}
+	return r.inner.GetByID(ctx, taskListID)
+}
+
+func (r *journalTaskListRepository) Update(ctx context.Context, taskList list.TaskList) error {
+	if err := r.uow.check(); err != nil {
+		return err
+	}
+	prev, err := r.inner.GetByID(ctx, taskList.ID)
+	if err != nil {
+		return err
+	}
+	if err := r.inner.Update(ctx, taskList); err != nil {
+		return err
+	}
+	r.uow.record(func(ctx context.Context) error {
+		current, err := r.inner.GetByID(ctx, prev.ID)
+		if err != nil {
+			return err
+		}
+		restored := *prev
+		restored.Version = current.Version
+		return r.inner.Update(ctx, restored)
+	})
+	return nil
+}
+
+// Delete guarda a lista com as tarefas associadas para que Rollback possa
+// recriá-la com as mesmas associações.
+func (r *journalTaskListRepository) Delete(ctx context.Context, taskListID string) error {
+	if err := r.uow.check(); err != nil {
+		return err
+	}
+	prev, err := r.inner.GetByID(ctx, taskListID)
+	if err != nil {
+		return err
+	}
+	if err := r.inner.Delete(ctx, taskListID); err != nil {
+		return err
+	}
+	r.uow.record(func(ctx context.Context) error {
+		_, err := r.inner.Create(ctx, *prev)
+		return err
+	})
+	return nil
+}
+
+func (r *journalTaskListRepository) AddTaskToList(ctx context.Context, taskID, taskListID string) error {
+	if err := r.uow.check(); err != nil {
+		return err
+	}
+	if err := r.inner.AddTaskToList(ctx, taskID, taskListID); err != nil {
+		return err
+	}
+	r.uow.record(func(ctx context.Context) error { return r.inner.RemoveTaskFromList(ctx, taskID, taskListID) })
+	return nil
+}
+
+func (r *journalTaskListRepository) RemoveTaskFromList(ctx context.Context, taskID, taskListID string) error {
+	if err := r.uow.check(); err != nil {
+		return err
+	}
+	if err := r.inner.RemoveTaskFromList(ctx, taskID, taskListID); err != nil {
+		return err
+	}
+	r.uow.record(func(ctx context.Context) error { return r.inner.AddTaskToList(ctx, taskID, taskListID) })
+	return nil
+}
+
+func (r *journalTaskListRepository) GetTasksByList(ctx context.Context, taskListID string) ([]list.Task, error) {
+	if err := r.uow.check(); err != nil {
+		return nil, err
+	}
+	return r.inner.GetTasksByList(ctx, taskListID)
+}
+
+func (r *journalTaskListRepository) List(ctx context.Context) ([]list.TaskList, error) {
+	if err := r.uow.check(); err != nil {
+		return nil, err
+	}
+	return r.inner.List(ctx)
+}
@@ -0,0 +1,32 @@
+package service
+
+import "context"
+
+type (
+	actorKey     struct{}
+	operationKey struct{}
+)
+
+// ContextWithActor retorna um contexto que identifica o autor das operações.
+// O serviço registra esse autor em CreatedBy/UpdatedBy.
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext retorna o autor registrado no contexto, ou "" se não houver.
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// ContextWithOperation agrupa os eventos publicados com este contexto sob o
+// mesmo OperationID. O serviço cria um ID novo quando o contexto não tem um.
+func ContextWithOperation(ctx context.Context, operationID string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operationID)
+}
+
+// OperationFromContext retorna o ID de operação do contexto, ou "" se não houver.
+func OperationFromContext(ctx context.Context) string {
+	operationID, _ := ctx.Value(operationKey{}).(string)
+	return operationID
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"botasks/internal/event"
+	"botasks/internal/repository"
+	"botasks/internal/task"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidParent é retornado quando o ParentID de uma tarefa aponta para
+// ela mesma ou para uma descendente dela.
+var ErrInvalidParent = errors.New("invalid parent task")
+
+// SetTaskParent torna a tarefa uma subtarefa de parentID. Um parentID vazio
+// a torna uma tarefa de primeiro nível.
+func (s *TaskListService) SetTaskParent(ctx context.Context, taskID, parentID string) error {
+	var before, after task.Task
+	err := s.inUnitOfWork(ctx, func(uow repository.UnitOfWork) error {
+		t, err := uow.Tasks().GetByID(ctx, taskID)
+		if err != nil {
+			return err
+		}
+		if err := checkParent(ctx, uow.Tasks(), taskID, parentID); err != nil {
+			return err
+		}
+		before = *t
+		t.ParentID = parentID
+		t.UpdatedAt = s.clock.Now()
+		t.UpdatedBy = ActorFromContext(ctx)
+		if err := uow.Tasks().Update(ctx, *t); err != nil {
+			return err
+		}
+		t.Version++
+		after = *t
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.publish(ctx, event.TaskUpdated{Meta: s.meta(ctx, after.UpdatedAt), Before: before, After: after})
+	return nil
+}
+
+// checkParent sobe pela cadeia de parentID e falha se ela passar por
+// taskID. Uma mãe inexistente encerra a cadeia, como nas subtarefas de
+// tarefas de outras listas importadas sem elas.
+func checkParent(ctx context.Context, tasks repository.TaskRepository, taskID, parentID string) error {
+	seen := make(map[string]bool)
+	for id := parentID; id != "" && !seen[id]; {
+		if id == taskID {
+			return fmt.Errorf("%w: %s would be its own ancestor", ErrInvalidParent, taskID)
+		}
+		seen[id] = true
+		parent, err := tasks.GetByID(ctx, id)
+		if errors.Is(err, repository.ErrTaskNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		id = parent.ParentID
+	}
+	return nil
+}
+
+// orphanChildren limpa o ParentID das subtarefas das tarefas excluídas, que
+// viram tarefas de primeiro nível, e retorna os eventos das alterações.
+func (s *TaskListService) orphanChildren(ctx context.Context, uow repository.UnitOfWork, deleted map[string]bool, now time.Time) ([]event.TaskUpdated, error) {
+	all, err := uow.Tasks().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var updates []event.TaskUpdated
+	for _, t := range all {
+		if t.ParentID == "" || !deleted[t.ParentID] || deleted[t.ID] {
+			continue
+		}
+		before := t
+		t.ParentID = ""
+		t.UpdatedAt = now
+		t.UpdatedBy = ActorFromContext(ctx)
+		if err := uow.Tasks().Update(ctx, t); err != nil {
+			return nil, err
+		}
+		t.Version++
+		updates = append(updates, event.TaskUpdated{Before: before, After: t})
+	}
+	return updates, nil
+}
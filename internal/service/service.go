@@ -1,93 +1,655 @@
 package service
 
 import (
+	"botasks/internal/backup"
+	"botasks/internal/clock"
+	"botasks/internal/event"
+	"botasks/internal/idgen"
 	"botasks/internal/list"
 	"botasks/internal/repository"
 	"botasks/internal/task"
+	"context"
 	"errors"
+	"fmt"
+	"sync"
 	"time"
 )
 
+// ErrInvalidTask é retornado quando os dados de uma nova tarefa são
+// inválidos, como um título vazio ou um prazo no passado.
+var ErrInvalidTask = errors.New("invalid task parameters")
 
 // TaskListService fornece a lógica de negócios para listas de tarefas e tarefas.
 type TaskListService struct {
 	taskListRepo repository.TaskListRepository
 	taskRepo     repository.TaskRepository
+	clock        clock.Clock
+	ids          idgen.Generator
+	uow          repository.UnitOfWorkFactory
+	events       event.Publisher
+}
+
+// Option configura um TaskListService.
+type Option func(*TaskListService)
+
+// WithClock define o relógio usado pelo serviço. O padrão é o relógio do sistema.
+func WithClock(c clock.Clock) Option {
+	return func(s *TaskListService) {
+		s.clock = c
+	}
+}
+
+// WithIDGenerator define o gerador de IDs para novas tarefas e listas. O padrão é xid.
+func WithIDGenerator(g idgen.Generator) Option {
+	return func(s *TaskListService) {
+		s.ids = g
+	}
+}
+
+// WithUnitOfWork define como o serviço inicia unidades de trabalho para as
+// operações com mais de um passo. O padrão compensa as operações sobre os
+// repositórios do serviço (repository.JournalUnitOfWorkFactory).
+func WithUnitOfWork(f repository.UnitOfWorkFactory) Option {
+	return func(s *TaskListService) {
+		s.uow = f
+	}
+}
+
+// WithEventPublisher define onde o serviço publica os eventos de domínio
+// (event.TaskCreated, event.ListRenamed, ...). Sem publicador, nenhum evento
+// é emitido.
+func WithEventPublisher(p event.Publisher) Option {
+	return func(s *TaskListService) {
+		s.events = p
+	}
 }
 
 // NewTaskListService cria uma nova instância de TaskListService.
-func NewTaskListService(taskListRepo repository.TaskListRepository, taskRepo repository.TaskRepository) *TaskListService {
-	return &TaskListService{
+func NewTaskListService(taskListRepo repository.TaskListRepository, taskRepo repository.TaskRepository, opts ...Option) *TaskListService {
+	s := &TaskListService{
 		taskListRepo: taskListRepo,
 		taskRepo:     taskRepo,
+		clock:        clock.New(),
+		ids:          idgen.Default(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.uow == nil {
+		s.uow = repository.NewJournalUnitOfWorkFactory(taskRepo, taskListRepo)
+	}
+	return s
+}
+
+type unitOfWorkKey struct{}
+
+// activeUnitOfWork é a unidade de trabalho aberta por WithinUnitOfWork, com
+// os eventos que só serão publicados após a confirmação.
+type activeUnitOfWork struct {
+	svc    *TaskListService
+	uow    repository.UnitOfWork
+	events []pendingEvent
+	mu     sync.Mutex
+}
+
+type pendingEvent struct {
+	ctx   context.Context
+	event event.Event
+}
+
+func (s *TaskListService) active(ctx context.Context) *activeUnitOfWork {
+	a, _ := ctx.Value(unitOfWorkKey{}).(*activeUnitOfWork)
+	if a == nil || a.svc != s {
+		return nil
+	}
+	return a
+}
+
+// tasks retorna o repositório de tarefas da unidade de trabalho do
+// contexto, se houver.
+func (s *TaskListService) tasks(ctx context.Context) repository.TaskRepository {
+	if a := s.active(ctx); a != nil {
+		return a.uow.Tasks()
+	}
+	return s.taskRepo
+}
+
+// taskLists retorna o repositório de listas da unidade de trabalho do
+// contexto, se houver.
+func (s *TaskListService) taskLists(ctx context.Context) repository.TaskListRepository {
+	if a := s.active(ctx); a != nil {
+		return a.uow.TaskLists()
+	}
+	return s.taskListRepo
+}
+
+// WithinUnitOfWork executa fn em uma unidade de trabalho. As operações do
+// serviço chamadas com o contexto recebido por fn são confirmadas juntas se
+// fn terminar sem erro e desfeitas juntas caso contrário; os seus eventos só
+// são publicados após a confirmação. Chamadas aninhadas usam a unidade de
+// trabalho externa.
+func (s *TaskListService) WithinUnitOfWork(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.active(ctx) != nil {
+		return fn(ctx)
+	}
+	a := &activeUnitOfWork{svc: s}
+	err := s.inUnitOfWork(ctx, func(uow repository.UnitOfWork) error {
+		a.uow = uow
+		return fn(context.WithValue(ctx, unitOfWorkKey{}, a))
+	})
+	if err != nil {
+		return err
+	}
+	for _, p := range a.events {
+		s.events.Publish(p.ctx, p.event)
+	}
+	return nil
+}
+
+// inUnitOfWork executa fn em uma unidade de trabalho, confirmando-a se fn
+// terminar sem erro e desfazendo-a caso contrário. Dentro de
+// WithinUnitOfWork, fn usa a unidade de trabalho já aberta.
+func (s *TaskListService) inUnitOfWork(ctx context.Context, fn func(uow repository.UnitOfWork) error) error {
+	if a := s.active(ctx); a != nil {
+		return fn(a.uow)
+	}
+	uow, err := s.uow.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	if err := fn(uow); err != nil {
+		if rbErr := uow.Rollback(); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+	return uow.Commit()
+}
+
+// publish envia o evento ao publicador configurado, se houver. Dentro de
+// WithinUnitOfWork, o evento espera a confirmação.
+func (s *TaskListService) publish(ctx context.Context, e event.Event) {
+	if s.events == nil {
+		return
+	}
+	if a := s.active(ctx); a != nil {
+		a.mu.Lock()
+		a.events = append(a.events, pendingEvent{ctx: ctx, event: e})
+		a.mu.Unlock()
+		return
+	}
+	s.events.Publish(ctx, e)
+}
+
+func (s *TaskListService) meta(ctx context.Context, at time.Time) event.Meta {
+	operationID := OperationFromContext(ctx)
+	if operationID == "" {
+		operationID = idgen.Default().NewID()
 	}
+	return event.Meta{At: at, Actor: ActorFromContext(ctx), OperationID: operationID}
 }
 
 // CreateTaskList cria uma nova lista de tarefas.
-func (s *TaskListService) CreateTaskList(name string) (string, error) {
-	taskList := list.CreateTaskList(name) // Use a função CreateTaskList do pacote list
-	taskListID, err := s.taskListRepo.Create(taskList)
+func (s *TaskListService) CreateTaskList(ctx context.Context, name string) (string, error) {
+	taskList := list.CreateTaskList(name, list.WithIDGenerator(s.ids)) // Use a função CreateTaskList do pacote list
+	now, actor := s.clock.Now(), ActorFromContext(ctx)
+	taskList.CreatedAt, taskList.UpdatedAt = now, now
+	taskList.CreatedBy, taskList.UpdatedBy = actor, actor
+	taskListID, err := s.taskLists(ctx).Create(ctx, taskList)
+	if err != nil {
+		return "", err
+	}
+	taskList.Version = 1
+	s.publish(ctx, event.ListCreated{Meta: s.meta(ctx, now), List: taskList})
+	return taskListID, nil
+}
+
+// ImportTaskList cria uma lista já montada, como as lidas da exportação de
+// outra ferramenta, preservando Name, Metadata e, se informado, CreatedAt.
+// As tarefas de taskList.Tasks são ignoradas; use ImportTask. Um ID novo é
+// gerado se taskList.ID estiver vazio.
+func (s *TaskListService) ImportTaskList(ctx context.Context, taskList list.TaskList) (string, error) {
+	if taskList.ID == "" {
+		taskList.ID = s.ids.NewID()
+	}
+	now, actor := s.clock.Now(), ActorFromContext(ctx)
+	if taskList.CreatedAt.IsZero() {
+		taskList.CreatedAt = now
+	}
+	taskList.UpdatedAt = now
+	taskList.CreatedBy, taskList.UpdatedBy = actor, actor
+	taskList.Tasks = nil
+	taskListID, err := s.taskLists(ctx).Create(ctx, taskList)
 	if err != nil {
 		return "", err
 	}
+	taskList.Version = 1
+	s.publish(ctx, event.ListCreated{Meta: s.meta(ctx, now), List: taskList})
 	return taskListID, nil
 }
 
 // UpdateTaskList atualiza uma lista de tarefas existente.
-func (s *TaskListService) UpdateTaskList(taskListID, newName string) error {
-	taskList, err := s.taskListRepo.GetByID(taskListID)
+func (s *TaskListService) UpdateTaskList(ctx context.Context, taskListID, newName string) error {
+	return s.UpdateTaskListIfMatch(ctx, taskListID, 0, newName)
+}
+
+// UpdateTaskListIfMatch atualiza a lista apenas se ela ainda estiver na versão
+// informada, retornando repository.ErrConflict caso contrário. A versão 0
+// desativa a verificação.
+func (s *TaskListService) UpdateTaskListIfMatch(ctx context.Context, taskListID string, version int, newName string) error {
+	taskList, err := s.taskLists(ctx).GetByID(ctx, taskListID)
 	if err != nil {
 		return err
 	}
+	if version != 0 && taskList.Version != version {
+		return repository.ErrConflict
+	}
+	before := *taskList
 	taskList.UpdateTaskList(newName) // Use o método UpdateTaskList do pacote list
-	return s.taskListRepo.Update(*taskList)
+	taskList.UpdatedAt = s.clock.Now()
+	taskList.UpdatedBy = ActorFromContext(ctx)
+	if err := s.taskLists(ctx).Update(ctx, *taskList); err != nil {
+		return err
+	}
+	taskList.Version++
+	s.publish(ctx, event.ListRenamed{Meta: s.meta(ctx, taskList.UpdatedAt), Before: before, After: *taskList})
+	return nil
+}
+
+// DeleteTaskList exclui uma lista de tarefas pelo ID. As tarefas da lista
+// não são excluídas.
+func (s *TaskListService) DeleteTaskList(ctx context.Context, taskListID string) error {
+	// A lista é lida antes da exclusão para que o evento a descreva
+	var taskList *list.TaskList
+	err := s.inUnitOfWork(ctx, func(uow repository.UnitOfWork) error {
+		var err error
+		taskList, err = uow.TaskLists().GetByID(ctx, taskListID)
+		if err != nil {
+			return err
+		}
+		return uow.TaskLists().Delete(ctx, taskListID)
+	})
+	if err != nil {
+		return err
+	}
+	s.publish(ctx, event.ListDeleted{Meta: s.meta(ctx, s.clock.Now()), List: *taskList})
+	return nil
 }
 
-// DeleteTaskList exclui uma lista de tarefas pelo ID.
-func (s *TaskListService) DeleteTaskList(taskListID string) error {
-	return s.taskListRepo.Delete(taskListID)
+// DeleteTaskListAndTasks exclui a lista e todas as tarefas associadas a ela
+// de forma atômica. Os eventos publicados compartilham o mesmo OperationID.
+func (s *TaskListService) DeleteTaskListAndTasks(ctx context.Context, taskListID string) error {
+	if OperationFromContext(ctx) == "" {
+		ctx = ContextWithOperation(ctx, idgen.Default().NewID())
+	}
+
+	var taskList *list.TaskList
+	var orphaned []event.TaskUpdated
+	now := s.clock.Now()
+	err := s.inUnitOfWork(ctx, func(uow repository.UnitOfWork) error {
+		var err error
+		taskList, err = uow.TaskLists().GetByID(ctx, taskListID)
+		if err != nil {
+			return err
+		}
+		deleted := make(map[string]bool, len(taskList.Tasks))
+		for _, t := range taskList.Tasks {
+			deleted[t.ID] = true
+		}
+		if orphaned, err = s.orphanChildren(ctx, uow, deleted, now); err != nil {
+			return err
+		}
+		for _, t := range taskList.Tasks {
+			if err := uow.Tasks().Delete(ctx, t.ID); err != nil {
+				return err
+			}
+		}
+		return uow.TaskLists().Delete(ctx, taskListID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.publishOrphaned(ctx, s.meta(ctx, now), orphaned)
+	for _, t := range taskList.Tasks {
+		s.publish(ctx, event.TaskDeleted{Meta: s.meta(ctx, now), Task: t.Task})
+	}
+	s.publish(ctx, event.ListDeleted{Meta: s.meta(ctx, now), List: *taskList})
+	return nil
 }
 
-// AddTask cria uma nova tarefa e a adiciona a uma lista de tarefas.
-func (s *TaskListService) AddTask(taskListID, title, description string, deadline time.Time) (string, error) {
-	newTask := task.NewTask(title, description, deadline)
+// AddTask cria uma nova tarefa e a adiciona a uma lista de tarefas. Se a
+// tarefa não puder ser adicionada à lista, ela também não é criada. As
+// opções definem campos extras, como task.WithTags; o relógio e o gerador
+// de IDs são sempre os do serviço.
+func (s *TaskListService) AddTask(ctx context.Context, taskListID, title, description string, deadline time.Time, opts ...task.Option) (string, error) {
+	opts = append(opts[:len(opts):len(opts)], task.WithClock(s.clock), task.WithIDGenerator(s.ids))
+	if title == "" {
+		return "", fmt.Errorf("%w: empty title", ErrInvalidTask)
+	}
+	newTask := task.NewTask(title, description, deadline, opts...)
 	if newTask == nil {
-		return "", errors.New("invalid task parameters")
+		return "", fmt.Errorf("%w: deadline %s is in the past", ErrInvalidTask, deadline.Format("2006-01-02 15:04"))
 	}
-	taskID, err := s.taskRepo.Create(*newTask)
+	now, actor := s.clock.Now(), ActorFromContext(ctx)
+	newTask.CreatedAt, newTask.UpdatedAt = now, now
+	newTask.CreatedBy, newTask.UpdatedBy = actor, actor
+	var taskID string
+	err := s.inUnitOfWork(ctx, func(uow repository.UnitOfWork) error {
+		var err error
+		taskID, err = uow.Tasks().Create(ctx, *newTask)
+		if err != nil {
+			return err
+		}
+		return uow.TaskLists().AddTaskToList(ctx, taskID, taskListID)
+	})
 	if err != nil {
 		return "", err
 	}
-	err = s.taskListRepo.AddTaskToList(taskID, taskListID)
+	newTask.Version = 1
+	s.publish(ctx, event.TaskCreated{Meta: s.meta(ctx, now), Task: *newTask, ListID: taskListID})
+	return taskID, nil
+}
+
+// ImportTask cria uma tarefa já montada, como as lidas de um arquivo
+// exportado por outra ferramenta, e a associa às listas informadas. Ao
+// contrário de AddTask, aceita prazos no passado e tarefas concluídas. Um
+// ID novo é gerado se t.ID estiver vazio e CreatedAt é preservado se
+// informado; os demais metadados são preenchidos pelo serviço.
+func (s *TaskListService) ImportTask(ctx context.Context, t task.Task, taskListIDs ...string) (string, error) {
+	if t.Title == "" {
+		return "", fmt.Errorf("%w: empty title", ErrInvalidTask)
+	}
+	if t.ID == "" {
+		t.ID = s.ids.NewID()
+	}
+	now, actor := s.clock.Now(), ActorFromContext(ctx)
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = now
+	}
+	t.UpdatedAt = now
+	t.CreatedBy, t.UpdatedBy = actor, actor
+	err := s.inUnitOfWork(ctx, func(uow repository.UnitOfWork) error {
+		if err := checkParent(ctx, uow.Tasks(), t.ID, t.ParentID); err != nil {
+			return err
+		}
+		if _, err := uow.Tasks().Create(ctx, t); err != nil {
+			return err
+		}
+		for _, taskListID := range taskListIDs {
+			if err := uow.TaskLists().AddTaskToList(ctx, t.ID, taskListID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return "", err
 	}
-	return taskID, nil
+	t.Version = 1
+
+	meta := s.meta(ctx, now)
+	var listID string
+	var others []string
+	if len(taskListIDs) > 0 {
+		listID, others = taskListIDs[0], taskListIDs[1:]
+	}
+	s.publish(ctx, event.TaskCreated{Meta: meta, Task: t, ListID: listID})
+	for _, taskListID := range others {
+		s.publish(ctx, event.TaskAddedToList{Meta: meta, TaskID: t.ID, ListID: taskListID})
+	}
+	return t.ID, nil
+}
+
+// AddTaskToList associa uma tarefa existente a uma lista.
+func (s *TaskListService) AddTaskToList(ctx context.Context, taskID, taskListID string) error {
+	if _, err := s.tasks(ctx).GetByID(ctx, taskID); err != nil {
+		return err
+	}
+	if err := s.taskLists(ctx).AddTaskToList(ctx, taskID, taskListID); err != nil {
+		return err
+	}
+	s.publish(ctx, event.TaskAddedToList{Meta: s.meta(ctx, s.clock.Now()), TaskID: taskID, ListID: taskListID})
+	return nil
+}
+
+// RemoveTaskFromList desassocia uma tarefa de uma lista sem excluí-la.
+func (s *TaskListService) RemoveTaskFromList(ctx context.Context, taskID, taskListID string) error {
+	if err := s.taskLists(ctx).RemoveTaskFromList(ctx, taskID, taskListID); err != nil {
+		return err
+	}
+	s.publish(ctx, event.TaskRemovedFromList{Meta: s.meta(ctx, s.clock.Now()), TaskID: taskID, ListID: taskListID})
+	return nil
 }
 
 // UpdateTask atualiza uma tarefa existente.
-func (s *TaskListService) UpdateTask(taskID, title, description string, deadline time.Time) error {
-	task, err := s.taskRepo.GetByID(taskID)
+func (s *TaskListService) UpdateTask(ctx context.Context, taskID, title, description string, deadline time.Time) error {
+	return s.UpdateTaskIfMatch(ctx, taskID, 0, title, description, deadline)
+}
+
+// UpdateTaskIfMatch atualiza a tarefa apenas se ela ainda estiver na versão
+// informada, retornando repository.ErrConflict caso contrário. A versão 0
+// desativa a verificação.
+func (s *TaskListService) UpdateTaskIfMatch(ctx context.Context, taskID string, version int, title, description string, deadline time.Time) error {
+	task, err := s.tasks(ctx).GetByID(ctx, taskID)
 	if err != nil {
 		return err
 	}
+	if version != 0 && task.Version != version {
+		return repository.ErrConflict
+	}
+	before := *task
 	task.UpdateTask(title, description, deadline) // Use o método UpdateTask do pacote task
-	return s.taskRepo.Update(*task)
+	task.UpdatedAt = s.clock.Now()
+	task.UpdatedBy = ActorFromContext(ctx)
+	if err := s.tasks(ctx).Update(ctx, *task); err != nil {
+		return err
+	}
+	task.Version++
+	s.publish(ctx, event.TaskUpdated{Meta: s.meta(ctx, task.UpdatedAt), Before: before, After: *task})
+	return nil
+}
+
+// CompleteTask marca uma tarefa como concluída.
+func (s *TaskListService) CompleteTask(ctx context.Context, taskID string) error {
+	task, err := s.tasks(ctx).GetByID(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	before := *task
+	now := s.clock.Now()
+	task.Complete(now)
+	task.UpdatedAt = now
+	task.UpdatedBy = ActorFromContext(ctx)
+	if err := s.tasks(ctx).Update(ctx, *task); err != nil {
+		return err
+	}
+	task.Version++
+	s.publish(ctx, event.TaskCompleted{Meta: s.meta(ctx, now), Before: before, After: *task})
+	return nil
+}
+
+// DeleteTask exclui uma tarefa pelo ID. As suas subtarefas viram tarefas
+// de primeiro nível.
+func (s *TaskListService) DeleteTask(ctx context.Context, taskID string) error {
+	_, err := s.deleteTask(ctx, taskID, -1)
+	return err
+}
+
+// DeleteTaskIfMatch é como DeleteTask, mas só exclui se a tarefa estiver na
+// versão informada, retornando repository.ErrConflict caso contrário.
+// Retorna as subtarefas como ficaram depois de perderem a mãe. Usado para
+// desfazer e refazer operações sem apagar as alterações feitas por outros
+// no meio tempo.
+func (s *TaskListService) DeleteTaskIfMatch(ctx context.Context, taskID string, version int) ([]task.Task, error) {
+	return s.deleteTask(ctx, taskID, version)
+}
+
+// deleteTask implementa DeleteTask e DeleteTaskIfMatch; a versão -1
+// desativa a verificação.
+func (s *TaskListService) deleteTask(ctx context.Context, taskID string, version int) ([]task.Task, error) {
+	// A tarefa é lida antes da exclusão para que o evento a descreva
+	var deleted *task.Task
+	var orphaned []event.TaskUpdated
+	now := s.clock.Now()
+	err := s.inUnitOfWork(ctx, func(uow repository.UnitOfWork) error {
+		var err error
+		deleted, err = uow.Tasks().GetByID(ctx, taskID)
+		if err != nil {
+			return err
+		}
+		if version >= 0 && deleted.Version != version {
+			return repository.ErrConflict
+		}
+		if orphaned, err = s.orphanChildren(ctx, uow, map[string]bool{taskID: true}, now); err != nil {
+			return err
+		}
+		return uow.Tasks().Delete(ctx, taskID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	meta := s.meta(ctx, now)
+	s.publishOrphaned(ctx, meta, orphaned)
+	s.publish(ctx, event.TaskDeleted{Meta: meta, Task: *deleted})
+
+	children := make([]task.Task, len(orphaned))
+	for i, e := range orphaned {
+		children[i] = e.After
+	}
+	return children, nil
 }
 
-// DeleteTask exclui uma tarefa pelo ID.
-func (s *TaskListService) DeleteTask(taskID string) error {
-	return s.taskRepo.Delete(taskID)
+func (s *TaskListService) publishOrphaned(ctx context.Context, meta event.Meta, orphaned []event.TaskUpdated) {
+	for _, e := range orphaned {
+		e.Meta = meta
+		s.publish(ctx, e)
+	}
+}
+
+// RemoveAndDeleteTask desassocia a tarefa da lista e a exclui de forma
+// atômica, como DeleteTask. Os eventos compartilham o mesmo OperationID,
+// formando uma única operação para o undo.
+func (s *TaskListService) RemoveAndDeleteTask(ctx context.Context, taskID, taskListID string) error {
+	var deleted *task.Task
+	var orphaned []event.TaskUpdated
+	now := s.clock.Now()
+	err := s.inUnitOfWork(ctx, func(uow repository.UnitOfWork) error {
+		var err error
+		deleted, err = uow.Tasks().GetByID(ctx, taskID)
+		if err != nil {
+			return err
+		}
+		if err := uow.TaskLists().RemoveTaskFromList(ctx, taskID, taskListID); err != nil {
+			return err
+		}
+		if orphaned, err = s.orphanChildren(ctx, uow, map[string]bool{taskID: true}, now); err != nil {
+			return err
+		}
+		return uow.Tasks().Delete(ctx, taskID)
+	})
+	if err != nil {
+		return err
+	}
+	meta := s.meta(ctx, now)
+	s.publish(ctx, event.TaskRemovedFromList{Meta: meta, TaskID: taskID, ListID: taskListID})
+	s.publishOrphaned(ctx, meta, orphaned)
+	s.publish(ctx, event.TaskDeleted{Meta: meta, Task: *deleted})
+	return nil
+}
+
+// RestoreTask grava a tarefa exatamente como informada, preservando ID e
+// metadados. Se a tarefa existir, ela é sobrescrita; caso contrário, é
+// recriada. Usado em restaurações.
+func (s *TaskListService) RestoreTask(ctx context.Context, t task.Task) error {
+	return s.restoreTask(ctx, t, -1)
+}
+
+// RestoreTaskIfMatch é como RestoreTask, mas só grava se a tarefa estiver na
+// versão informada, retornando repository.ErrConflict caso contrário. A
+// versão 0 exige que a tarefa não exista. A tarefa gravada fica na versão
+// seguinte (1, se recriada). Usado para desfazer e refazer operações sem
+// apagar as alterações feitas por outros no meio tempo.
+func (s *TaskListService) RestoreTaskIfMatch(ctx context.Context, t task.Task, version int) error {
+	return s.restoreTask(ctx, t, version)
+}
+
+// restoreTask implementa RestoreTask e RestoreTaskIfMatch; a versão -1
+// desativa a verificação.
+func (s *TaskListService) restoreTask(ctx context.Context, t task.Task, version int) error {
+	current, err := s.tasks(ctx).GetByID(ctx, t.ID)
+	if errors.Is(err, repository.ErrTaskNotFound) {
+		if version > 0 {
+			return repository.ErrConflict
+		}
+		if _, err := s.tasks(ctx).Create(ctx, t); err != nil {
+			return err
+		}
+		t.Version = 1
+		s.publish(ctx, event.TaskCreated{Meta: s.meta(ctx, s.clock.Now()), Task: t})
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if version >= 0 && current.Version != version {
+		return repository.ErrConflict
+	}
+
+	t.Version = current.Version
+	if err := s.tasks(ctx).Update(ctx, t); err != nil {
+		return err
+	}
+	t.Version++
+	s.publish(ctx, event.TaskUpdated{Meta: s.meta(ctx, s.clock.Now()), Before: *current, After: t})
+	return nil
+}
+
+// RestoreTaskList grava a lista exatamente como informada, preservando ID e
+// metadados. Uma lista recriada volta com as tarefas de taskList.Tasks
+// associadas; em uma lista existente só os dados da lista são sobrescritos.
+func (s *TaskListService) RestoreTaskList(ctx context.Context, taskList list.TaskList) error {
+	current, err := s.taskLists(ctx).GetByID(ctx, taskList.ID)
+	if errors.Is(err, repository.ErrTaskListNotFound) {
+		if _, err := s.taskLists(ctx).Create(ctx, taskList); err != nil {
+			return err
+		}
+		taskList.Version = 1
+		s.publish(ctx, event.ListCreated{Meta: s.meta(ctx, s.clock.Now()), List: taskList})
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	taskList.Version = current.Version
+	taskList.Tasks = current.Tasks
+	if err := s.taskLists(ctx).Update(ctx, taskList); err != nil {
+		return err
+	}
+	taskList.Version++
+	s.publish(ctx, event.ListRenamed{Meta: s.meta(ctx, s.clock.Now()), Before: *current, After: taskList})
+	return nil
+}
+
+// GetTask recupera uma tarefa pelo ID.
+func (s *TaskListService) GetTask(ctx context.Context, taskID string) (*task.Task, error) {
+	return s.tasks(ctx).GetByID(ctx, taskID)
 }
 
 // GetTaskList recupera uma lista de tarefas pelo ID.
-func (s *TaskListService) GetTaskList(taskListID string) (*list.TaskList, error) {
-	return s.taskListRepo.GetByID(taskListID)
+func (s *TaskListService) GetTaskList(ctx context.Context, taskListID string) (*list.TaskList, error) {
+	return s.taskLists(ctx).GetByID(ctx, taskListID)
+}
+
+// ListTaskLists recupera todas as listas, ordenadas pelo ID.
+func (s *TaskListService) ListTaskLists(ctx context.Context) ([]list.TaskList, error) {
+	return s.taskLists(ctx).List(ctx)
 }
 
 // GetTasksByTaskList recupera todas as tarefas associadas a uma lista de tarefas.
-func (s *TaskListService) GetTasksByTaskList(taskListID string) ([]task.Task, error) {
-	listTasks, err := s.taskListRepo.GetTasksByList(taskListID)
+func (s *TaskListService) GetTasksByTaskList(ctx context.Context, taskListID string) ([]task.Task, error) {
+	listTasks, err := s.taskLists(ctx).GetTasksByList(ctx, taskListID)
 	if err != nil {
 		return nil, err
 	}
@@ -101,4 +663,22 @@ func (s *TaskListService) GetTasksByTaskList(taskListID string) ([]task.Task, er
 	return tasks, nil
 }
 
+// QueryTasks recupera as tarefas de uma lista filtradas e ordenadas conforme a consulta.
+func (s *TaskListService) QueryTasks(ctx context.Context, taskListID string, q task.Query) ([]task.Task, error) {
+	tasks, err := s.GetTasksByTaskList(ctx, taskListID)
+	if err != nil {
+		return nil, err
+	}
+	return q.Apply(tasks), nil
+}
+
+// Export gera um backup de todas as tarefas e listas (veja backup.Export).
+func (s *TaskListService) Export(ctx context.Context) (*backup.Archive, error) {
+	return backup.Export(ctx, s.taskRepo, s.taskListRepo, s.clock.Now())
+}
 
+// Restore grava o backup em uma única unidade de trabalho. Nenhum evento é
+// publicado: a restauração não aparece no histórico nem pode ser desfeita.
+func (s *TaskListService) Restore(ctx context.Context, a *backup.Archive, mode backup.Mode) error {
+	return backup.Restore(ctx, s.uow, a, mode)
+}
@@ -0,0 +1,56 @@
+package slack
+
+import "strings"
+
+// Tipos do Block Kit usados nas respostas. Só os campos necessários estão
+// aqui; veja https://api.slack.com/block-kit.
+
+// Message é o corpo de uma resposta a comando ou de um POST em
+// response_url.
+type Message struct {
+	ResponseType    string  `json:"response_type,omitempty"` // "ephemeral" ou "in_channel"
+	ReplaceOriginal bool    `json:"replace_original,omitempty"`
+	Text            string  `json:"text"` // usado em notificações e clientes sem blocos
+	Blocks          []Block `json:"blocks,omitempty"`
+}
+
+type Block struct {
+	Type      string    `json:"type"` // "section", "context", "divider"
+	Text      *Text     `json:"text,omitempty"`
+	Accessory *Element  `json:"accessory,omitempty"`
+	Elements  []Element `json:"elements,omitempty"`
+}
+
+type Text struct {
+	Type string `json:"type"` // "mrkdwn" ou "plain_text"
+	Text string `json:"text"`
+}
+
+// Element é um elemento de bloco: botão ou texto de um bloco context.
+type Element struct {
+	Type     string `json:"type"`
+	Text     any    `json:"text,omitempty"` // *Text em botões, string em textos
+	ActionID string `json:"action_id,omitempty"`
+	Value    string `json:"value,omitempty"`
+	Style    string `json:"style,omitempty"`
+}
+
+func section(mrkdwn string) Block {
+	return Block{Type: "section", Text: &Text{Type: "mrkdwn", Text: mrkdwn}}
+}
+
+func contextBlock(mrkdwn string) Block {
+	return Block{Type: "context", Elements: []Element{{Type: "mrkdwn", Text: mrkdwn}}}
+}
+
+func button(label, actionID, value string) *Element {
+	return &Element{
+		Type:     "button",
+		Text:     &Text{Type: "plain_text", Text: label},
+		ActionID: actionID,
+		Value:    value,
+	}
+}
+
+// escape protege os caracteres de controle do mrkdwn.
+var escape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
@@ -0,0 +1,399 @@
+// Package slack atende o comando de barra /tasks e os botões das respostas
+// no formato do Slack, executando-os no TaskListService e respondendo com
+// Block Kit:
+//
+//	/tasks add <title>  adiciona uma tarefa à lista do canal
+//	/tasks mine         mostra as tarefas pendentes criadas pelo usuário
+//
+// O mesmo Handler serve como Request URL do comando e das interações. Toda
+// requisição precisa estar assinada com o Signing Secret do app.
+package slack
+
+import (
+	"botasks/internal/bot"
+	"botasks/internal/clock"
+	"botasks/internal/list"
+	"botasks/internal/quickadd"
+	"botasks/internal/service"
+	"botasks/internal/task"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Slack-Signature"
+	TimestampHeader = "X-Slack-Request-Timestamp"
+	// MaxClockSkew é a idade máxima aceita de uma requisição assinada, o que
+	// impede que requisições capturadas sejam repetidas depois.
+	MaxClockSkew = 5 * time.Minute
+
+	completeAction = "complete"
+	// maxTasks mantém a resposta de /tasks mine abaixo do limite de 50
+	// blocos por mensagem.
+	maxTasks    = 45
+	maxBodySize = 1 << 20
+	usage       = "Usage: `/tasks add <title>` or `/tasks mine`"
+)
+
+var ErrInvalidSignature = errors.New("slack: invalid request signature")
+
+// Sign calcula a assinatura de uma requisição ("v0=<hex>").
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:", timestamp)
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature confere a assinatura e a idade da requisição.
+func VerifySignature(secret string, h http.Header, body []byte, now time.Time) error {
+	ts := h.Get(TimestampHeader)
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(sec, 0)); age > MaxClockSkew || age < -MaxClockSkew {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(h.Get(SignatureHeader)), []byte(Sign(secret, ts, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Service é a parte do service.TaskListService usada pelo Handler.
+type Service interface {
+	CreateTaskList(ctx context.Context, name string) (string, error)
+	GetTaskList(ctx context.Context, taskListID string) (*list.TaskList, error)
+	ListTaskLists(ctx context.Context) ([]list.TaskList, error)
+	AddTask(ctx context.Context, taskListID, title, description string, deadline time.Time, opts ...task.Option) (string, error)
+	GetTask(ctx context.Context, taskID string) (*task.Task, error)
+	CompleteTask(ctx context.Context, taskID string) error
+}
+
+// Handler atende as requisições do Slack. Cada canal tem a sua lista, criada
+// no primeiro /tasks add; o autor das operações é
+// "slack:<team_id>:<user_id>".
+type Handler struct {
+	svc    Service
+	secret string
+	states bot.StateStore
+	clock  clock.Clock
+	client *http.Client
+
+	mu      sync.Mutex     // evita que dois /tasks add criem duas listas no canal
+	pending sync.WaitGroup // interações ainda sendo atendidas
+}
+
+// Option configura um Handler.
+type Option func(*Handler)
+
+// WithStateStore define onde fica a lista de cada canal. O padrão é a
+// memória.
+func WithStateStore(store bot.StateStore) Option {
+	return func(h *Handler) {
+		h.states = store
+	}
+}
+
+// WithClock define o relógio usado nos prazos e na verificação das
+// assinaturas.
+func WithClock(c clock.Clock) Option {
+	return func(h *Handler) {
+		h.clock = c
+	}
+}
+
+// WithHTTPClient define o cliente usado para responder em response_url.
+func WithHTTPClient(c *http.Client) Option {
+	return func(h *Handler) {
+		h.client = c
+	}
+}
+
+// NewHandler cria um Handler que aceita requisições assinadas com
+// signingSecret.
+func NewHandler(svc Service, signingSecret string, opts ...Option) *Handler {
+	h := &Handler{
+		svc:    svc,
+		secret: signingSecret,
+		states: bot.NewMemoryStateStore(),
+		clock:  clock.New(),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	if err := VerifySignature(h.secret, r.Header, body, h.clock.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+
+	if payload := form.Get("payload"); payload != "" {
+		h.interact(r.Context(), w, payload)
+		return
+	}
+	writeMessage(w, h.command(r.Context(), form))
+}
+
+// command executa um comando de barra. Erros viram respostas visíveis só
+// para quem digitou o comando.
+func (h *Handler) command(ctx context.Context, form url.Values) Message {
+	team, user := form.Get("team_id"), form.Get("user_id")
+	ctx = service.ContextWithActor(ctx, actor(team, user))
+
+	sub, arg, _ := strings.Cut(strings.TrimSpace(form.Get("text")), " ")
+	arg = strings.TrimSpace(arg)
+	var msg Message
+	var err error
+	switch strings.ToLower(sub) {
+	case "add":
+		msg, err = h.add(ctx, form, arg)
+	case "mine":
+		msg, err = h.mine(ctx, team, user)
+	case "", "help":
+		msg = ephemeral(usage)
+	default:
+		msg = ephemeral(fmt.Sprintf("Unknown subcommand %q. %s", sub, usage))
+	}
+	if err != nil {
+		return ephemeral("Error: " + err.Error())
+	}
+	return msg
+}
+
+func (h *Handler) add(ctx context.Context, form url.Values, arg string) (Message, error) {
+	now := h.clock.Now()
+	q := quickadd.Parse(arg, now)
+	if q.Title == "" {
+		return ephemeral(usage), nil
+	}
+	taskList, err := h.channelList(ctx, form)
+	if err != nil {
+		return Message{}, err
+	}
+	deadline := q.DeadlineOr(now.Add(24 * time.Hour))
+	if _, err := h.svc.AddTask(ctx, taskList.ID, q.Title, "", deadline, q.Options()...); err != nil {
+		return Message{}, err
+	}
+	text := fmt.Sprintf("<@%s> added *%s* to *%s*", form.Get("user_id"), escape(q.Title), escape(taskList.Name))
+	return Message{
+		ResponseType: "in_channel",
+		Text:         text,
+		Blocks: []Block{
+			section(text),
+			contextBlock("Due " + formatDeadline(deadline)),
+		},
+	}, nil
+}
+
+// channelList retorna a lista do canal, criando-a se ainda não existir.
+func (h *Handler) channelList(ctx context.Context, form url.Values) (*list.TaskList, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := "slack:" + form.Get("team_id") + ":" + form.Get("channel_id")
+	st, err := h.states.Load(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if st.Current != "" {
+		taskList, err := h.svc.GetTaskList(ctx, st.Current)
+		if err == nil {
+			return taskList, nil
+		}
+	}
+	name := form.Get("channel_name")
+	if name == "" {
+		name = form.Get("channel_id")
+	}
+	taskListID, err := h.svc.CreateTaskList(ctx, "#"+name)
+	if err != nil {
+		return nil, err
+	}
+	st.Lists = append(st.Lists, taskListID)
+	st.Current = taskListID
+	if err := h.states.Save(ctx, key, st); err != nil {
+		return nil, err
+	}
+	return h.svc.GetTaskList(ctx, taskListID)
+}
+
+// mine mostra as tarefas pendentes criadas pelo usuário, em qualquer lista,
+// com um botão para concluir cada uma.
+func (h *Handler) mine(ctx context.Context, team, user string) (Message, error) {
+	taskLists, err := h.svc.ListTaskLists(ctx)
+	if err != nil {
+		return Message{}, err
+	}
+	var tasks []task.Task
+	seen := make(map[string]bool)
+	for _, taskList := range taskLists {
+		for _, t := range taskList.Tasks {
+			if !seen[t.ID] {
+				seen[t.ID] = true
+				tasks = append(tasks, t.Task)
+			}
+		}
+	}
+	pending := false
+	tasks = task.Query{CreatedBy: actor(team, user), Completed: &pending, SortBy: task.SortByDeadline}.Apply(tasks)
+
+	if len(tasks) == 0 {
+		return ephemeral("You have no open tasks."), nil
+	}
+	msg := ephemeral(fmt.Sprintf("Your open tasks (%d)", len(tasks)))
+	msg.Blocks = []Block{section("*" + msg.Text + "*")}
+	for i, t := range tasks {
+		if i == maxTasks {
+			msg.Blocks = append(msg.Blocks, contextBlock(fmt.Sprintf("and %d more", len(tasks)-maxTasks)))
+			break
+		}
+		b := section(fmt.Sprintf("*%s*\nDue %s", escape(t.Title), formatDeadline(t.Deadline)))
+		b.Accessory = button("Complete", completeAction, t.ID)
+		msg.Blocks = append(msg.Blocks, b)
+	}
+	return msg, nil
+}
+
+type interaction struct {
+	Type string `json:"type"`
+	Team struct {
+		ID string `json:"id"`
+	} `json:"team"`
+	User struct {
+		ID string `json:"id"`
+	} `json:"user"`
+	ResponseURL string `json:"response_url"`
+	Actions     []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+}
+
+// interact atende os botões. O Slack ignora o corpo da resposta HTTP das
+// interações e espera por ela só 3s, então a requisição é respondida na
+// hora e a ação roda em segundo plano, enviando o resultado para
+// response_url.
+func (h *Handler) interact(ctx context.Context, w http.ResponseWriter, payload string) {
+	var in interaction
+	if err := json.Unmarshal([]byte(payload), &in); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	if in.Type != "block_actions" || in.ResponseURL == "" {
+		return
+	}
+	// O contexto da requisição é cancelado assim que ela é respondida
+	ctx = service.ContextWithActor(context.WithoutCancel(ctx), actor(in.Team.ID, in.User.ID))
+	h.pending.Add(1)
+	go func() {
+		defer h.pending.Done()
+		h.act(ctx, in)
+	}()
+}
+
+// Wait espera as interações que ainda estão sendo atendidas.
+func (h *Handler) Wait() {
+	h.pending.Wait()
+}
+
+// act executa as ações da interação e envia o resultado para response_url.
+func (h *Handler) act(ctx context.Context, in interaction) {
+	for _, a := range in.Actions {
+		if a.ActionID != completeAction {
+			continue
+		}
+		if err := h.complete(ctx, actor(in.Team.ID, in.User.ID), a.Value); err != nil {
+			h.respond(ctx, in.ResponseURL, ephemeral("Error: "+err.Error()))
+			return
+		}
+	}
+	msg, err := h.mine(ctx, in.Team.ID, in.User.ID)
+	if err != nil {
+		msg = ephemeral("Error: " + err.Error())
+	}
+	msg.ReplaceOriginal = true
+	h.respond(ctx, in.ResponseURL, msg)
+}
+
+// complete conclui a tarefa, desde que ela tenha sido criada pelo usuário.
+func (h *Handler) complete(ctx context.Context, by, taskID string) error {
+	t, err := h.svc.GetTask(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	if t.CreatedBy != by {
+		return errors.New("you can only complete your own tasks")
+	}
+	return h.svc.CompleteTask(ctx, taskID)
+}
+
+// respond envia a mensagem para response_url. Não há a quem informar uma
+// falha aqui, então ela é descartada.
+func (h *Handler) respond(ctx context.Context, responseURL string, msg Message) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+}
+
+func writeMessage(w http.ResponseWriter, msg Message) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(msg)
+}
+
+func ephemeral(text string) Message {
+	return Message{ResponseType: "ephemeral", Text: text}
+}
+
+func actor(team, user string) string {
+	return "slack:" + team + ":" + user
+}
+
+func formatDeadline(d time.Time) string {
+	return d.Format("Mon Jan 2 15:04")
+}
@@ -0,0 +1,92 @@
+package task
+
+import (
+	"sort"
+	"time"
+)
+
+// SortField identifica o campo usado para ordenar tarefas.
+type SortField int
+
+const (
+	SortNone SortField = iota
+	SortByCreatedAt
+	SortByUpdatedAt
+	SortByCompletedAt
+	SortByDeadline
+)
+
+// Query descreve filtros e ordenação aplicados a um conjunto de tarefas.
+// Campos vazios não filtram.
+type Query struct {
+	CreatedBy     string
+	UpdatedBy     string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+	Completed     *bool
+	SortBy        SortField
+	Descending    bool
+}
+
+// Match informa se a tarefa satisfaz os filtros da consulta.
+func (q Query) Match(t Task) bool {
+	if q.CreatedBy != "" && t.CreatedBy != q.CreatedBy {
+		return false
+	}
+	if q.UpdatedBy != "" && t.UpdatedBy != q.UpdatedBy {
+		return false
+	}
+	if !q.CreatedAfter.IsZero() && !t.CreatedAt.After(q.CreatedAfter) {
+		return false
+	}
+	if !q.CreatedBefore.IsZero() && !t.CreatedAt.Before(q.CreatedBefore) {
+		return false
+	}
+	if !q.UpdatedAfter.IsZero() && !t.UpdatedAt.After(q.UpdatedAfter) {
+		return false
+	}
+	if !q.UpdatedBefore.IsZero() && !t.UpdatedAt.Before(q.UpdatedBefore) {
+		return false
+	}
+	if q.Completed != nil && t.IsCompleted() != *q.Completed {
+		return false
+	}
+	return true
+}
+
+// Apply retorna as tarefas que satisfazem a consulta, na ordem pedida.
+// O slice original não é modificado.
+func (q Query) Apply(tasks []Task) []Task {
+	result := make([]Task, 0, len(tasks))
+	for _, t := range tasks {
+		if q.Match(t) {
+			result = append(result, t)
+		}
+	}
+
+	if q.SortBy != SortNone {
+		key := sortKey(q.SortBy)
+		sort.SliceStable(result, func(i, j int) bool {
+			if q.Descending {
+				return key(result[j]).Before(key(result[i]))
+			}
+			return key(result[i]).Before(key(result[j]))
+		})
+	}
+	return result
+}
+
+func sortKey(field SortField) func(Task) time.Time {
+	switch field {
+	case SortByUpdatedAt:
+		return func(t Task) time.Time { return t.UpdatedAt }
+	case SortByCompletedAt:
+		return func(t Task) time.Time { return t.CompletedAt }
+	case SortByDeadline:
+		return func(t Task) time.Time { return t.Deadline }
+	default:
+		return func(t Task) time.Time { return t.CreatedAt }
+	}
+}
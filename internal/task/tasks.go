@@ -1,9 +1,9 @@
 package task
 
 import (
+	"botasks/internal/clock"
+	"botasks/internal/idgen"
 	"time"
-
-	"github.com/rs/xid"
 )
 
 type Task struct {
@@ -11,20 +11,85 @@ type Task struct {
 	Title       string
 	Description string
 	Deadline    time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	CompletedAt time.Time // zero enquanto a tarefa não for concluída
+	Priority    string    // "A" (mais alta) a "Z"; vazio se não houver
+	Tags        []string
+	Recurrence  string            // regra RRULE (RFC 5545), como "FREQ=MONTHLY;BYMONTHDAY=5"; vazio se não se repetir
+	ParentID    string            // tarefa da qual esta é uma subtarefa; vazio se não for
+	Metadata    map[string]string // dados de origem, como o ID em outra ferramenta
+	CreatedBy   string
+	UpdatedBy   string
+	Version     int // incrementada pelo repositório a cada Update
+}
+
+type options struct {
+	clock      clock.Clock
+	ids        idgen.Generator
+	priority   string
+	tags       []string
+	recurrence string
+}
+
+// Option configura a criação de uma tarefa.
+type Option func(*options)
+
+// WithIDGenerator define o gerador de IDs da tarefa.
+func WithIDGenerator(g idgen.Generator) Option {
+	return func(o *options) {
+		o.ids = g
+	}
 }
 
-func NewTask(title, description string, deadline time.Time) *Task {
+// WithClock define o relógio usado para validar o prazo da tarefa.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// WithPriority define a prioridade da tarefa ("A" a "Z").
+func WithPriority(priority string) Option {
+	return func(o *options) {
+		o.priority = priority
+	}
+}
+
+// WithTags define as tags da tarefa.
+func WithTags(tags ...string) Option {
+	return func(o *options) {
+		o.tags = tags
+	}
+}
+
+// WithRecurrence define a regra de repetição da tarefa (veja Task.Recurrence).
+func WithRecurrence(rule string) Option {
+	return func(o *options) {
+		o.recurrence = rule
+	}
+}
+
+func NewTask(title, description string, deadline time.Time, opts ...Option) *Task {
+	o := options{clock: clock.New(), ids: idgen.Default()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	if title == "" {
 		return nil
-	} else if deadline.Before(time.Now()) {
+	} else if deadline.Before(o.clock.Now()) {
 		return nil
 	}
 
 	return &Task{
-		ID:          xid.New().String(),
+		ID:          o.ids.NewID(),
 		Title:       title,
 		Description: description,
 		Deadline:    deadline,
+		Priority:    o.priority,
+		Tags:        o.tags,
+		Recurrence:  o.recurrence,
 	}
 }
 
@@ -38,6 +103,21 @@ func (t *Task) UpdateTask(title, description string, deadline time.Time) {
 	}
 }
 
+// Complete marca a tarefa como concluída no instante informado.
+func (t *Task) Complete(now time.Time) {
+	t.CompletedAt = now
+}
+
+// IsCompleted informa se a tarefa já foi concluída.
+func (t *Task) IsCompleted() bool {
+	return !t.CompletedAt.IsZero()
+}
+
+// IsOverdue informa se o prazo da tarefa já passou no instante informado.
+func (t *Task) IsOverdue(now time.Time) bool {
+	return !t.IsCompleted() && !t.Deadline.IsZero() && t.Deadline.Before(now)
+}
+
 /*
 func (t *Task) DeleteTask() {
 
@@ -0,0 +1,135 @@
+package taskcsv
+
+import (
+	"botasks/internal/list"
+	"botasks/internal/task"
+	"context"
+	"encoding/csv"
+	"io"
+	"strings"
+	"time"
+)
+
+// Service é a parte do service.TaskListService usada por Import e Export.
+type Service interface {
+	WithinUnitOfWork(ctx context.Context, fn func(ctx context.Context) error) error
+	ListTaskLists(ctx context.Context) ([]list.TaskList, error)
+	QueryTasks(ctx context.Context, taskListID string, q task.Query) ([]task.Task, error)
+	ImportTask(ctx context.Context, t task.Task, taskListIDs ...string) (string, error)
+}
+
+// Import lê a planilha e cria as tarefas das linhas válidas na lista
+// taskListID. As linhas inválidas ficam em Report.Errors e não impedem as
+// demais. Com opts.DryRun, nenhuma tarefa é criada. As tarefas são criadas
+// em uma única unidade de trabalho: se alguma criação falhar, nada é
+// criado e Report.Imported fica em 0.
+func Import(ctx context.Context, svc Service, r io.Reader, taskListID string, opts Options) (Report, error) {
+	rep, err := Parse(r, opts)
+	if err != nil || opts.DryRun {
+		return rep, err
+	}
+	err = svc.WithinUnitOfWork(ctx, func(ctx context.Context) error {
+		for _, t := range rep.Tasks {
+			if _, err := svc.ImportTask(ctx, t, taskListID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return rep, err
+	}
+	rep.Imported = len(rep.Tasks)
+	return rep, nil
+}
+
+// header são as colunas gravadas por Export, na ordem.
+var header = []string{"id", "title", "description", "deadline", "priority", "tags", "completed", "created_at", "updated_at"}
+
+// Export grava em CSV as tarefas da lista taskListID que atendem à
+// consulta. Com taskListID vazio, a consulta é feita sobre as tarefas de
+// todas as listas. As datas usam RFC 3339 e as tags são separadas por
+// vírgula, de modo que o arquivo pode ser lido de volta por Import. Células
+// que começam com =, +, - ou @ ganham um ' na frente, para que planilhas
+// não as interpretem como fórmulas; Import o remove.
+func Export(ctx context.Context, svc Service, w io.Writer, taskListID string, q task.Query) error {
+	tasks, err := queryTasks(ctx, svc, taskListID, q)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		record := []string{
+			t.ID,
+			t.Title,
+			t.Description,
+			formatTime(t.Deadline),
+			t.Priority,
+			strings.Join(t.Tags, ","),
+			formatTime(t.CompletedAt),
+			formatTime(t.CreatedAt),
+			formatTime(t.UpdatedAt),
+		}
+		for i, v := range record {
+			record[i] = escapeFormula(v)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func queryTasks(ctx context.Context, svc Service, taskListID string, q task.Query) ([]task.Task, error) {
+	if taskListID != "" {
+		return svc.QueryTasks(ctx, taskListID, q)
+	}
+	taskLists, err := svc.ListTaskLists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var tasks []task.Task
+	seen := make(map[string]bool)
+	for _, taskList := range taskLists {
+		for _, t := range taskList.Tasks {
+			if !seen[t.ID] {
+				seen[t.ID] = true
+				tasks = append(tasks, t.Task)
+			}
+		}
+	}
+	return q.Apply(tasks), nil
+}
+
+// escapeFormula protege as células que uma planilha executaria como
+// fórmula.
+func escapeFormula(v string) string {
+	if isFormula(v) {
+		return "'" + v
+	}
+	return v
+}
+
+// unescapeFormula desfaz escapeFormula.
+func unescapeFormula(v string) string {
+	if rest, ok := strings.CutPrefix(v, "'"); ok && isFormula(rest) {
+		return rest
+	}
+	return v
+}
+
+func isFormula(v string) bool {
+	return v != "" && strings.ContainsRune("=+-@", rune(v[0]))
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
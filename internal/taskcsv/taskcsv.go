@@ -0,0 +1,297 @@
+// Package taskcsv importa tarefas de planilhas CSV e exporta consultas de
+// tarefas para CSV.
+//
+// A importação usa um Mapping das colunas da planilha para os campos da
+// tarefa, detecta o formato das datas da coluna de prazo e informa os erros
+// linha a linha, importando as linhas válidas. Com DryRun, nada é gravado e
+// o Report serve de prévia.
+package taskcsv
+
+import (
+	"botasks/internal/task"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Field é um campo da tarefa que pode vir de uma coluna.
+type Field string
+
+const (
+	Title       Field = "title"
+	Description Field = "description"
+	Deadline    Field = "deadline"
+	Priority    Field = "priority"
+	Tags        Field = "tags"
+	Completed   Field = "completed"
+)
+
+// Mapping associa campos aos nomes das colunas no cabeçalho. A comparação
+// ignora maiúsculas e espaços nas pontas. Title é obrigatório; campos sem
+// coluna ficam vazios.
+type Mapping map[Field]string
+
+// DefaultMapping usa os nomes de coluna gravados por Export.
+func DefaultMapping() Mapping {
+	return Mapping{
+		Title:       "title",
+		Description: "description",
+		Deadline:    "deadline",
+		Priority:    "priority",
+		Tags:        "tags",
+		Completed:   "completed",
+	}
+}
+
+// DateFormats são os formatos tentados na detecção, em ordem. Datas como
+// 03/04/2024 são lidas como dia/mês, a não ser que algum valor da coluna só
+// faça sentido como mês/dia (por exemplo 04/25/2024).
+var DateFormats = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2/1/2006 15:04",
+	"2/1/2006",
+	"1/2/2006 15:04",
+	"1/2/2006",
+	"2.1.2006",
+}
+
+// Options configura Parse e Import.
+type Options struct {
+	Mapping    Mapping   // padrão: DefaultMapping
+	DateFormat string    // formato do prazo; vazio para detectar entre DateFormats
+	Comma      rune      // separador; padrão ','
+	DryRun     bool      // Import apenas valida e preenche o Report
+	Now        time.Time // instante de conclusão para "sim", "x", ...; padrão: time.Now()
+}
+
+// RowError é o erro de uma linha da planilha.
+type RowError struct {
+	Row    int // linha no arquivo; o cabeçalho é a linha 1
+	Column string
+	Err    error
+}
+
+func (e RowError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+	}
+	return fmt.Sprintf("row %d, column %q: %v", e.Row, e.Column, e.Err)
+}
+
+func (e RowError) Unwrap() error { return e.Err }
+
+// Report descreve o resultado de uma importação.
+type Report struct {
+	Tasks      []task.Task // tarefas das linhas válidas, na ordem da planilha
+	Rows       []int       // linha de cada tarefa em Tasks
+	Errors     []RowError
+	DateFormat string // formato usado para o prazo
+	Imported   int    // tarefas gravadas; 0 em DryRun
+}
+
+// ErrNoTitleColumn é retornado quando o cabeçalho não tem a coluna do título.
+var ErrNoTitleColumn = errors.New("header has no title column")
+
+// Parse lê e valida a planilha sem gravar nada. O ' antes de uma célula
+// que começa com =, +, - ou @, como os gravados por Export, é removido.
+func Parse(r io.Reader, opts Options) (Report, error) {
+	if opts.Mapping == nil {
+		opts.Mapping = DefaultMapping()
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	cr := csv.NewReader(r)
+	if opts.Comma != 0 {
+		cr.Comma = opts.Comma
+	}
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return Report{}, fmt.Errorf("reading header: %w", err)
+	}
+	columns := make(map[Field]int)
+	for field, name := range opts.Mapping {
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), strings.TrimSpace(name)) {
+				columns[field] = i
+				break
+			}
+		}
+	}
+	if _, ok := columns[Title]; !ok {
+		return Report{}, ErrNoTitleColumn
+	}
+
+	type row struct {
+		line   int
+		record []string
+	}
+	var rows []row
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Report{}, err
+		}
+		line, _ := cr.FieldPos(0)
+		rows = append(rows, row{line: line, record: record})
+	}
+
+	value := func(record []string, field Field) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return unescapeFormula(strings.TrimSpace(record[i]))
+	}
+
+	rep := Report{DateFormat: opts.DateFormat}
+	if rep.DateFormat == "" {
+		var deadlines []string
+		for _, row := range rows {
+			if v := value(row.record, Deadline); v != "" {
+				deadlines = append(deadlines, v)
+			}
+		}
+		rep.DateFormat = DetectDateFormat(deadlines)
+	}
+
+	for _, row := range rows {
+		if isBlank(row.record) {
+			continue
+		}
+		t, rowErr := parseRow(func(f Field) string { return value(row.record, f) }, rep.DateFormat, opts)
+		if rowErr != nil {
+			rowErr.Row = row.line
+			rep.Errors = append(rep.Errors, *rowErr)
+			continue
+		}
+		rep.Tasks = append(rep.Tasks, t)
+		rep.Rows = append(rep.Rows, row.line)
+	}
+	return rep, nil
+}
+
+func parseRow(value func(Field) string, dateFormat string, opts Options) (task.Task, *RowError) {
+	t := task.Task{Title: value(Title), Description: value(Description)}
+	if t.Title == "" {
+		return task.Task{}, &RowError{Column: opts.Mapping[Title], Err: errors.New("title is empty")}
+	}
+
+	if v := value(Deadline); v != "" {
+		d, err := time.ParseInLocation(dateFormat, v, time.Local)
+		if err != nil {
+			return task.Task{}, &RowError{Column: opts.Mapping[Deadline], Err: fmt.Errorf("invalid date %q (expected %s)", v, dateFormat)}
+		}
+		t.Deadline = d
+	}
+
+	if v := value(Priority); v != "" {
+		p, ok := parsePriority(v)
+		if !ok {
+			return task.Task{}, &RowError{Column: opts.Mapping[Priority], Err: fmt.Errorf("invalid priority %q", v)}
+		}
+		t.Priority = p
+	}
+
+	for _, tag := range strings.FieldsFunc(value(Tags), func(r rune) bool { return r == ',' || r == ';' }) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			t.Tags = append(t.Tags, tag)
+		}
+	}
+
+	if v := value(Completed); v != "" {
+		completedAt, ok := parseCompleted(v, dateFormat, opts.Now)
+		if !ok {
+			return task.Task{}, &RowError{Column: opts.Mapping[Completed], Err: fmt.Errorf("invalid completion %q", v)}
+		}
+		t.CompletedAt = completedAt
+	}
+	return t, nil
+}
+
+// DetectDateFormat retorna o primeiro formato de DateFormats que aceita
+// todos os valores. Se nenhum aceitar todos, retorna o que aceita mais.
+func DetectDateFormat(values []string) string {
+	best, bestCount := DateFormats[0], -1
+	for _, layout := range DateFormats {
+		count := 0
+		for _, v := range values {
+			if _, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+				count++
+			}
+		}
+		if count == len(values) {
+			return layout
+		}
+		if count > bestCount {
+			best, bestCount = layout, count
+		}
+	}
+	return best
+}
+
+// parsePriority aceita letras (A a Z), números de 1 a 9 (1 é a mais alta,
+// como no iCalendar) e alta/média/baixa ou high/medium/low.
+func parsePriority(v string) (string, bool) {
+	switch strings.ToLower(v) {
+	case "alta", "high":
+		return "A", true
+	case "média", "media", "medium":
+		return "B", true
+	case "baixa", "low":
+		return "C", true
+	}
+	if n, err := strconv.Atoi(v); err == nil {
+		if n < 1 || n > 9 {
+			return "", false
+		}
+		return string(rune('A' + n - 1)), true
+	}
+	if len(v) == 1 {
+		c := strings.ToUpper(v)[0]
+		if c >= 'A' && c <= 'Z' {
+			return string(c), true
+		}
+	}
+	return "", false
+}
+
+// parseCompleted aceita uma data no formato da coluna de prazo, RFC 3339 ou
+// uma marcação (sim, x, yes, true, 1, done), que conclui a tarefa em now.
+// Valores como "não" ou "0" deixam a tarefa pendente.
+func parseCompleted(v, dateFormat string, now time.Time) (time.Time, bool) {
+	switch strings.ToLower(v) {
+	case "sim", "s", "x", "yes", "y", "true", "1", "done", "concluída", "concluida":
+		return now, true
+	case "não", "nao", "n", "no", "false", "0":
+		return time.Time{}, true
+	}
+	for _, layout := range []string{dateFormat, time.RFC3339} {
+		if d, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+			return d, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func isBlank(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,244 @@
+// Package telegram liga o bot à Bot API do Telegram, por long polling
+// (Poll) ou por webhook (WebhookHandler).
+//
+// Cada chat do Telegram é um chat do bot, com as suas próprias listas, e o
+// autor das operações é "telegram:<username>" (ou o ID numérico, para
+// usuários sem username). As respostas com botões viram teclados inline;
+// tocar em um botão envia o comando dele ao bot.
+package telegram
+
+import (
+	"botasks/internal/bot"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// SecretTokenHeader é o cabeçalho com o segredo informado em SetWebhook.
+const SecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// maxCallbackAnswer é o limite da Bot API para o texto de
+// answerCallbackQuery, em caracteres.
+const maxCallbackAnswer = 200
+
+// Adapter entrega as atualizações do Telegram ao bot e envia as respostas.
+type Adapter struct {
+	// OnError é chamado quando uma atualização não pode ser atendida, como
+	// em uma falha ao enviar a resposta. O padrão registra no log.
+	OnError func(err error)
+
+	bot         *bot.Bot
+	token       string
+	baseURL     string
+	client      *http.Client
+	pollTimeout time.Duration
+	retryDelay  time.Duration
+}
+
+// Option configura um Adapter.
+type Option func(*Adapter)
+
+// WithBaseURL define o endereço da Bot API, como o de um servidor de testes.
+func WithBaseURL(u string) Option {
+	return func(a *Adapter) {
+		a.baseURL = u
+	}
+}
+
+// WithHTTPClient define o cliente HTTP. O timeout dele deve ser maior que o
+// do long polling.
+func WithHTTPClient(c *http.Client) Option {
+	return func(a *Adapter) {
+		a.client = c
+	}
+}
+
+// WithPollTimeout define quanto tempo getUpdates espera por atualizações.
+func WithPollTimeout(d time.Duration) Option {
+	return func(a *Adapter) {
+		a.pollTimeout = d
+	}
+}
+
+// WithRetryDelay define a espera de Poll após uma falha de getUpdates.
+func WithRetryDelay(d time.Duration) Option {
+	return func(a *Adapter) {
+		a.retryDelay = d
+	}
+}
+
+func New(token string, b *bot.Bot, opts ...Option) *Adapter {
+	a := &Adapter{
+		bot:         b,
+		token:       token,
+		baseURL:     DefaultBaseURL,
+		client:      &http.Client{Timeout: 60 * time.Second},
+		pollTimeout: 30 * time.Second,
+		retryDelay:  5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Poll busca atualizações com getUpdates até o contexto ser cancelado.
+// Falhas temporárias são repetidas; token inválido (401) ou webhook ativo
+// (409) encerram Poll com erro.
+func (a *Adapter) Poll(ctx context.Context) error {
+	var offset int64
+	for {
+		var updates []Update
+		params := getUpdatesParams{
+			Offset:         offset,
+			Timeout:        int(a.pollTimeout / time.Second),
+			AllowedUpdates: allowedUpdates,
+		}
+		err := a.call(ctx, "getUpdates", params, &updates)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && (apiErr.Code == http.StatusUnauthorized || apiErr.Code == http.StatusConflict) {
+			return err
+		}
+		if err != nil {
+			a.reportError(err)
+			select {
+			case <-time.After(a.retryDelay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			continue
+		}
+		for _, u := range updates {
+			// A atualização é confirmada mesmo se falhar, para que um comando
+			// não seja executado duas vezes
+			offset = u.UpdateID + 1
+			if err := a.HandleUpdate(ctx, u); err != nil {
+				a.reportError(err)
+			}
+		}
+	}
+}
+
+// SetWebhook registra a URL do webhook. O Telegram envia o segredo no
+// cabeçalho SecretTokenHeader de cada requisição.
+func (a *Adapter) SetWebhook(ctx context.Context, url, secret string) error {
+	return a.call(ctx, "setWebhook", setWebhookParams{URL: url, SecretToken: secret, AllowedUpdates: allowedUpdates}, nil)
+}
+
+// DeleteWebhook remove o webhook, o que é necessário para usar Poll.
+func (a *Adapter) DeleteWebhook(ctx context.Context) error {
+	return a.call(ctx, "deleteWebhook", struct{}{}, nil)
+}
+
+// WebhookHandler recebe as atualizações enviadas pelo Telegram. Requisições
+// sem o segredo correto recebem 401.
+func (a *Adapter) WebhookHandler(secret string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		got := r.Header.Get(SecretTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(got), []byte(secret)) != 1 {
+			http.Error(w, "invalid secret token", http.StatusUnauthorized)
+			return
+		}
+		var u Update
+		if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+			http.Error(w, "invalid update", http.StatusBadRequest)
+			return
+		}
+		// Responder com erro faria o Telegram reenviar a atualização
+		if err := a.HandleUpdate(r.Context(), u); err != nil {
+			a.reportError(err)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// HandleUpdate atende uma atualização: mensagens de texto e toques em
+// botões. Outros tipos são ignorados.
+func (a *Adapter) HandleUpdate(ctx context.Context, u Update) error {
+	switch {
+	case u.Message != nil && u.Message.Text != "":
+		m := u.Message
+		reply := a.bot.Handle(ctx, bot.Message{ChatID: chatID(m.Chat), User: actor(m.From), Text: m.Text})
+		return a.send(ctx, m.Chat.ID, reply)
+	case u.CallbackQuery != nil:
+		q := u.CallbackQuery
+		if q.Message == nil {
+			// Botões de mensagens inline não têm chat
+			return a.call(ctx, "answerCallbackQuery", answerCallbackQueryParams{CallbackQueryID: q.ID}, nil)
+		}
+		reply := a.bot.Handle(ctx, bot.Message{ChatID: chatID(q.Message.Chat), User: actor(&q.From), Text: q.Data})
+		answer := answerCallbackQueryParams{CallbackQueryID: q.ID, Text: truncate(reply.Text, maxCallbackAnswer)}
+		err := a.call(ctx, "answerCallbackQuery", answer, nil)
+		return errors.Join(err, a.send(ctx, q.Message.Chat.ID, reply))
+	}
+	return nil
+}
+
+// Send envia a resposta ao chat de reply.ChatID, como os lembretes do
+// bot.ReminderNotifier.
+func (a *Adapter) Send(ctx context.Context, reply bot.Reply) error {
+	chat, err := strconv.ParseInt(reply.ChatID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid chat ID %q", reply.ChatID)
+	}
+	return a.send(ctx, chat, reply)
+}
+
+func (a *Adapter) send(ctx context.Context, chat int64, reply bot.Reply) error {
+	params := sendMessageParams{ChatID: chat, Text: reply.Text}
+	if len(reply.Buttons) > 0 {
+		params.ReplyMarkup = &InlineKeyboardMarkup{}
+		for _, row := range reply.Buttons {
+			var buttons []InlineKeyboardButton
+			for _, b := range row {
+				buttons = append(buttons, InlineKeyboardButton{Text: b.Text, CallbackData: b.Data})
+			}
+			params.ReplyMarkup.InlineKeyboard = append(params.ReplyMarkup.InlineKeyboard, buttons)
+		}
+	}
+	return a.call(ctx, "sendMessage", params, nil)
+}
+
+func (a *Adapter) reportError(err error) {
+	if a.OnError != nil {
+		a.OnError(err)
+		return
+	}
+	log.Printf("telegram adapter: %v", err)
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+func chatID(c Chat) string {
+	return strconv.FormatInt(c.ID, 10)
+}
+
+func actor(u *User) string {
+	if u == nil {
+		return ""
+	}
+	if u.Username != "" {
+		return "telegram:" + u.Username
+	}
+	return "telegram:" + strconv.FormatInt(u.ID, 10)
+}
@@ -0,0 +1,106 @@
+package todotxt
+
+import (
+	"botasks/internal/list"
+	"botasks/internal/task"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+// Service é a parte do service.TaskListService usada por Import e Export.
+type Service interface {
+	WithinUnitOfWork(ctx context.Context, fn func(ctx context.Context) error) error
+	ListTaskLists(ctx context.Context) ([]list.TaskList, error)
+	CreateTaskList(ctx context.Context, name string) (string, error)
+	ImportTask(ctx context.Context, t task.Task, taskListIDs ...string) (string, error)
+}
+
+// Export grava todas as tarefas das listas do serviço. Cada lista vira um
+// projeto; uma tarefa em várias listas é escrita uma vez, com todos os
+// projetos.
+func Export(ctx context.Context, svc Service, w io.Writer) error {
+	taskLists, err := svc.ListTaskLists(ctx)
+	if err != nil {
+		return err
+	}
+
+	var items []Item
+	index := make(map[string]int)
+	for _, taskList := range taskLists {
+		for _, t := range taskList.Tasks {
+			i, seen := index[t.ID]
+			if !seen {
+				i = len(items)
+				index[t.ID] = i
+				items = append(items, Item{Task: t.Task})
+			}
+			items[i].Projects = append(items[i].Projects, Word(taskList.Name))
+		}
+	}
+	return Write(w, items)
+}
+
+// Import lê um arquivo todo.txt e cria as tarefas no serviço. Cada projeto é
+// associado à lista de mesmo nome (comparado via Word), que é criada se não
+// existir. Tarefas sem projeto vão para a lista defaultListID; se ela for
+// vazia, essas tarefas são um erro. Tudo é criado em uma única unidade de
+// trabalho: se alguma linha for inválida ou alguma criação falhar, nada é
+// criado. Retorna o número de tarefas importadas.
+func Import(ctx context.Context, svc Service, r io.Reader, defaultListID string, now time.Time) (int, error) {
+	items, err := Read(r, now)
+	if err != nil {
+		return 0, err
+	}
+	for i, it := range items {
+		if len(it.Projects) == 0 && defaultListID == "" {
+			return 0, fmt.Errorf("task %d (%q) has no project and no default list was given", i+1, it.Task.Title)
+		}
+	}
+
+	err = svc.WithinUnitOfWork(ctx, func(ctx context.Context) error {
+		return create(ctx, svc, items, defaultListID)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(items), nil
+}
+
+func create(ctx context.Context, svc Service, items []Item, defaultListID string) error {
+	taskLists, err := svc.ListTaskLists(ctx)
+	if err != nil {
+		return err
+	}
+	byProject := make(map[string]string, len(taskLists))
+	for _, taskList := range taskLists {
+		if _, exists := byProject[Word(taskList.Name)]; !exists {
+			byProject[Word(taskList.Name)] = taskList.ID
+		}
+	}
+
+	for _, it := range items {
+		var taskListIDs []string
+		for _, project := range it.Projects {
+			taskListID, exists := byProject[project]
+			if !exists {
+				if taskListID, err = svc.CreateTaskList(ctx, project); err != nil {
+					return err
+				}
+				byProject[project] = taskListID
+			}
+			if !slices.Contains(taskListIDs, taskListID) {
+				taskListIDs = append(taskListIDs, taskListID)
+			}
+		}
+		if len(taskListIDs) == 0 {
+			taskListIDs = append(taskListIDs, defaultListID)
+		}
+		if _, err := svc.ImportTask(ctx, it.Task, taskListIDs...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
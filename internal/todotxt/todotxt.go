@@ -0,0 +1,215 @@
+// Package todotxt converte tarefas de e para o formato todo.txt
+// (https://github.com/todotxt/todo.txt):
+//
+//	x 2024-05-02 2024-05-01 Lavar a louça +Casa @cozinha due:2024-05-03 pri:A
+//	(B) 2024-05-01 Ligar para o banco +Financeiro due:2024-05-10
+//
+// A prioridade vira Task.Priority, os contextos (@) viram Task.Tags e os
+// projetos (+) são as listas da tarefa. O formato guarda apenas datas, então
+// prazos e instantes perdem a hora, e não há lugar para a descrição.
+package todotxt
+
+import (
+	"botasks/internal/task"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// ErrNoTitle é retornado por Parse quando a linha não tem texto além de
+// prioridade, datas, projetos, contextos e pares chave:valor.
+var ErrNoTitle = errors.New("todo.txt line has no title")
+
+// Item é uma linha do todo.txt: a tarefa e os projetos (listas) a que ela
+// pertence.
+type Item struct {
+	Task     task.Task
+	Projects []string
+}
+
+// Parse interpreta uma linha do todo.txt. As datas são lidas no fuso local.
+// Uma tarefa marcada com "x" sem data de conclusão é considerada concluída
+// em now.
+func Parse(line string, now time.Time) (Item, error) {
+	var it Item
+	fields := strings.Fields(line)
+
+	if len(fields) > 0 && fields[0] == "x" {
+		fields = fields[1:]
+		it.Task.CompletedAt = now
+		if d, ok := parseDate(first(fields)); ok {
+			it.Task.CompletedAt = d
+			fields = fields[1:]
+			if d, ok := parseDate(first(fields)); ok {
+				it.Task.CreatedAt = d
+				fields = fields[1:]
+			}
+		}
+	} else {
+		if p, ok := parsePriority(first(fields)); ok {
+			it.Task.Priority = p
+			fields = fields[1:]
+		}
+		if d, ok := parseDate(first(fields)); ok {
+			it.Task.CreatedAt = d
+			fields = fields[1:]
+		}
+	}
+
+	var title []string
+	for _, f := range fields {
+		switch {
+		case len(f) > 1 && f[0] == '+':
+			it.Projects = append(it.Projects, f[1:])
+		case len(f) > 1 && f[0] == '@':
+			it.Task.Tags = append(it.Task.Tags, f[1:])
+		case strings.HasPrefix(f, "due:"):
+			if d, ok := parseDate(f[len("due:"):]); ok {
+				it.Task.Deadline = d
+			} else {
+				title = append(title, f)
+			}
+		case strings.HasPrefix(f, "pri:"):
+			if p, ok := parsePriority("(" + f[len("pri:"):] + ")"); ok {
+				it.Task.Priority = p
+			} else {
+				title = append(title, f)
+			}
+		default:
+			title = append(title, f)
+		}
+	}
+	if len(title) == 0 {
+		return Item{}, ErrNoTitle
+	}
+	it.Task.Title = strings.Join(title, " ")
+	return it, nil
+}
+
+// Format escreve o item como uma linha do todo.txt. Tarefas concluídas
+// guardam a prioridade em pri:, como recomenda o formato.
+func Format(it Item) string {
+	t := it.Task
+	var prefix []string
+	// ambiguous informa se uma palavra no início do título seria lida por
+	// Parse como parte do prefixo.
+	var ambiguous func(word string) bool
+
+	if t.IsCompleted() {
+		prefix = append(prefix, "x", t.CompletedAt.Format(dateLayout))
+		ambiguous = isDate
+		if !t.CreatedAt.IsZero() {
+			prefix = append(prefix, t.CreatedAt.Format(dateLayout))
+			ambiguous = func(string) bool { return false }
+		}
+	} else {
+		ambiguous = func(word string) bool {
+			_, isPriority := parsePriority(word)
+			return word == "x" || isPriority || isDate(word)
+		}
+		if t.Priority != "" {
+			prefix = append(prefix, "("+t.Priority+")")
+			ambiguous = isDate
+		}
+		if !t.CreatedAt.IsZero() {
+			prefix = append(prefix, t.CreatedAt.Format(dateLayout))
+			ambiguous = func(string) bool { return false }
+		}
+	}
+
+	var suffix []string
+	for _, p := range it.Projects {
+		suffix = append(suffix, "+"+Word(p))
+	}
+	for _, tag := range t.Tags {
+		suffix = append(suffix, "@"+Word(tag))
+	}
+	if !t.Deadline.IsZero() {
+		suffix = append(suffix, "due:"+t.Deadline.Format(dateLayout))
+	}
+	if t.IsCompleted() && t.Priority != "" {
+		suffix = append(suffix, "pri:"+t.Priority)
+	}
+
+	words := strings.Fields(t.Title)
+	if ambiguous(first(words)) {
+		// Os projetos, contextos e chaves vêm antes do título para que a
+		// primeira palavra não seja confundida com o prefixo
+		words = append(suffix, words...)
+		suffix = nil
+	}
+	return strings.Join(append(append(prefix, words...), suffix...), " ")
+}
+
+// Read lê um arquivo todo.txt, ignorando linhas em branco. O erro indica o
+// número da primeira linha inválida.
+func Read(r io.Reader, now time.Time) ([]Item, error) {
+	var items []Item
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		it, err := Parse(scanner.Text(), now)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		items = append(items, it)
+	}
+	return items, scanner.Err()
+}
+
+// Write grava os itens em w, um por linha.
+func Write(w io.Writer, items []Item) error {
+	bw := bufio.NewWriter(w)
+	for _, it := range items {
+		if _, err := fmt.Fprintln(bw, Format(it)); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Word troca os espaços de s por "_", tornando-o um projeto ou contexto
+// válido. Nomes de listas passam por Word ao virarem projetos.
+func Word(s string) string {
+	return strings.Join(strings.Fields(s), "_")
+}
+
+func first(fields []string) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// parseDate não aceita 0001-01-01: no fuso UTC esse é o instante zero,
+// que Format trata como data ausente.
+func parseDate(s string) (time.Time, bool) {
+	if len(s) != len(dateLayout) {
+		return time.Time{}, false
+	}
+	d, err := time.ParseInLocation(dateLayout, s, time.Local)
+	if err != nil || d.Year() == 1 && d.YearDay() == 1 {
+		return time.Time{}, false
+	}
+	return d, true
+}
+
+func isDate(s string) bool {
+	_, ok := parseDate(s)
+	return ok
+}
+
+// parsePriority reconhece "(A)" a "(Z)".
+func parsePriority(s string) (string, bool) {
+	if len(s) != 3 || s[0] != '(' || s[2] != ')' || s[1] < 'A' || s[1] > 'Z' {
+		return "", false
+	}
+	return s[1:2], true
+}
@@ -0,0 +1,363 @@
+// Package undo mantém, para cada autor, pilhas de desfazer e refazer das
+// operações feitas pelo TaskListService.
+//
+// O Manager assina o barramento de eventos e agrupa os eventos de uma mesma
+// chamada do serviço (mesmo OperationID) em uma única operação. Desfazer uma
+// exclusão de lista com as suas tarefas, por exemplo, restaura tudo de uma vez.
+package undo
+
+import (
+	"botasks/internal/event"
+	"botasks/internal/list"
+	"botasks/internal/service"
+	"botasks/internal/task"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrNothingToRedo = errors.New("nothing to redo")
+)
+
+// Service é o subconjunto do TaskListService usado para reverter e reaplicar operações.
+type Service interface {
+	WithinUnitOfWork(ctx context.Context, fn func(ctx context.Context) error) error
+	DeleteTaskIfMatch(ctx context.Context, taskID string, version int) ([]task.Task, error)
+	RestoreTaskIfMatch(ctx context.Context, t task.Task, version int) error
+	DeleteTaskList(ctx context.Context, taskListID string) error
+	RestoreTaskList(ctx context.Context, taskList list.TaskList) error
+	AddTaskToList(ctx context.Context, taskID, taskListID string) error
+	RemoveTaskFromList(ctx context.Context, taskID, taskListID string) error
+}
+
+var _ Service = (*service.TaskListService)(nil)
+
+type replayKey struct{}
+
+// operation reúne os eventos publicados por uma chamada do serviço. before
+// e after guardam, por tarefa, a versão esperada para refazer e para
+// desfazer a operação (0 para uma tarefa que não deve existir).
+type operation struct {
+	id     string
+	events []event.Event
+	before map[string]int
+	after  map[string]int
+}
+
+func newOperation(id string) operation {
+	return operation{id: id, before: make(map[string]int), after: make(map[string]int)}
+}
+
+func (op *operation) add(e event.Event) {
+	op.events = append(op.events, e)
+	if taskID, before, after, ok := taskVersions(e); ok {
+		if _, seen := op.before[taskID]; !seen {
+			op.before[taskID] = before
+		}
+		op.after[taskID] = after
+	}
+}
+
+// taskVersions retorna as versões da tarefa antes e depois do evento.
+func taskVersions(e event.Event) (taskID string, before, after int, ok bool) {
+	switch e := e.(type) {
+	case event.TaskCreated:
+		return e.Task.ID, 0, e.Task.Version, true
+	case event.TaskUpdated:
+		return e.After.ID, e.Before.Version, e.After.Version, true
+	case event.TaskCompleted:
+		return e.After.ID, e.Before.Version, e.After.Version, true
+	case event.TaskDeleted:
+		return e.Task.ID, e.Task.Version, 0, true
+	}
+	return "", 0, 0, false
+}
+
+// replay acompanha as versões durante um Undo ou Redo. versions começa com
+// as versões esperadas das tarefas da operação e recebe as que forem
+// gravadas. orphaned guarda a versão atual das subtarefas de outras
+// operações que perderam a mãe em uma exclusão, e orphanedFrom a versão que
+// elas tinham antes.
+type replay struct {
+	versions     map[string]int
+	orphaned     map[string]int
+	orphanedFrom map[string]int
+}
+
+func newReplay(versions map[string]int) *replay {
+	return &replay{versions: copyVersions(versions), orphaned: make(map[string]int), orphanedFrom: make(map[string]int)}
+}
+
+type history struct {
+	undo []operation
+	redo []operation
+}
+
+// Manager guarda o histórico de operações de cada autor.
+type Manager struct {
+	svc   Service
+	depth int
+
+	histories map[string]*history
+	mu        sync.Mutex
+}
+
+// NewManager cria um Manager que guarda até depth operações por autor.
+func NewManager(svc Service, depth int) *Manager {
+	return &Manager{svc: svc, depth: depth, histories: make(map[string]*history)}
+}
+
+// Subscribe inscreve o Manager no barramento de forma síncrona, para que a
+// operação esteja registrada assim que a chamada do serviço retornar.
+func (m *Manager) Subscribe(bus *event.Bus) (unsubscribe func()) {
+	return bus.Subscribe(m.Handle)
+}
+
+// Handle registra o evento no histórico do autor. Eventos gerados pelo
+// próprio Manager ao desfazer ou refazer são ignorados.
+func (m *Manager) Handle(ctx context.Context, e event.Event) {
+	if ctx.Value(replayKey{}) != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	meta := e.Metadata()
+	h := m.history(meta.Actor)
+	if n := len(h.undo); n > 0 && meta.OperationID != "" && h.undo[n-1].id == meta.OperationID {
+		h.undo[n-1].add(e)
+		return
+	}
+
+	op := newOperation(meta.OperationID)
+	op.add(e)
+	h.undo = append(h.undo, op)
+	if len(h.undo) > m.depth {
+		h.undo = h.undo[len(h.undo)-m.depth:]
+	}
+	h.redo = nil
+}
+
+// history deve ser chamado com m.mu travado.
+func (m *Manager) history(actor string) *history {
+	h, ok := m.histories[actor]
+	if !ok {
+		h = &history{}
+		m.histories[actor] = h
+	}
+	return h
+}
+
+// Undo desfaz a última operação do autor do contexto. A operação é
+// revertida em uma unidade de trabalho do serviço e só passa para a pilha
+// de refazer se der certo. Se alguém alterou uma das tarefas depois da
+// operação, nada é revertido e o erro é repository.ErrConflict.
+func (m *Manager) Undo(ctx context.Context) error {
+	actor := service.ActorFromContext(ctx)
+
+	m.mu.Lock()
+	h := m.history(actor)
+	if len(h.undo) == 0 {
+		m.mu.Unlock()
+		return ErrNothingToUndo
+	}
+	op := h.undo[len(h.undo)-1]
+	r := newReplay(op.after)
+	m.mu.Unlock()
+
+	ctx = context.WithValue(ctx, replayKey{}, true)
+	err := m.svc.WithinUnitOfWork(ctx, func(ctx context.Context) error {
+		for i := len(op.events) - 1; i >= 0; i-- {
+			if err := m.revert(ctx, op.events[i], r); err != nil {
+				return fmt.Errorf("undo %s: %w", op.events[i].Name(), err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h.undo = removeOperation(h.undo, op.id)
+	m.relink(op.before, r.versions)
+	m.relink(r.orphanedFrom, r.orphaned)
+	op.before = r.versions
+	h.redo = append(h.redo, op)
+	return nil
+}
+
+// Redo reaplica a última operação desfeita pelo autor do contexto, com as
+// mesmas garantias do Undo.
+func (m *Manager) Redo(ctx context.Context) error {
+	actor := service.ActorFromContext(ctx)
+
+	m.mu.Lock()
+	h := m.history(actor)
+	if len(h.redo) == 0 {
+		m.mu.Unlock()
+		return ErrNothingToRedo
+	}
+	op := h.redo[len(h.redo)-1]
+	r := newReplay(op.before)
+	m.mu.Unlock()
+
+	ctx = context.WithValue(ctx, replayKey{}, true)
+	err := m.svc.WithinUnitOfWork(ctx, func(ctx context.Context) error {
+		for _, e := range op.events {
+			if err := m.apply(ctx, e, r); err != nil {
+				return fmt.Errorf("redo %s: %w", e.Name(), err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h.redo = removeOperation(h.redo, op.id)
+	m.relink(op.after, r.versions)
+	m.relink(r.orphanedFrom, r.orphaned)
+	op.after = r.versions
+	h.undo = append(h.undo, op)
+	return nil
+}
+
+func copyVersions(versions map[string]int) map[string]int {
+	c := make(map[string]int, len(versions))
+	for taskID, v := range versions {
+		c[taskID] = v
+	}
+	return c
+}
+
+func removeOperation(ops []operation, id string) []operation {
+	for i := len(ops) - 1; i >= 0; i-- {
+		if ops[i].id == id {
+			return append(ops[:i:i], ops[i+1:]...)
+		}
+	}
+	return ops
+}
+
+// relink registra que o estado das tarefas na versão old voltou a valer na
+// versão current, gravada ao desfazer ou refazer: as operações de todos os
+// autores que esperavam old passam a esperar current. Deve ser chamado com
+// m.mu travado.
+func (m *Manager) relink(old, current map[string]int) {
+	update := func(versions map[string]int) {
+		for taskID, v := range current {
+			if prev, ok := versions[taskID]; ok && prev == old[taskID] {
+				versions[taskID] = v
+			}
+		}
+	}
+	for _, h := range m.histories {
+		for _, stack := range [][]operation{h.undo, h.redo} {
+			for _, op := range stack {
+				update(op.before)
+				update(op.after)
+			}
+		}
+	}
+}
+
+// restore grava a tarefa se ela estiver na versão esperada e registra a
+// versão resultante.
+func (m *Manager) restore(ctx context.Context, t task.Task, r *replay) error {
+	expected := r.versions[t.ID]
+	if err := m.svc.RestoreTaskIfMatch(ctx, t, expected); err != nil {
+		return err
+	}
+	r.versions[t.ID] = expected + 1
+	return nil
+}
+
+// delete exclui a tarefa se ela estiver na versão esperada e registra as
+// novas versões das subtarefas que perderam a mãe.
+func (m *Manager) delete(ctx context.Context, taskID string, r *replay) error {
+	children, err := m.svc.DeleteTaskIfMatch(ctx, taskID, r.versions[taskID])
+	if err != nil {
+		return err
+	}
+	r.versions[taskID] = 0
+	for _, child := range children {
+		if _, own := r.versions[child.ID]; own {
+			r.versions[child.ID] = child.Version
+			continue
+		}
+		if _, seen := r.orphanedFrom[child.ID]; !seen {
+			r.orphanedFrom[child.ID] = child.Version - 1
+		}
+		r.orphaned[child.ID] = child.Version
+	}
+	return nil
+}
+
+// revert aplica o inverso do evento.
+func (m *Manager) revert(ctx context.Context, e event.Event, r *replay) error {
+	switch e := e.(type) {
+	case event.TaskCreated:
+		if e.ListID != "" {
+			if err := m.svc.RemoveTaskFromList(ctx, e.Task.ID, e.ListID); err != nil {
+				return err
+			}
+		}
+		return m.delete(ctx, e.Task.ID, r)
+	case event.TaskUpdated:
+		return m.restore(ctx, e.Before, r)
+	case event.TaskCompleted:
+		return m.restore(ctx, e.Before, r)
+	case event.TaskDeleted:
+		return m.restore(ctx, e.Task, r)
+	case event.ListCreated:
+		return m.svc.DeleteTaskList(ctx, e.List.ID)
+	case event.ListRenamed:
+		return m.svc.RestoreTaskList(ctx, e.Before)
+	case event.ListDeleted:
+		return m.svc.RestoreTaskList(ctx, e.List)
+	case event.TaskAddedToList:
+		return m.svc.RemoveTaskFromList(ctx, e.TaskID, e.ListID)
+	case event.TaskRemovedFromList:
+		return m.svc.AddTaskToList(ctx, e.TaskID, e.ListID)
+	}
+	return nil
+}
+
+// apply reaplica o evento.
+func (m *Manager) apply(ctx context.Context, e event.Event, r *replay) error {
+	switch e := e.(type) {
+	case event.TaskCreated:
+		if err := m.restore(ctx, e.Task, r); err != nil {
+			return err
+		}
+		if e.ListID != "" {
+			return m.svc.AddTaskToList(ctx, e.Task.ID, e.ListID)
+		}
+		return nil
+	case event.TaskUpdated:
+		return m.restore(ctx, e.After, r)
+	case event.TaskCompleted:
+		return m.restore(ctx, e.After, r)
+	case event.TaskDeleted:
+		return m.delete(ctx, e.Task.ID, r)
+	case event.ListCreated:
+		return m.svc.RestoreTaskList(ctx, e.List)
+	case event.ListRenamed:
+		return m.svc.RestoreTaskList(ctx, e.After)
+	case event.ListDeleted:
+		return m.svc.DeleteTaskList(ctx, e.List.ID)
+	case event.TaskAddedToList:
+		return m.svc.AddTaskToList(ctx, e.TaskID, e.ListID)
+	case event.TaskRemovedFromList:
+		return m.svc.RemoveTaskFromList(ctx, e.TaskID, e.ListID)
+	}
+	return nil
+}
@@ -0,0 +1,538 @@
+// Package webhook entrega os eventos de domínio a endpoints HTTP registrados.
+//
+// Cada entrega é um POST com um JSON (Payload) assinado com HMAC-SHA256 no
+// cabeçalho X-BoTasks-Signature ("sha256=<hex>"). As tentativas rodam em
+// goroutines próprias, sem prender quem publicou o evento, e as falhas são
+// repetidas com backoff exponencial; depois da última tentativa a entrega
+// vai para o DeadLetterStore, de onde pode ser reenviada com Redeliver.
+package webhook
+
+import (
+	"botasks/internal/clock"
+	"botasks/internal/event"
+	"botasks/internal/idgen"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-BoTasks-Signature"
+	EventHeader     = "X-BoTasks-Event"
+	DeliveryHeader  = "X-BoTasks-Delivery"
+)
+
+var (
+	ErrEndpointNotFound = errors.New("webhook endpoint not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// Endpoint é um destino registrado para receber eventos.
+type Endpoint struct {
+	ID     string
+	URL    string
+	Secret string
+	// Events limita os eventos entregues, por nome ("task.completed").
+	// Vazio entrega todos.
+	Events []string
+}
+
+func (e Endpoint) wants(name string) bool {
+	if len(e.Events) == 0 {
+		return true
+	}
+	for _, n := range e.Events {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Payload é o corpo JSON enviado aos endpoints.
+type Payload struct {
+	DeliveryID  string          `json:"delivery_id"`
+	Event       string          `json:"event"`
+	AggregateID string          `json:"aggregate_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Actor       string          `json:"actor,omitempty"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// Status é a situação de uma entrega.
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusDelivered Status = "delivered"
+	StatusDead      Status = "dead" // esgotou as tentativas
+)
+
+// Attempt registra uma tentativa de entrega.
+type Attempt struct {
+	At         time.Time
+	StatusCode int    // 0 se a requisição não obteve resposta
+	Error      string // vazio em caso de sucesso
+}
+
+// Delivery é o registro de entrega de um evento a um endpoint.
+type Delivery struct {
+	ID         string
+	EndpointID string
+	Event      string
+	Body       []byte
+	Status     Status
+	Attempts   []Attempt
+}
+
+// DeliveryQuery filtra o log de entregas. Campos vazios não filtram.
+type DeliveryQuery struct {
+	EndpointID string
+	Event      string
+	Status     Status
+}
+
+func (q DeliveryQuery) match(d Delivery) bool {
+	return (q.EndpointID == "" || d.EndpointID == q.EndpointID) &&
+		(q.Event == "" || d.Event == q.Event) &&
+		(q.Status == "" || d.Status == q.Status)
+}
+
+// DeadLetterStore guarda as entregas que esgotaram as tentativas.
+type DeadLetterStore interface {
+	Add(d Delivery) error
+	Remove(deliveryID string) error
+	List() ([]Delivery, error)
+}
+
+// MemoryDeadLetterStore é um DeadLetterStore em memória.
+type MemoryDeadLetterStore struct {
+	deliveries []Delivery
+	mu         sync.Mutex
+}
+
+func NewMemoryDeadLetterStore() *MemoryDeadLetterStore {
+	return &MemoryDeadLetterStore{}
+}
+
+func (s *MemoryDeadLetterStore) Add(d Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deliveries = append(s.deliveries, d)
+	return nil
+}
+
+func (s *MemoryDeadLetterStore) Remove(deliveryID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, d := range s.deliveries {
+		if d.ID == deliveryID {
+			s.deliveries = append(s.deliveries[:i:i], s.deliveries[i+1:]...)
+			return nil
+		}
+	}
+	return ErrDeliveryNotFound
+}
+
+func (s *MemoryDeadLetterStore) List() ([]Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Delivery(nil), s.deliveries...), nil
+}
+
+// Sign calcula a assinatura do corpo no formato do cabeçalho SignatureHeader.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify confere a assinatura recebida em tempo constante.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// Service registra endpoints, entrega eventos e mantém o log das entregas
+// mais recentes.
+type Service struct {
+	client      *http.Client
+	clock       clock.Clock
+	ids         idgen.Generator
+	deadLetters DeadLetterStore
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	sleep       func(ctx context.Context, d time.Duration) error
+	logSize     int
+
+	endpoints  map[string]Endpoint
+	deliveries []*Delivery
+	mu         sync.Mutex
+
+	// retries acompanha as entregas em andamento; stop interrompe as
+	// esperas entre tentativas no Close. closed, protegido por mu, impede
+	// novas entregas depois do Close (veja track).
+	retries sync.WaitGroup
+	stop    context.Context
+	cancel  context.CancelFunc
+	closed  bool
+}
+
+// Option configura um Service.
+type Option func(*Service)
+
+// WithHTTPClient define o cliente HTTP usado nas entregas.
+func WithHTTPClient(c *http.Client) Option {
+	return func(s *Service) { s.client = c }
+}
+
+// WithClock define o relógio usado para registrar as tentativas.
+func WithClock(c clock.Clock) Option {
+	return func(s *Service) { s.clock = c }
+}
+
+// WithIDGenerator define o gerador de IDs de endpoints e entregas.
+func WithIDGenerator(g idgen.Generator) Option {
+	return func(s *Service) { s.ids = g }
+}
+
+// WithDeadLetterStore define onde ficam as entregas que esgotaram as tentativas.
+func WithDeadLetterStore(d DeadLetterStore) Option {
+	return func(s *Service) { s.deadLetters = d }
+}
+
+// WithRetry define o número máximo de tentativas e o intervalo antes da
+// segunda tentativa. O intervalo dobra a cada falha, até maxBackoff.
+func WithRetry(maxAttempts int, backoff, maxBackoff time.Duration) Option {
+	return func(s *Service) {
+		s.maxAttempts = maxAttempts
+		s.backoff = backoff
+		s.maxBackoff = maxBackoff
+	}
+}
+
+// WithSleep substitui a espera entre tentativas. Útil em testes.
+func WithSleep(sleep func(ctx context.Context, d time.Duration) error) Option {
+	return func(s *Service) { s.sleep = sleep }
+}
+
+// WithDeliveryLogSize define quantas entregas o log guarda. As mais antigas
+// saem primeiro; as que esgotaram as tentativas continuam no DeadLetterStore.
+// O padrão é 1000.
+func WithDeliveryLogSize(n int) Option {
+	return func(s *Service) { s.logSize = n }
+}
+
+// NewService cria um Service. Por padrão faz até 5 tentativas, começando
+// com 1s de espera e limitando a espera a 1min.
+func NewService(opts ...Option) *Service {
+	s := &Service{
+		client:      &http.Client{Timeout: 10 * time.Second},
+		clock:       clock.New(),
+		ids:         idgen.Default(),
+		deadLetters: NewMemoryDeadLetterStore(),
+		maxAttempts: 5,
+		backoff:     time.Second,
+		maxBackoff:  time.Minute,
+		sleep:       sleepContext,
+		logSize:     1000,
+		endpoints:   make(map[string]Endpoint),
+	}
+	s.stop, s.cancel = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Close interrompe a espera das novas tentativas agendadas, que vão para o
+// DeadLetterStore, e espera as tentativas em andamento terminarem. Depois
+// dele, os eventos recebidos por Handle vão direto para o DeadLetterStore.
+func (s *Service) Close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.cancel()
+	s.retries.Wait()
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Register registra um endpoint e retorna o seu ID. Se e.ID estiver vazio,
+// um novo ID é gerado.
+func (s *Service) Register(e Endpoint) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e.ID == "" {
+		e.ID = s.ids.NewID()
+	}
+	s.endpoints[e.ID] = e
+	return e.ID
+}
+
+// Unregister remove um endpoint.
+func (s *Service) Unregister(endpointID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.endpoints[endpointID]; !ok {
+		return ErrEndpointNotFound
+	}
+	delete(s.endpoints, endpointID)
+	return nil
+}
+
+// Subscribe inscreve o serviço no barramento. As entregas acontecem fora da
+// goroutine de publicação e sem ordem garantida.
+func (s *Service) Subscribe(bus *event.Bus) (unsubscribe func()) {
+	return bus.SubscribeAsync(s.Handle, 4, 64)
+}
+
+// Handle agenda a entrega do evento a todos os endpoints interessados, sem
+// esperar por ela. Pode ser usado diretamente como event.Handler.
+func (s *Service) Handle(ctx context.Context, e event.Event) {
+	ctx = context.WithoutCancel(ctx)
+	s.mu.Lock()
+	var targets []Endpoint
+	for _, ep := range s.endpoints {
+		if ep.wants(e.Name()) {
+			targets = append(targets, ep)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, ep := range targets {
+		d, err := s.newDelivery(ep, e)
+		if err != nil {
+			continue
+		}
+		if !s.track() {
+			s.bury(d)
+			continue
+		}
+		go func(ep Endpoint) {
+			defer s.retries.Done()
+			s.deliver(ctx, ep, d, 1, s.backoff, true)
+		}(ep)
+	}
+}
+
+func (s *Service) newDelivery(ep Endpoint, e event.Event) (*Delivery, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	meta := e.Metadata()
+	p := Payload{
+		DeliveryID:  s.ids.NewID(),
+		Event:       e.Name(),
+		AggregateID: e.AggregateID(),
+		OccurredAt:  meta.At,
+		Actor:       meta.Actor,
+		Data:        data,
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Delivery{ID: p.DeliveryID, EndpointID: ep.ID, Event: p.Event, Body: body, Status: StatusPending}
+	s.mu.Lock()
+	s.logDelivery(d)
+	s.mu.Unlock()
+	return d, nil
+}
+
+// logDelivery deve ser chamado com s.mu travado.
+func (s *Service) logDelivery(d *Delivery) {
+	s.deliveries = append(s.deliveries, d)
+	if s.logSize > 0 && len(s.deliveries) > s.logSize {
+		// Copia para liberar o início do array antigo
+		s.deliveries = append([]*Delivery(nil), s.deliveries[len(s.deliveries)-s.logSize:]...)
+	}
+}
+
+// deliver faz a tentativa de número attempt. Respostas 2xx encerram com
+// sucesso; qualquer outra resposta ou erro de rede conta como falha, e a
+// próxima tentativa é agendada para depois de wait. Com retry, deve ser
+// chamado por uma entrega registrada com track; sem ele, a falha vai
+// direto para o DeadLetterStore.
+func (s *Service) deliver(ctx context.Context, ep Endpoint, d *Delivery, attempt int, wait time.Duration, retry bool) {
+	code, err := s.post(ctx, ep, d)
+	a := Attempt{At: s.clock.Now(), StatusCode: code}
+	if err != nil {
+		a.Error = err.Error()
+	}
+
+	s.mu.Lock()
+	d.Attempts = append(d.Attempts, a)
+	if err == nil {
+		d.Status = StatusDelivered
+	}
+	s.mu.Unlock()
+	if err == nil {
+		return
+	}
+	if !retry || attempt >= s.maxAttempts {
+		s.bury(d)
+		return
+	}
+
+	s.retries.Add(1)
+	go func() {
+		defer s.retries.Done()
+
+		if err := s.sleep(s.stop, wait); err != nil {
+			s.bury(d)
+			return
+		}
+		s.deliver(ctx, ep, d, attempt+1, min(wait*2, s.maxBackoff), true)
+	}()
+}
+
+// track registra uma entrega em andamento para o Close esperar por ela.
+// Retorna false depois do Close.
+func (s *Service) track() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Dentro de mu, para o Add não correr com o Wait do Close
+	if s.closed {
+		return false
+	}
+	s.retries.Add(1)
+	return true
+}
+
+// bury marca a entrega como morta e a guarda no DeadLetterStore.
+func (s *Service) bury(d *Delivery) {
+	s.mu.Lock()
+	d.Status = StatusDead
+	dead := s.copyDelivery(d)
+	s.mu.Unlock()
+	s.deadLetters.Add(dead)
+}
+
+func (s *Service) post(ctx context.Context, ep Endpoint, d *Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(d.Body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, d.Event)
+	req.Header.Set(DeliveryHeader, d.ID)
+	req.Header.Set(SignatureHeader, Sign(ep.Secret, d.Body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Deliveries retorna o log de entregas que satisfazem a consulta, da mais
+// antiga para a mais recente.
+func (s *Service) Deliveries(q DeliveryQuery) []Delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []Delivery
+	for _, d := range s.deliveries {
+		if q.match(*d) {
+			result = append(result, s.copyDelivery(d))
+		}
+	}
+	return result
+}
+
+// DeadLetters retorna as entregas que esgotaram as tentativas.
+func (s *Service) DeadLetters() ([]Delivery, error) {
+	return s.deadLetters.List()
+}
+
+// Redeliver tira a entrega do DeadLetterStore e tenta enviá-la novamente
+// com o mesmo corpo e ID. Se a tentativa falhar, as próximas são agendadas
+// como as de um evento novo; depois do Close, a entrega volta para o
+// DeadLetterStore.
+func (s *Service) Redeliver(ctx context.Context, deliveryID string) error {
+	dead, err := s.deadLetters.List()
+	if err != nil {
+		return err
+	}
+	var found *Delivery
+	for i := range dead {
+		if dead[i].ID == deliveryID {
+			found = &dead[i]
+			break
+		}
+	}
+	if found == nil {
+		return ErrDeliveryNotFound
+	}
+	s.mu.Lock()
+	ep, ok := s.endpoints[found.EndpointID]
+	s.mu.Unlock()
+	if !ok {
+		return ErrEndpointNotFound
+	}
+
+	if err := s.deadLetters.Remove(deliveryID); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	// Continua o registro do log se ele ainda estiver lá
+	d := found
+	for _, candidate := range s.deliveries {
+		if candidate.ID == deliveryID {
+			d = candidate
+			break
+		}
+	}
+	if d == found {
+		s.logDelivery(d)
+	}
+	d.Status = StatusPending
+	s.mu.Unlock()
+	retry := s.track()
+	if retry {
+		defer s.retries.Done()
+	}
+	s.deliver(context.WithoutCancel(ctx), ep, d, 1, s.backoff, retry)
+	return nil
+}
+
+// copyDelivery deve ser chamado com s.mu travado.
+func (s *Service) copyDelivery(d *Delivery) Delivery {
+	c := *d
+	c.Attempts = append([]Attempt(nil), d.Attempts...)
+	return c
+}
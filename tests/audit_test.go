@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"botasks/internal/audit"
+	"botasks/internal/clock"
+	"botasks/internal/event"
+	"botasks/internal/service"
+	"botasks/internal/task"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog_TaskAndListHistory(t *testing.T) {
+	start := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	c := clock.NewFake(start)
+	bus := event.NewBus()
+	auditLog := audit.NewLog(audit.NewMemoryStore())
+	auditLog.Subscribe(bus)
+	s := newMemoryService(service.WithClock(c), service.WithEventPublisher(bus))
+
+	alice := service.ContextWithActor(context.Background(), "alice")
+	bob := service.ContextWithActor(context.Background(), "bob")
+
+	taskListID, err := s.CreateTaskList(alice, "Casa")
+	require.NoError(t, err)
+	otherListID, err := s.CreateTaskList(alice, "Trabalho")
+	require.NoError(t, err)
+	taskID, err := s.AddTask(alice, taskListID, "Pagar luz", "", start.Add(24*time.Hour))
+	require.NoError(t, err)
+
+	c.Advance(time.Hour)
+	require.NoError(t, s.UpdateTask(bob, taskID, "Pagar conta de luz", "", time.Time{}))
+	require.NoError(t, s.UpdateTaskList(bob, taskListID, "Lar"))
+	require.NoError(t, s.AddTaskToList(bob, taskID, otherListID))
+	require.NoError(t, s.RemoveTaskFromList(bob, taskID, taskListID))
+	require.NoError(t, s.DeleteTask(bob, taskID))
+
+	history, err := auditLog.TaskHistory(context.Background(), taskID)
+	require.NoError(t, err)
+	ops := make([]string, len(history))
+	for i, e := range history {
+		ops[i] = e.Operation
+	}
+	assert.Equal(t, []string{"task.created", "task.updated", "list.task_added", "list.task_removed", "task.deleted"}, ops)
+
+	updated := history[1]
+	assert.Equal(t, "bob", updated.Actor)
+	assert.Equal(t, start.Add(time.Hour), updated.At)
+	assert.Equal(t, []audit.FieldChange{{Field: "Title", Before: "Pagar luz", After: "Pagar conta de luz"}}, updated.Changes)
+	assert.Less(t, history[0].Seq, history[1].Seq)
+
+	listHistory, err := auditLog.ListHistory(context.Background(), taskListID)
+	require.NoError(t, err)
+	ops = ops[:0]
+	for _, e := range listHistory {
+		ops = append(ops, e.Operation)
+	}
+	assert.Equal(t, []string{"list.created", "task.created", "list.renamed", "list.task_removed"}, ops)
+	assert.Equal(t, []audit.FieldChange{{Field: "Name", Before: "Casa", After: "Lar"}}, listHistory[2].Changes)
+}
+
+func TestAuditFileStore_AppendOnlyAcrossReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	store, err := audit.OpenFileStore(path)
+	require.NoError(t, err)
+	first, err := store.Append(ctx, audit.Entry{Operation: "task.created", EntityType: audit.EntityTask, EntityID: "t1"})
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	store, err = audit.OpenFileStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+	second, err := store.Append(ctx, audit.Entry{Operation: "task.deleted", EntityType: audit.EntityTask, EntityID: "t1"})
+	require.NoError(t, err)
+	assert.Equal(t, first.Seq+1, second.Seq)
+
+	entries, err := audit.NewLog(store).TaskHistory(ctx, "t1")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "task.created", entries[0].Operation)
+	assert.Equal(t, "task.deleted", entries[1].Operation)
+}
+
+func TestAuditLog_RecordsEventsWithCanceledContext(t *testing.T) {
+	store, err := audit.OpenFileStore(filepath.Join(t.TempDir(), "audit.jsonl"))
+	require.NoError(t, err)
+	defer store.Close()
+	auditLog := audit.NewLog(store)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	auditLog.Handle(ctx, event.TaskCreated{Meta: event.Meta{Actor: "alice"}, Task: task.Task{ID: "t1", Title: "Pagar luz"}})
+
+	history, err := auditLog.TaskHistory(context.Background(), "t1")
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, "task.created", history[0].Operation)
+}
@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"botasks/internal/backup"
+	"botasks/internal/cli"
+	"botasks/internal/clock"
+	"botasks/internal/repository/eventsourced"
+	"botasks/internal/service"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackup_RestoreIntoAnotherBackend(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC))
+	source := newMemoryService(service.WithClock(clk))
+
+	casa, err := source.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+	lavar, err := source.AddTask(ctx, casa, "Lavar", "", clk.Now().Add(time.Hour))
+	require.NoError(t, err)
+	varrer, err := source.AddTask(ctx, casa, "Varrer", "", clk.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, source.CompleteTask(ctx, varrer))
+	_, err = source.CreateTaskList(ctx, "Vazia")
+	require.NoError(t, err)
+
+	a, err := source.Export(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, backup.FormatVersion, a.Version)
+	assert.Len(t, a.Lists, 2)
+	assert.Len(t, a.Tasks, 2)
+
+	path := filepath.Join(t.TempDir(), "backup.json.gz")
+	require.NoError(t, backup.WriteFile(path, a))
+	read, err := backup.ReadFile(path)
+	require.NoError(t, err)
+
+	store, err := eventsourced.Open(ctx, eventsourced.NewMemoryLog())
+	require.NoError(t, err)
+	target := service.NewTaskListService(store.TaskLists(), store.Tasks(), service.WithUnitOfWork(store))
+	require.NoError(t, target.Restore(ctx, read, backup.Replace))
+
+	tasks, err := target.GetTasksByTaskList(ctx, casa)
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	assert.Equal(t, lavar, tasks[0].ID)
+	assert.Equal(t, "Varrer", tasks[1].Title)
+	assert.True(t, tasks[1].IsCompleted())
+
+	lists, err := target.ListTaskLists(ctx)
+	require.NoError(t, err)
+	assert.Len(t, lists, 2)
+}
+
+func TestBackup_MergeKeepsNewerAndUnitesLists(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC))
+	s := newMemoryService(service.WithClock(clk))
+
+	casa, err := s.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+	lavar, err := s.AddTask(ctx, casa, "Lavar", "", clk.Now().Add(time.Hour))
+	require.NoError(t, err)
+	a, err := s.Export(ctx)
+	require.NoError(t, err)
+
+	// Depois do backup: uma alteração mais nova e uma tarefa removida da lista
+	clk.Advance(time.Minute)
+	require.NoError(t, s.UpdateTask(ctx, lavar, "Lavar louça", "", time.Time{}))
+	require.NoError(t, s.RemoveTaskFromList(ctx, lavar, casa))
+	extra, err := s.AddTask(ctx, casa, "Extra", "", clk.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	require.NoError(t, s.Restore(ctx, a, backup.Merge))
+
+	restored, err := s.GetTask(ctx, lavar)
+	require.NoError(t, err)
+	assert.Equal(t, "Lavar louça", restored.Title)
+
+	tasks, err := s.GetTasksByTaskList(ctx, casa)
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	assert.Equal(t, extra, tasks[0].ID)
+	assert.Equal(t, lavar, tasks[1].ID)
+}
+
+func TestBackup_ReplaceRemovesExistingData(t *testing.T) {
+	ctx := context.Background()
+	s := newMemoryService()
+
+	casa, err := s.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+	a, err := s.Export(ctx)
+	require.NoError(t, err)
+
+	trabalho, err := s.CreateTaskList(ctx, "Trabalho")
+	require.NoError(t, err)
+	relatorio, err := s.AddTask(ctx, trabalho, "Relatório", "", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	require.NoError(t, s.Restore(ctx, a, backup.Replace))
+
+	lists, err := s.ListTaskLists(ctx)
+	require.NoError(t, err)
+	require.Len(t, lists, 1)
+	assert.Equal(t, casa, lists[0].ID)
+	_, err = s.GetTask(ctx, relatorio)
+	assert.Error(t, err)
+}
+
+func TestBackup_RejectsUnknownVersion(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write([]byte(`{"version": 99, "tasks": [], "lists": []}`))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	_, err = backup.Read(&buf)
+	assert.ErrorIs(t, err, backup.ErrUnsupportedVersion)
+}
+
+func TestCLI_ExportImport(t *testing.T) {
+	s, m := newUndoService(t)
+	ctx := service.ContextWithActor(context.Background(), "cli")
+	path := filepath.Join(t.TempDir(), "backup.json.gz")
+	var out bytes.Buffer
+	c := cli.New(s, m, &out)
+
+	script := strings.Join([]string{
+		"newlist Casa", "add Lavar", "export " + path,
+		"rmlist", "import " + path + " replace", "use 1", "tasks",
+	}, "\n")
+	require.NoError(t, c.Run(ctx, strings.NewReader(script)))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, "exported 1 lists, 1 tasks", lines[1])
+	assert.Equal(t, "1 lists", lines[2])
+	assert.Contains(t, lines[3], "1. [ ] Lavar")
+}
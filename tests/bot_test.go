@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"botasks/internal/bot"
+	"botasks/internal/clock"
+	"botasks/internal/service"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBot_ConversationOverLocalTransport(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// Terça-feira, 15h
+	clk := clock.NewFake(time.Date(2024, 5, 7, 15, 0, 0, 0, time.UTC))
+	s := newMemoryService(service.WithClock(clk))
+	b := bot.New(s, bot.WithClock(clk))
+
+	tr := bot.NewLocal()
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx, tr) }()
+
+	say := func(chatID, text string) string {
+		t.Helper()
+		r, err := tr.Say(ctx, bot.Message{ChatID: chatID, Text: text})
+		require.NoError(t, err)
+		assert.Equal(t, chatID, r.ChatID)
+		return r.Text
+	}
+
+	assert.Equal(t, "You have no lists. Create one with /newlist <name>.", say("1", "/lists"))
+	assert.Equal(t, "Added to Inbox: Buy milk (due Wed May 8 18:00) #home", say("1", "/add Buy milk tomorrow 18h #home"))
+	assert.Equal(t, "Added to Inbox: Call Ana (due Tue May 7 23:59)", say("1", "/add@boTasksBot Call Ana hoje"))
+	assert.Equal(t, "Added to Inbox: Revisar PR (due Wed May 8 09:30)", say("1", "/add Revisar PR 9h30"))
+	assert.Equal(t, "Added to Inbox: Pagar luz (due Wed May 8 15:00)", say("1", "/add Pagar luz"))
+	assert.Equal(t, "Error: usage: /add <task> [when] [every ...] [!high] [#tag]", say("1", "/add #casa amanhã"))
+	assert.Equal(t, "Error: invalid task parameters: deadline 2024-05-07 09:00 is in the past", say("1", "/add Standup hoje 9h"))
+
+	assert.Equal(t, "Error: no tasks shown (use /list first)", say("1", "/done 1"))
+	assert.Equal(t, "Inbox:\n"+
+		"1. [ ] Buy milk (due Wed May 8 18:00) #home\n"+
+		"2. [ ] Call Ana (due Tue May 7 23:59)\n"+
+		"3. [ ] Revisar PR (due Wed May 8 09:30)\n"+
+		"4. [ ] Pagar luz (due Wed May 8 15:00)", say("1", "/list"))
+	assert.Equal(t, "Done: Revisar PR", say("1", "/done 3"))
+	assert.Equal(t, `Error: invalid number "5"`, say("1", "/done 5"))
+
+	// Cada chat tem as suas listas
+	assert.Equal(t, "You have no lists. Create one with /newlist <name>.", say("2", "/lists"))
+	assert.Equal(t, "Created list 1: Trabalho", say("2", "/newlist Trabalho"))
+	assert.Equal(t, "Trabalho is empty.", say("2", "/list"))
+	assert.Equal(t, "*1. Inbox (4 tasks)", say("1", "/lists"))
+
+	assert.Equal(t, "Created list 2: Casa", say("1", "/newlist Casa"))
+	assert.Equal(t, " 1. Inbox (4 tasks)\n*2. Casa (0 tasks)", say("1", "/lists"))
+	assert.Equal(t, "Selected Inbox", say("1", "/use 1"))
+	assert.Contains(t, say("1", "/list"), "3. [x] Revisar PR")
+
+	assert.Equal(t, "Error: unknown command /foo (try /help)", say("1", "/foo"))
+	assert.Equal(t, "Send /help to see the commands.", say("1", "oi"))
+
+	taskLists, err := s.ListTaskLists(ctx)
+	require.NoError(t, err)
+	require.Len(t, taskLists, 3)
+	tasks, err := s.GetTasksByTaskList(ctx, taskLists[0].ID)
+	require.NoError(t, err)
+	require.Len(t, tasks, 4)
+	assert.Equal(t, []string{"home"}, tasks[0].Tags)
+	assert.Equal(t, "chat:1", tasks[0].CreatedBy)
+
+	tr.Close()
+	require.NoError(t, <-done)
+}
+
+func TestBot_ChatsAreIndependentUnderConcurrency(t *testing.T) {
+	ctx := context.Background()
+	s := newMemoryService()
+	b := bot.New(s)
+
+	var wg sync.WaitGroup
+	for _, chatID := range []string{"a", "b", "c", "d"} {
+		chatID := chatID
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				r := b.Handle(ctx, bot.Message{ChatID: chatID, User: "user-" + chatID, Text: "/add tarefa"})
+				assert.Equal(t, chatID, r.ChatID)
+			}
+		}()
+	}
+	wg.Wait()
+
+	taskLists, err := s.ListTaskLists(ctx)
+	require.NoError(t, err)
+	require.Len(t, taskLists, 4, "one Inbox per chat")
+	for _, taskList := range taskLists {
+		assert.Len(t, taskList.Tasks, 20)
+	}
+}
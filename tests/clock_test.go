@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"botasks/internal/clock"
+	"botasks/internal/service"
+	"botasks/internal/task"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := clock.NewFake(start)
+	assert.Equal(t, start, c.Now())
+
+	c.Advance(time.Hour)
+	assert.Equal(t, start.Add(time.Hour), c.Now())
+
+	c.Set(start)
+	assert.Equal(t, start, c.Now())
+}
+
+func TestNewTaskWithClock(t *testing.T) {
+	c := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	// O prazo é exatamente o instante atual: ainda é válido
+	t1 := task.NewTask("Task 1", "", c.Now(), task.WithClock(c))
+	assert.NotNil(t, t1)
+
+	// Um nanossegundo antes do instante atual já é passado
+	t2 := task.NewTask("Task 2", "", c.Now().Add(-time.Nanosecond), task.WithClock(c))
+	assert.Nil(t, t2)
+
+	c.Advance(time.Nanosecond)
+	assert.True(t, t1.IsOverdue(c.Now()))
+}
+
+func TestAddTask_UsesServiceClock(t *testing.T) {
+	ctx := context.Background()
+	mockTaskListRepo := new(MockTaskListRepo)
+	mockTaskRepo := new(MockTaskRepo)
+	c := clock.NewFake(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := service.NewTaskListService(mockTaskListRepo, mockTaskRepo, service.WithClock(c))
+
+	// Prazo no futuro em relação ao relógio real, mas no passado para o relógio do serviço
+	_, err := s.AddTask(ctx, "list-id", "Task", "", time.Date(2029, 12, 31, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, service.ErrInvalidTask)
+	assert.EqualError(t, err, "invalid task parameters: deadline 2029-12-31 00:00 is in the past")
+
+	mockTaskRepo.On("Create", mock.Anything, mock.AnythingOfType("task.Task")).Return("task-id", nil)
+	mockTaskListRepo.On("AddTaskToList", mock.Anything, "task-id", "list-id").Return(nil)
+
+	taskID, err := s.AddTask(ctx, "list-id", "Task", "", c.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, "task-id", taskID)
+}
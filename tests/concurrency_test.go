@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"botasks/internal/list"
+	"botasks/internal/repository"
+	"botasks/internal/task"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPopulatedRepositories(tb testing.TB, tasks int) (*repository.MemoryTaskRepository, *repository.MemoryTaskListRepository) {
+	ctx := context.Background()
+	taskRepo := repository.NewMemoryTaskRepository()
+	taskListRepo := repository.NewMemoryTaskListRepository(taskRepo)
+
+	_, err := taskListRepo.Create(ctx, list.TaskList{ID: "l1", Name: "Lista"})
+	require.NoError(tb, err)
+	for i := 0; i < tasks; i++ {
+		taskID := fmt.Sprintf("t%d", i)
+		_, err := taskRepo.Create(ctx, task.Task{ID: taskID, Title: taskID})
+		require.NoError(tb, err)
+		require.NoError(tb, taskListRepo.AddTaskToList(ctx, taskID, "l1"))
+	}
+	return taskRepo, taskListRepo
+}
+
+// TestMemoryRepositories_ConcurrentStress mistura leituras e escritas em
+// paralelo. Deve ser executado com -race para detectar acessos concorrentes.
+func TestMemoryRepositories_ConcurrentStress(t *testing.T) {
+	ctx := context.Background()
+	taskRepo, taskListRepo := newPopulatedRepositories(t, 50)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				taskID := fmt.Sprintf("w%d-%d", w, i)
+				_, err := taskRepo.Create(ctx, task.Task{ID: taskID})
+				assert.NoError(t, err)
+				assert.NoError(t, taskListRepo.AddTaskToList(ctx, taskID, "l1"))
+				if i%2 == 0 {
+					assert.NoError(t, taskListRepo.RemoveTaskFromList(ctx, taskID, "l1"))
+				}
+				stored, err := taskListRepo.GetByID(ctx, "l1")
+				if assert.NoError(t, err) {
+					stored.Name = taskID
+					// Conflitos de versão são esperados entre escritores concorrentes
+					err := taskListRepo.Update(ctx, *stored)
+					if err != nil {
+						assert.ErrorIs(t, err, repository.ErrConflict)
+					}
+				}
+			}
+		}(w)
+	}
+	for r := 0; r < 8; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				tasks, err := taskListRepo.GetTasksByList(ctx, "l1")
+				assert.NoError(t, err)
+				assert.GreaterOrEqual(t, len(tasks), 50)
+				_, err = taskRepo.GetByID(ctx, "t0")
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	tasks, err := taskListRepo.GetTasksByList(ctx, "l1")
+	require.NoError(t, err)
+	assert.Len(t, tasks, 50+4*100)
+}
+
+// Os benchmarks abaixo medem o custo por operação com RunParallel. Para
+// avaliar o ganho com vários leitores, compare -cpu 1,N em uma máquina com
+// pelo menos N núcleos; com um só núcleo os resultados não dizem nada sobre
+// escalabilidade.
+func BenchmarkMemoryTaskListRepository_GetTasksByList(b *testing.B) {
+	ctx := context.Background()
+	_, taskListRepo := newPopulatedRepositories(b, 100)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := taskListRepo.GetTasksByList(ctx, "l1"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkMemoryTaskRepository_GetByID(b *testing.B) {
+	ctx := context.Background()
+	taskRepo, _ := newPopulatedRepositories(b, 100)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := taskRepo.GetByID(ctx, "t42"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkMemoryTaskListRepository_MixedReadWrite mede leituras paralelas
+// com uma escrita a cada 16 operações.
+func BenchmarkMemoryTaskListRepository_MixedReadWrite(b *testing.B) {
+	ctx := context.Background()
+	_, taskListRepo := newPopulatedRepositories(b, 100)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			i++
+			if i%16 == 0 {
+				if err := taskListRepo.AddTaskToList(ctx, "t0", "l1"); err != nil {
+					b.Fatal(err)
+				}
+				if err := taskListRepo.RemoveTaskFromList(ctx, "t0", "l1"); err != nil {
+					b.Fatal(err)
+				}
+				continue
+			}
+			if _, err := taskListRepo.GetTasksByList(ctx, "l1"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"botasks/internal/list"
+	"botasks/internal/repository"
+	"botasks/internal/service"
+	"botasks/internal/task"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRepositories_HonourCancellation(t *testing.T) {
+	taskRepo := repository.NewMemoryTaskRepository()
+	taskListRepo := repository.NewMemoryTaskListRepository(taskRepo)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := taskRepo.Create(ctx, task.Task{ID: "t1"})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = taskListRepo.Create(ctx, list.TaskList{ID: "l1"})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = taskListRepo.GetTasksByList(ctx, "l1")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// Nada foi gravado
+	_, err = taskRepo.GetByID(context.Background(), "t1")
+	assert.ErrorIs(t, err, repository.ErrTaskNotFound)
+}
+
+func TestService_CancelledContext(t *testing.T) {
+	s := newMemoryService()
+	taskListID, err := s.CreateTaskList(context.Background(), "Lista")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = s.AddTask(ctx, taskListID, "Tarefa", "", time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, context.Canceled)
+
+	tasks, err := s.GetTasksByTaskList(context.Background(), taskListID)
+	require.NoError(t, err)
+	assert.Empty(t, tasks)
+}
+
+func TestActorFromContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "", service.ActorFromContext(ctx))
+	assert.Equal(t, "alice", service.ActorFromContext(service.ContextWithActor(ctx, "alice")))
+}
@@ -0,0 +1,247 @@
+package tests
+
+import (
+	"botasks/internal/clock"
+	"botasks/internal/event"
+	"botasks/internal/service"
+	"botasks/internal/task"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder guarda os eventos recebidos por um handler.
+type recorder struct {
+	events []event.Event
+	mu     sync.Mutex
+}
+
+func (r *recorder) handle(_ context.Context, e event.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, e)
+}
+
+func (r *recorder) names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, len(r.events))
+	for i, e := range r.events {
+		names[i] = e.Name()
+	}
+	return names
+}
+
+func TestBus_SyncSubscriberAndUnsubscribe(t *testing.T) {
+	ctx := context.Background()
+	bus := event.NewBus()
+	rec := &recorder{}
+	unsubscribe := bus.Subscribe(rec.handle)
+
+	bus.Publish(ctx, event.TaskDeleted{Task: task.Task{ID: "t1"}})
+	unsubscribe()
+	bus.Publish(ctx, event.TaskDeleted{Task: task.Task{ID: "t2"}})
+
+	require.Len(t, rec.events, 1)
+	assert.Equal(t, "t1", rec.events[0].AggregateID())
+}
+
+func TestBus_PanicIsolation(t *testing.T) {
+	ctx := context.Background()
+	bus := event.NewBus()
+	var panics []interface{}
+	bus.OnPanic = func(_ event.Event, recovered interface{}) {
+		panics = append(panics, recovered)
+	}
+
+	rec := &recorder{}
+	bus.Subscribe(func(context.Context, event.Event) { panic("boom") })
+	bus.Subscribe(rec.handle)
+
+	bus.Publish(ctx, event.TaskDeleted{Task: task.Task{ID: "t1"}})
+
+	assert.Equal(t, []interface{}{"boom"}, panics)
+	assert.Len(t, rec.events, 1)
+}
+
+func TestBus_AsyncOrderingPerAggregate(t *testing.T) {
+	ctx := context.Background()
+	bus := event.NewBus()
+
+	seen := make(map[string][]string)
+	var mu sync.Mutex
+	bus.SubscribeAsync(func(_ context.Context, e event.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		updated := e.(event.TaskUpdated)
+		seen[e.AggregateID()] = append(seen[e.AggregateID()], updated.After.Title)
+	}, 4, 8)
+
+	for i := 0; i < 50; i++ {
+		for _, id := range []string{"a", "b", "c"} {
+			bus.Publish(ctx, event.TaskUpdated{After: task.Task{ID: id, Title: fmt.Sprint(i)}})
+		}
+	}
+	bus.Close()
+
+	for _, id := range []string{"a", "b", "c"} {
+		require.Len(t, seen[id], 50)
+		for i, title := range seen[id] {
+			assert.Equal(t, fmt.Sprint(i), title)
+		}
+	}
+}
+
+func TestBus_AsyncHandlerPublishDoesNotDeadlock(t *testing.T) {
+	ctx := context.Background()
+	bus := event.NewBus()
+	var dropped []error
+	var mu sync.Mutex
+	bus.OnDrop = func(_ event.Event, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		dropped = append(dropped, err)
+	}
+
+	// Com um worker e fila de 1, o handler republica na própria fila cheia
+	handled := 0
+	republished := make(chan struct{})
+	bus.SubscribeAsync(func(ctx context.Context, e event.Event) {
+		mu.Lock()
+		handled++
+		mu.Unlock()
+		if e.AggregateID() == "t1" {
+			for i := 0; i < 3; i++ {
+				bus.Publish(ctx, event.TaskDeleted{Task: task.Task{ID: "t2"}})
+			}
+			close(republished)
+		}
+	}, 1, 1)
+
+	bus.Publish(ctx, event.TaskDeleted{Task: task.Task{ID: "t1"}})
+	select {
+	case <-republished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("publish from an async handler deadlocked")
+	}
+	bus.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.NotEmpty(t, dropped)
+	for _, err := range dropped {
+		assert.ErrorIs(t, err, event.ErrReentrantPublish)
+	}
+	assert.Equal(t, 1+3-len(dropped), handled)
+}
+
+func TestBus_AsyncHandlerUnsubscribesInGoroutine(t *testing.T) {
+	ctx := context.Background()
+	bus := event.NewBus()
+	rec := &recorder{}
+
+	var unsubscribe func()
+	unsubscribed := make(chan struct{})
+	unsubscribe = bus.SubscribeAsync(func(ctx context.Context, e event.Event) {
+		rec.handle(ctx, e)
+		go func() {
+			unsubscribe()
+			close(unsubscribed)
+		}()
+	}, 2, 4)
+
+	bus.Publish(ctx, event.TaskDeleted{Task: task.Task{ID: "t1"}})
+	select {
+	case <-unsubscribed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("unsubscribe from an async handler deadlocked")
+	}
+	bus.Publish(ctx, event.TaskDeleted{Task: task.Task{ID: "t2"}})
+	bus.Close()
+
+	assert.Equal(t, []string{"task.deleted"}, rec.names())
+}
+
+func TestBus_UnsubscribeWhilePublisherWaitsDoesNotDeadlock(t *testing.T) {
+	ctx := context.Background()
+	bus := event.NewBus()
+
+	// O handler segura o único worker e depois publica com um contexto
+	// próprio, que espera vaga na fila
+	started := make(chan struct{})
+	release := make(chan struct{})
+	unsubscribe := bus.SubscribeAsync(func(_ context.Context, e event.Event) {
+		if e.AggregateID() != "t1" {
+			return
+		}
+		close(started)
+		<-release
+		bus.Publish(context.Background(), event.TaskDeleted{Task: task.Task{ID: "t4"}})
+	}, 1, 1)
+
+	bus.Publish(ctx, event.TaskDeleted{Task: task.Task{ID: "t1"}})
+	<-started
+	bus.Publish(ctx, event.TaskDeleted{Task: task.Task{ID: "t2"}})
+	go bus.Publish(ctx, event.TaskDeleted{Task: task.Task{ID: "t3"}})
+	time.Sleep(10 * time.Millisecond)
+
+	unsubscribed := make(chan struct{})
+	go func() {
+		unsubscribe()
+		close(unsubscribed)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	select {
+	case <-unsubscribed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("unsubscribe while a publisher waits deadlocked")
+	}
+	bus.Close()
+}
+
+func TestService_PublishesEvents(t *testing.T) {
+	ctx := service.ContextWithActor(context.Background(), "alice")
+	c := clock.NewFake(time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC))
+	bus := event.NewBus()
+	rec := &recorder{}
+	bus.Subscribe(rec.handle)
+	s := newMemoryService(service.WithClock(c), service.WithEventPublisher(bus))
+
+	taskListID, err := s.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+	taskID, err := s.AddTask(ctx, taskListID, "Lavar louça", "", c.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, s.UpdateTask(ctx, taskID, "Lavar a louça", "", time.Time{}))
+	require.NoError(t, s.CompleteTask(ctx, taskID))
+	require.NoError(t, s.UpdateTaskList(ctx, taskListID, "Lar"))
+	require.NoError(t, s.DeleteTaskList(ctx, taskListID))
+	require.NoError(t, s.DeleteTask(ctx, taskID))
+
+	// Operações que falham não publicam eventos
+	assert.Error(t, s.DeleteTask(ctx, taskID))
+
+	assert.Equal(t, []string{
+		"list.created", "task.created", "task.updated", "task.completed",
+		"list.renamed", "list.deleted", "task.deleted",
+	}, rec.names())
+
+	updated := rec.events[2].(event.TaskUpdated)
+	assert.Equal(t, "Lavar louça", updated.Before.Title)
+	assert.Equal(t, "Lavar a louça", updated.After.Title)
+	assert.Equal(t, 2, updated.After.Version)
+	assert.Equal(t, "alice", updated.Actor)
+	assert.Equal(t, c.Now(), updated.At)
+
+	deleted := rec.events[5].(event.ListDeleted)
+	require.Len(t, deleted.List.Tasks, 1)
+	assert.Equal(t, taskID, deleted.List.Tasks[0].ID)
+}
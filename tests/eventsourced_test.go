@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"botasks/internal/clock"
+	"botasks/internal/list"
+	"botasks/internal/repository"
+	"botasks/internal/repository/eventsourced"
+	"botasks/internal/repository/repotest"
+	"botasks/internal/task"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventSourcedRepositories_Contract(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) (repository.TaskRepository, repository.TaskListRepository) {
+		store, err := eventsourced.Open(context.Background(), eventsourced.NewMemoryLog())
+		require.NoError(t, err)
+		return store.Tasks(), store.TaskLists()
+	})
+}
+
+func TestEventSourcedStore_ReplayWithSnapshots(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "events.jsonl")
+	snapshots := eventsourced.NewFileSnapshotStore(filepath.Join(dir, "snapshot.json"))
+
+	log, err := eventsourced.OpenFileLog(logPath)
+	require.NoError(t, err)
+	store, err := eventsourced.Open(ctx, log, eventsourced.WithSnapshots(snapshots, 4))
+	require.NoError(t, err)
+
+	_, err = store.TaskLists().Create(ctx, list.TaskList{ID: "l1", Name: "Lista"})
+	require.NoError(t, err)
+	for _, id := range []string{"t1", "t2"} {
+		_, err := store.Tasks().Create(ctx, task.Task{ID: id, Title: id})
+		require.NoError(t, err)
+		require.NoError(t, store.TaskLists().AddTaskToList(ctx, id, "l1"))
+	}
+	require.NoError(t, store.Tasks().Delete(ctx, "t1"))
+	require.NoError(t, log.Close())
+
+	// O snapshot cobre os 4 primeiros dos 6 registros; os demais são reaplicados
+	snap, ok, err := snapshots.Latest(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, int64(4), snap.Seq)
+
+	log, err = eventsourced.OpenFileLog(logPath)
+	require.NoError(t, err)
+	defer log.Close()
+	reopened, err := eventsourced.Open(ctx, log, eventsourced.WithSnapshots(snapshots, 4))
+	require.NoError(t, err)
+
+	tasks, err := reopened.TaskLists().GetTasksByList(ctx, "l1")
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "t2", tasks[0].ID)
+	_, err = reopened.Tasks().GetByID(ctx, "t1")
+	assert.ErrorIs(t, err, repository.ErrTaskNotFound)
+}
+
+func TestEventSourcedFileLog_DropsTornLastRecord(t *testing.T) {
+	ctx := context.Background()
+	logPath := filepath.Join(t.TempDir(), "events.jsonl")
+
+	log, err := eventsourced.OpenFileLog(logPath)
+	require.NoError(t, err)
+	store, err := eventsourced.Open(ctx, log)
+	require.NoError(t, err)
+	_, err = store.Tasks().Create(ctx, task.Task{ID: "t1", Title: "Primeira"})
+	require.NoError(t, err)
+	require.NoError(t, log.Close())
+
+	// Uma queda no meio de Append deixa meio registro no fim do arquivo
+	good, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"Seq":2,"Type":"task.crea`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	log, err = eventsourced.OpenFileLog(logPath)
+	require.NoError(t, err)
+	store, err = eventsourced.Open(ctx, log)
+	require.NoError(t, err)
+	repaired, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Equal(t, good, repaired)
+
+	_, err = store.Tasks().Create(ctx, task.Task{ID: "t2", Title: "Segunda"})
+	require.NoError(t, err)
+	require.NoError(t, log.Close())
+
+	log, err = eventsourced.OpenFileLog(logPath)
+	require.NoError(t, err)
+	defer log.Close()
+	store, err = eventsourced.Open(ctx, log)
+	require.NoError(t, err)
+	tasks, err := store.Tasks().List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, tasks, 2)
+}
+
+func TestEventSourcedFileLog_RejectsCorruptionBeforeTheEnd(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "events.jsonl")
+	content := `{"Seq":1,"Type":"list.created","ListID":"l1","List":{"ID":"l1","Name":"Lista"}}` + "\n" +
+		`{"Seq":2,"Type":"task.crea` + "\n" +
+		`{"Seq":3,"Type":"list.deleted","ListID":"l1"}` + "\n"
+	require.NoError(t, os.WriteFile(logPath, []byte(content), 0o600))
+
+	_, err := eventsourced.OpenFileLog(logPath)
+	assert.ErrorContains(t, err, "corrupt record at offset")
+}
+
+func TestEventSourcedStore_AsOf(t *testing.T) {
+	ctx := context.Background()
+	monday := time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC)
+	c := clock.NewFake(monday)
+	store, err := eventsourced.Open(ctx, eventsourced.NewMemoryLog(), eventsourced.WithClock(c))
+	require.NoError(t, err)
+
+	_, err = store.TaskLists().Create(ctx, list.TaskList{ID: "l1", Name: "Semana"})
+	require.NoError(t, err)
+	_, err = store.Tasks().Create(ctx, task.Task{ID: "t1", Title: "Relatório"})
+	require.NoError(t, err)
+	require.NoError(t, store.TaskLists().AddTaskToList(ctx, "t1", "l1"))
+
+	c.Advance(48 * time.Hour)
+	stored, err := store.TaskLists().GetByID(ctx, "l1")
+	require.NoError(t, err)
+	stored.Name = "Semana (revisada)"
+	require.NoError(t, store.TaskLists().Update(ctx, *stored))
+	require.NoError(t, store.TaskLists().RemoveTaskFromList(ctx, "t1", "l1"))
+
+	view, err := store.AsOf(ctx, monday.Add(time.Hour))
+	require.NoError(t, err)
+	past, err := view.TaskList("l1")
+	require.NoError(t, err)
+	assert.Equal(t, "Semana", past.Name)
+	require.Len(t, past.Tasks, 1)
+	assert.Equal(t, "Relatório", past.Tasks[0].Title)
+
+	current, err := store.TaskLists().GetByID(ctx, "l1")
+	require.NoError(t, err)
+	assert.Equal(t, "Semana (revisada)", current.Name)
+	assert.Empty(t, current.Tasks)
+
+	view, err = store.AsOf(ctx, monday.Add(-time.Hour))
+	require.NoError(t, err)
+	_, err = view.TaskList("l1")
+	assert.ErrorIs(t, err, repository.ErrTaskListNotFound)
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"botasks/internal/clock"
+	"botasks/internal/httpapi"
+	"botasks/internal/service"
+	"botasks/internal/task"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getFeed(t *testing.T, h http.Handler, path string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestFeed_ListFeedWithConditionalRequests(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC))
+	s := newMemoryService(service.WithClock(clk))
+	casa, err := s.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+	lavar, err := s.AddTask(ctx, casa, "Lavar", "", clk.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	feeds := httpapi.NewMemoryFeedStore()
+	token, err := feeds.Issue(httpapi.Feed{ListID: casa})
+	require.NoError(t, err)
+	h := httpapi.NewHandler(s, httpapi.WithFeeds(feeds))
+
+	rec := getFeed(t, h, "/feeds/"+token+".ics", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/calendar; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "SUMMARY:Lavar\r\n")
+	etag := rec.Header().Get("ETag")
+	lastModified := rec.Header().Get("Last-Modified")
+	require.NotEmpty(t, etag)
+	assert.Equal(t, "Wed, 01 May 2024 09:00:00 GMT", lastModified)
+
+	// Nada mudou: o mesmo corpo e o mesmo ETag, mesmo com o relógio andando
+	clk.Advance(time.Minute)
+	rec = getFeed(t, h, "/feeds/"+token+".ics", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	rec = getFeed(t, h, "/feeds/"+token+".ics", http.Header{"If-Modified-Since": {lastModified}})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	require.NoError(t, s.CompleteTask(ctx, lavar))
+	rec = getFeed(t, h, "/feeds/"+token+".ics", http.Header{"If-None-Match": {etag}})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+	assert.Contains(t, rec.Body.String(), "STATUS:COMPLETED\r\n")
+
+	feeds.Revoke(token)
+	rec = getFeed(t, h, "/feeds/"+token+".ics", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestFeed_TagFeedAcrossLists(t *testing.T) {
+	ctx := context.Background()
+	s := newMemoryService()
+	casa, err := s.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+	trabalho, err := s.CreateTaskList(ctx, "Trabalho")
+	require.NoError(t, err)
+	for _, it := range []struct {
+		list, title string
+		tags        []string
+	}{
+		{casa, "Ligar para o encanador", []string{"telefone"}},
+		{casa, "Varrer", nil},
+		{trabalho, "Ligar para o cliente", []string{"telefone", "urgente"}},
+	} {
+		_, err := s.ImportTask(ctx, task.Task{Title: it.title, Tags: it.tags}, it.list)
+		require.NoError(t, err)
+	}
+
+	feeds := httpapi.NewMemoryFeedStore()
+	token, err := feeds.Issue(httpapi.Feed{Tag: "telefone"})
+	require.NoError(t, err)
+	h := httpapi.NewHandler(s, httpapi.WithFeeds(feeds))
+
+	rec := getFeed(t, h, "/feeds/"+token+".ics", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Equal(t, 2, strings.Count(body, "BEGIN:VTODO"))
+	assert.Contains(t, body, "X-WR-CALNAME:#telefone")
+	assert.NotContains(t, body, "Varrer")
+
+	assert.Equal(t, http.StatusNotFound, getFeed(t, h, "/feeds/wrong.ics", nil).Code)
+	assert.Equal(t, http.StatusNotFound, getFeed(t, h, "/feeds/"+token, nil).Code)
+	_, err = feeds.Issue(httpapi.Feed{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"botasks/internal/clock"
+	"botasks/internal/ical"
+	"botasks/internal/service"
+	"botasks/internal/task"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestICal_WriteFoldsAndEscapes(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cal := ical.Calendar{Name: "Casa", Tasks: []task.Task{{
+		ID:          "t1",
+		Title:       "Comprar pão, leite; café",
+		Description: strings.Repeat("descrição longa ", 8) + "\nsegunda linha",
+		Deadline:    time.Date(2024, 5, 3, 18, 30, 0, 0, time.UTC),
+		Priority:    "B",
+		Tags:        []string{"mercado", "a,b"},
+	}}}
+
+	var buf bytes.Buffer
+	require.NoError(t, ical.Write(&buf, cal, now))
+	out := buf.String()
+
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75, line)
+	}
+	assert.Contains(t, out, "SUMMARY:Comprar pão\\, leite\\; café\r\n")
+	assert.Contains(t, out, "DUE:20240503T183000Z\r\n")
+	assert.Contains(t, out, "STATUS:NEEDS-ACTION\r\n")
+	assert.Contains(t, out, "PRIORITY:2\r\n")
+	assert.Contains(t, out, "CATEGORIES:mercado,a\\,b\r\n")
+
+	read, err := ical.Read(&buf, now)
+	require.NoError(t, err)
+	assert.Equal(t, "Casa", read.Name)
+	require.Len(t, read.Tasks, 1)
+	got := read.Tasks[0]
+	assert.Equal(t, cal.Tasks[0].Title, got.Title)
+	assert.Equal(t, cal.Tasks[0].Description, got.Description)
+	assert.True(t, cal.Tasks[0].Deadline.Equal(got.Deadline))
+	assert.Equal(t, "B", got.Priority)
+	assert.Equal(t, []string{"mercado", "a,b"}, got.Tags)
+	assert.Empty(t, got.ID)
+}
+
+func TestICal_ReadFromOtherTools(t *testing.T) {
+	input := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Outro//App//EN",
+		"BEGIN:VEVENT",
+		"SUMMARY:Reunião",
+		"END:VEVENT",
+		"BEGIN:VTODO",
+		"UID:abc@outro",
+		"SUMMARY:Pagar con",
+		" ta de luz",
+		"DUE;VALUE=DATE:20240510",
+		"STATUS:COMPLETED",
+		"BEGIN:VALARM",
+		"SUMMARY:Alarme",
+		"END:VALARM",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"SUMMARY:Com fuso",
+		`DUE;TZID="America/Sao_Paulo":20240510T090000`,
+		"END:VTODO",
+		"END:VCALENDAR",
+	}, "\n")
+	now := time.Date(2024, 5, 20, 8, 0, 0, 0, time.UTC)
+
+	cal, err := ical.Read(strings.NewReader(input), now)
+	require.NoError(t, err)
+	require.Len(t, cal.Tasks, 2)
+
+	assert.Equal(t, "Pagar conta de luz", cal.Tasks[0].Title)
+	assert.Equal(t, time.Date(2024, 5, 10, 0, 0, 0, 0, time.Local), cal.Tasks[0].Deadline)
+	assert.Equal(t, now, cal.Tasks[0].CompletedAt)
+	assert.Equal(t, "Com fuso", cal.Tasks[1].Title)
+	assert.False(t, cal.Tasks[1].IsCompleted())
+	if sp, err := time.LoadLocation("America/Sao_Paulo"); err == nil {
+		assert.True(t, time.Date(2024, 5, 10, 9, 0, 0, 0, sp).Equal(cal.Tasks[1].Deadline))
+	}
+
+	_, err = ical.Read(strings.NewReader("BEGIN:VCALENDAR\nBEGIN:VTODO\nEND:VCALENDAR\n"), now)
+	assert.Error(t, err)
+}
+
+func TestICal_ExportImportList(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC))
+	source := newMemoryService(service.WithClock(clk))
+
+	casa, err := source.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+	lavar, err := source.AddTask(ctx, casa, "Lavar", "A louça toda", clk.Now().Add(48*time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, source.CompleteTask(ctx, lavar))
+	_, err = source.AddTask(ctx, casa, "Varrer", "", clk.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, ical.ExportList(ctx, source, casa, &buf, clk.Now()))
+
+	target := newMemoryService(service.WithClock(clk))
+	taskListID, n, err := ical.ImportList(ctx, target, &buf, "", clk.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	imported, err := target.GetTaskList(ctx, taskListID)
+	require.NoError(t, err)
+	assert.Equal(t, "Casa", imported.Name)
+	require.Len(t, imported.Tasks, 2)
+	assert.Equal(t, "A louça toda", imported.Tasks[0].Description)
+	assert.True(t, imported.Tasks[0].IsCompleted())
+	assert.True(t, clk.Now().Add(48*time.Hour).Equal(imported.Tasks[0].Deadline))
+	assert.False(t, imported.Tasks[1].IsCompleted())
+}
+
+func TestICal_ImportListIsAllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC))
+	input := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"X-WR-CALNAME:Casa",
+		"BEGIN:VTODO", "UID:1", "SUMMARY:Lavar", "END:VTODO",
+		"BEGIN:VTODO", "UID:2", "SUMMARY:Varrer", "END:VTODO",
+		"END:VCALENDAR", "",
+	}, "\r\n")
+
+	target := &failingImports{TaskListService: newMemoryService(service.WithClock(clk)), failAt: 2}
+	_, _, err := ical.ImportList(ctx, target, strings.NewReader(input), "", clk.Now())
+	assert.ErrorContains(t, err, "disk full")
+
+	lists, err := target.ListTaskLists(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, lists)
+}
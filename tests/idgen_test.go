@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"botasks/internal/clock"
+	"botasks/internal/idgen"
+	"botasks/internal/list"
+	"botasks/internal/service"
+	"botasks/internal/task"
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSequentialGenerator(t *testing.T) {
+	g := idgen.NewSequential("task-")
+	assert.Equal(t, "task-1", g.NewID())
+	assert.Equal(t, "task-2", g.NewID())
+}
+
+func TestUUIDGenerators(t *testing.T) {
+	v4 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	v7 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	assert.Regexp(t, v4, idgen.UUIDv4{}.NewID())
+
+	c := clock.NewFake(time.UnixMilli(0x0123456789ab))
+	id := idgen.UUIDv7{Clock: c}.NewID()
+	assert.Regexp(t, v7, id)
+	assert.Equal(t, "01234567-89ab", id[:13])
+}
+
+func TestULIDGenerator(t *testing.T) {
+	c := clock.NewFake(time.UnixMilli(1469918176385))
+	id := idgen.ULID{Clock: c}.NewID()
+
+	assert.Len(t, id, 26)
+	// Timestamp do exemplo da especificação do ULID
+	assert.Equal(t, "01ARYZ6S41", id[:10])
+
+	// IDs gerados em milissegundos posteriores ordenam depois
+	c.Advance(time.Millisecond)
+	assert.Greater(t, idgen.ULID{Clock: c}.NewID(), id)
+}
+
+func TestNewTaskAndListWithIDGenerator(t *testing.T) {
+	g := idgen.NewSequential("id-")
+
+	newTask := task.NewTask("Task", "", time.Now().Add(time.Hour), task.WithIDGenerator(g))
+	newList := list.CreateTaskList("List", list.WithIDGenerator(g))
+
+	assert.Equal(t, "id-1", newTask.ID)
+	assert.Equal(t, "id-2", newList.ID)
+}
+
+func TestServiceWithIDGenerator(t *testing.T) {
+	ctx := context.Background()
+	mockTaskListRepo := new(MockTaskListRepo)
+	mockTaskRepo := new(MockTaskRepo)
+	s := service.NewTaskListService(mockTaskListRepo, mockTaskRepo, service.WithIDGenerator(idgen.NewSequential("list-")))
+
+	mockTaskListRepo.On("Create", mock.Anything, mock.MatchedBy(func(l list.TaskList) bool {
+		return l.ID == "list-1"
+	})).Return("list-1", nil)
+
+	taskListID, err := s.CreateTaskList(ctx, "Lista de Tarefas")
+	assert.NoError(t, err)
+	assert.Equal(t, "list-1", taskListID)
+	mockTaskListRepo.AssertExpectations(t)
+}
@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"botasks/internal/clock"
+	"botasks/internal/markdown"
+	"botasks/internal/service"
+	"botasks/internal/task"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdown_ParseMeetingNotes(t *testing.T) {
+	input := strings.Join([]string{
+		"## Reunião de 2024-05-01 ##",
+		"",
+		"Participantes: Ana, Bruno",
+		"",
+		"- [ ] Preparar o orçamento — due 2024-05-10",
+		"  Incluir a reforma.",
+		"  - [x] Pedir cotações -- due 2024-05-05 14:30",
+		"\t- [ ] Comparar preços",
+		"  Depois das cotações.",
+		"- Tópico sem checkbox",
+		"* [X] Enviar a ata",
+		"- [ ]   ",
+	}, "\n")
+	now := time.Date(2024, 5, 1, 18, 0, 0, 0, time.Local)
+
+	c, err := markdown.Parse(strings.NewReader(input), now)
+	require.NoError(t, err)
+	assert.Equal(t, "Reunião de 2024-05-01", c.Title)
+	require.Len(t, c.Items, 4)
+
+	assert.Equal(t, "Preparar o orçamento", c.Items[0].Task.Title)
+	assert.Equal(t, day(2024, 5, 10), c.Items[0].Task.Deadline)
+	assert.Equal(t, "Incluir a reforma.\nDepois das cotações.", c.Items[0].Task.Description)
+	assert.Equal(t, -1, c.Items[0].Parent)
+
+	assert.Equal(t, "Pedir cotações", c.Items[1].Task.Title)
+	assert.Equal(t, time.Date(2024, 5, 5, 14, 30, 0, 0, time.Local), c.Items[1].Task.Deadline)
+	assert.Equal(t, now, c.Items[1].Task.CompletedAt)
+	assert.Equal(t, 0, c.Items[1].Parent)
+
+	assert.Equal(t, "Comparar preços", c.Items[2].Task.Title)
+	assert.Equal(t, 1, c.Items[2].Parent)
+
+	assert.Equal(t, "Enviar a ata", c.Items[3].Task.Title)
+	assert.True(t, c.Items[3].Task.IsCompleted())
+	assert.Equal(t, -1, c.Items[3].Parent)
+}
+
+func TestMarkdown_ExportImportWithSubtasks(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 9, 0, 0, 0, time.Local))
+	source := newMemoryService(service.WithClock(clk))
+	casa, err := source.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+
+	cozinha, err := source.ImportTask(ctx, task.Task{Title: "Arrumar a cozinha", Deadline: day(2024, 5, 10)}, casa)
+	require.NoError(t, err)
+	_, err = source.ImportTask(ctx, task.Task{Title: "Lavar a louça", ParentID: cozinha, CompletedAt: clk.Now()}, casa)
+	require.NoError(t, err)
+	_, err = source.ImportTask(ctx, task.Task{Title: "Varrer", ParentID: cozinha, Description: "Embaixo da mesa também."}, casa)
+	require.NoError(t, err)
+	_, err = source.ImportTask(ctx, task.Task{Title: "Órfã", ParentID: "outra-lista"}, casa)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, markdown.ExportList(ctx, source, casa, &buf))
+	want := strings.Join([]string{
+		"# Casa",
+		"",
+		"- [ ] Arrumar a cozinha — due 2024-05-10",
+		"  - [x] Lavar a louça",
+		"  - [ ] Varrer",
+		"    Embaixo da mesa também.",
+		"- [ ] Órfã",
+		"",
+	}, "\n")
+	assert.Equal(t, want, buf.String())
+
+	target := newMemoryService(service.WithClock(clk))
+	taskListID, n, err := markdown.ImportList(ctx, target, strings.NewReader(buf.String()), "", clk.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+
+	imported, err := target.GetTaskList(ctx, taskListID)
+	require.NoError(t, err)
+	assert.Equal(t, "Casa", imported.Name)
+	require.Len(t, imported.Tasks, 4)
+	assert.Equal(t, imported.Tasks[0].ID, imported.Tasks[1].ParentID)
+	assert.Equal(t, imported.Tasks[0].ID, imported.Tasks[2].ParentID)
+	assert.Empty(t, imported.Tasks[3].ParentID)
+
+	var again bytes.Buffer
+	require.NoError(t, markdown.ExportList(ctx, target, taskListID, &again))
+	assert.Equal(t, want, again.String())
+}
+
+func TestMarkdown_ImportListIsAllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC))
+	input := "# Casa\n\n- [ ] Arrumar a cozinha\n  - [ ] Lavar a louça\n  - [ ] Varrer\n"
+
+	target := &failingImports{TaskListService: newMemoryService(service.WithClock(clk)), failAt: 3}
+	_, _, err := markdown.ImportList(ctx, target, strings.NewReader(input), "", clk.Now())
+	assert.ErrorContains(t, err, "disk full")
+
+	lists, err := target.ListTaskLists(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, lists)
+}
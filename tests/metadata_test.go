@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"botasks/internal/clock"
+	"botasks/internal/repository"
+	"botasks/internal/service"
+	"botasks/internal/task"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMemoryService(opts ...service.Option) *service.TaskListService {
+	taskRepo := repository.NewMemoryTaskRepository()
+	taskListRepo := repository.NewMemoryTaskListRepository(taskRepo)
+	return service.NewTaskListService(taskListRepo, taskRepo, opts...)
+}
+
+func TestServiceStampsMetadata(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	c := clock.NewFake(start)
+	s := newMemoryService(service.WithClock(c))
+
+	taskListID, err := s.CreateTaskList(service.ContextWithActor(ctx, "alice"), "Compras")
+	require.NoError(t, err)
+	taskID, err := s.AddTask(service.ContextWithActor(ctx, "alice"), taskListID, "Leite", "", start.Add(24*time.Hour))
+	require.NoError(t, err)
+
+	c.Advance(time.Hour)
+	require.NoError(t, s.UpdateTaskList(service.ContextWithActor(ctx, "bob"), taskListID, "Mercado"))
+	require.NoError(t, s.UpdateTask(service.ContextWithActor(ctx, "bob"), taskID, "Leite integral", "", time.Time{}))
+
+	taskList, err := s.GetTaskList(ctx, taskListID)
+	require.NoError(t, err)
+	assert.Equal(t, start, taskList.CreatedAt)
+	assert.Equal(t, start.Add(time.Hour), taskList.UpdatedAt)
+	assert.Equal(t, "alice", taskList.CreatedBy)
+	assert.Equal(t, "bob", taskList.UpdatedBy)
+
+	c.Advance(time.Hour)
+	require.NoError(t, s.CompleteTask(service.ContextWithActor(ctx, "carol"), taskID))
+
+	tasks, err := s.GetTasksByTaskList(ctx, taskListID)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "Leite integral", tasks[0].Title)
+	assert.Equal(t, start, tasks[0].CreatedAt)
+	assert.Equal(t, start.Add(2*time.Hour), tasks[0].UpdatedAt)
+	assert.Equal(t, start.Add(2*time.Hour), tasks[0].CompletedAt)
+	assert.Equal(t, "alice", tasks[0].CreatedBy)
+	assert.Equal(t, "carol", tasks[0].UpdatedBy)
+	assert.True(t, tasks[0].IsCompleted())
+}
+
+func TestQueryTasks(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	c := clock.NewFake(start)
+	s := newMemoryService(service.WithClock(c))
+
+	taskListID, err := s.CreateTaskList(ctx, "Trabalho")
+	require.NoError(t, err)
+
+	var ids []string
+	for _, actor := range []string{"alice", "bob", "alice"} {
+		id, err := s.AddTask(service.ContextWithActor(ctx, actor), taskListID, "Tarefa de "+actor, "", start.Add(48*time.Hour))
+		require.NoError(t, err)
+		ids = append(ids, id)
+		c.Advance(time.Minute)
+	}
+	require.NoError(t, s.CompleteTask(ctx, ids[0]))
+
+	byAlice, err := s.QueryTasks(ctx, taskListID, task.Query{CreatedBy: "alice", SortBy: task.SortByCreatedAt, Descending: true})
+	require.NoError(t, err)
+	require.Len(t, byAlice, 2)
+	assert.Equal(t, ids[2], byAlice[0].ID)
+	assert.Equal(t, ids[0], byAlice[1].ID)
+
+	open := false
+	pending, err := s.QueryTasks(ctx, taskListID, task.Query{Completed: &open, CreatedAfter: start})
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, ids[1], pending[0].ID)
+	assert.Equal(t, ids[2], pending[1].ID)
+}
@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"botasks/internal/clock"
+	"botasks/internal/migrate"
+	"botasks/internal/service"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const taskwarriorExport = `[
+{"id":1,"uuid":"a1","description":"Pagar luz","status":"pending","entry":"20240501T120000Z","due":"20240510T000000Z","project":"Casa","tags":["contas"],"priority":"H","urgency":9.1,"annotations":[{"entry":"20240502T080000Z","description":"Boleto no email"}]},
+{"id":0,"uuid":"a2","description":"Lavar o carro","status":"completed","entry":"20240401T120000Z","end":"20240403T150000Z","project":"Casa"},
+{"id":0,"uuid":"a3","description":"Apagada","status":"deleted","entry":"20240401T120000Z"},
+{"id":2,"uuid":"a4","description":"Ler","status":"waiting","entry":"20240401T120000Z","wait":"20240601T000000Z","depends":"a1"}
+]`
+
+func TestMigrate_ReadTaskwarrior(t *testing.T) {
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	res, err := migrate.ReadTaskwarrior(strings.NewReader(taskwarriorExport), now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Skipped)
+	assert.Equal(t, []string{"task.depends", "task.wait"}, res.Unmapped)
+
+	require.Len(t, res.Lists, 2)
+	casa := res.Lists[0]
+	assert.Equal(t, "Casa", casa.List.Name)
+	require.Len(t, casa.Items, 2)
+
+	luz := casa.Items[0].Task
+	assert.Equal(t, "Pagar luz", luz.Title)
+	assert.Equal(t, "Boleto no email", luz.Description)
+	assert.Equal(t, "A", luz.Priority)
+	assert.Equal(t, []string{"contas"}, luz.Tags)
+	assert.Equal(t, time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC), luz.Deadline)
+	assert.Equal(t, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), luz.CreatedAt)
+	assert.Equal(t, "a1", luz.Metadata["taskwarrior.uuid"])
+	assert.False(t, luz.IsCompleted())
+
+	assert.Equal(t, time.Date(2024, 4, 3, 15, 0, 0, 0, time.UTC), casa.Items[1].Task.CompletedAt)
+
+	assert.Equal(t, "Taskwarrior", res.Lists[1].List.Name)
+	require.Len(t, res.Lists[1].Items, 1)
+	assert.Equal(t, "Ler", res.Lists[1].Items[0].Task.Title)
+
+	// Versões antigas exportam um objeto por linha
+	lines := `{"uuid":"b1","description":"Um","status":"pending"}
+{"uuid":"b2","description":"Dois","status":"pending"}`
+	res, err = migrate.ReadTaskwarrior(strings.NewReader(lines), now)
+	require.NoError(t, err)
+	require.Len(t, res.Lists, 1)
+	assert.Len(t, res.Lists[0].Items, 2)
+}
+
+const trelloExport = `{
+"id":"board1","name":"Mudança","prefs":{"background":"blue"},
+"lists":[
+	{"id":"l2","name":"Feito","closed":false,"pos":2},
+	{"id":"l1","name":"A fazer","closed":false,"pos":1},
+	{"id":"l3","name":"Antiga","closed":true,"pos":3}
+],
+"cards":[
+	{"id":"c2","name":"Contratar frete","idList":"l1","pos":2,"closed":false,"labels":[]},
+	{"id":"c1","name":"Encaixotar livros","desc":"Caixas na garagem","idList":"l1","pos":1,"closed":false,
+	 "due":"2024-05-10T15:00:00.000Z","url":"https://trello.com/c/c1","idMembers":["m1"],
+	 "labels":[{"name":"casa","color":"green"},{"name":"","color":"red"}]},
+	{"id":"c3","name":"Avisar o condomínio","idList":"l2","pos":1,"closed":false,
+	 "due":"2024-04-20T12:00:00.000Z","dueComplete":true,"dateLastActivity":"2024-04-19T10:00:00.000Z"},
+	{"id":"c4","name":"Arquivado","idList":"l1","pos":3,"closed":true},
+	{"id":"c5","name":"Na lista arquivada","idList":"l3","pos":1,"closed":false}
+],
+"checklists":[
+	{"id":"k1","idCard":"c1","name":"Passos","pos":1,"checkItems":[
+		{"id":"i2","name":"Fechar caixas","state":"incomplete","pos":2},
+		{"id":"i1","name":"Separar livros","state":"complete","pos":1}
+	]}
+]
+}`
+
+func TestMigrate_ReadTrelloAndImport(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC))
+
+	res, err := migrate.ReadTrello(strings.NewReader(trelloExport), clk.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 3, res.Skipped)
+	assert.Equal(t, []string{"board.prefs", "card.idMembers"}, res.Unmapped)
+
+	require.Len(t, res.Lists, 2)
+	aFazer := res.Lists[0]
+	assert.Equal(t, "A fazer", aFazer.List.Name)
+	assert.Equal(t, "board1", aFazer.List.Metadata["trello.board_id"])
+	assert.Equal(t, "l1", aFazer.List.Metadata["trello.list_id"])
+	require.Len(t, aFazer.Items, 4)
+
+	livros := aFazer.Items[0]
+	assert.Equal(t, "Encaixotar livros", livros.Task.Title)
+	assert.Equal(t, "Caixas na garagem", livros.Task.Description)
+	assert.Equal(t, []string{"casa", "red"}, livros.Task.Tags)
+	assert.Equal(t, time.Date(2024, 5, 10, 15, 0, 0, 0, time.UTC), livros.Task.Deadline)
+	assert.Equal(t, "c1", livros.Task.Metadata["trello.card_id"])
+	assert.Equal(t, "https://trello.com/c/c1", livros.Task.Metadata["trello.url"])
+
+	assert.Equal(t, "Separar livros", aFazer.Items[1].Task.Title)
+	assert.Equal(t, 0, aFazer.Items[1].Parent)
+	assert.Equal(t, clk.Now(), aFazer.Items[1].Task.CompletedAt)
+	assert.Equal(t, "Fechar caixas", aFazer.Items[2].Task.Title)
+	assert.False(t, aFazer.Items[2].Task.IsCompleted())
+	assert.Equal(t, "Contratar frete", aFazer.Items[3].Task.Title)
+	assert.Equal(t, -1, aFazer.Items[3].Parent)
+
+	avisar := res.Lists[1].Items[0].Task
+	assert.Equal(t, time.Date(2024, 4, 19, 10, 0, 0, 0, time.UTC), avisar.CompletedAt)
+
+	s := newMemoryService(service.WithClock(clk))
+	taskListIDs, n, err := migrate.Import(ctx, s, res)
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	require.Len(t, taskListIDs, 2)
+
+	imported, err := s.GetTaskList(ctx, taskListIDs[0])
+	require.NoError(t, err)
+	assert.Equal(t, "A fazer", imported.Name)
+	assert.Equal(t, "l1", imported.Metadata["trello.list_id"])
+	require.Len(t, imported.Tasks, 4)
+	assert.Equal(t, imported.Tasks[0].ID, imported.Tasks[1].ParentID)
+	assert.Equal(t, imported.Tasks[0].ID, imported.Tasks[2].ParentID)
+	assert.Empty(t, imported.Tasks[3].ParentID)
+	assert.Equal(t, "c1", imported.Tasks[0].Metadata["trello.card_id"])
+}
+
+func TestMigrate_UntitledItemsAreSkipped(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC))
+	input := `[
+{"uuid":"a1","description":"Pagar luz","status":"pending","project":"Casa"},
+{"uuid":"a2","description":"","status":"pending","project":"Casa"},
+{"uuid":"a3","description":"  ","status":"completed","project":"Trabalho"},
+{"uuid":"a4","description":"Lavar o carro","status":"pending","project":"Casa"}
+]`
+	res, err := migrate.ReadTaskwarrior(strings.NewReader(input), clk.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 2, res.Skipped)
+
+	s := newMemoryService(service.WithClock(clk))
+	taskListIDs, n, err := migrate.Import(ctx, s, res)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	require.Len(t, taskListIDs, 1)
+
+	board := `{"id":"b1","lists":[{"id":"l1","name":"A fazer","pos":1}],
+"cards":[
+	{"id":"c1","name":"","idList":"l1","pos":1},
+	{"id":"c2","name":"Contratar frete","idList":"l1","pos":2}
+],
+"checklists":[
+	{"id":"k1","idCard":"c1","pos":1,"checkItems":[{"id":"i1","name":"Ligar","state":"incomplete","pos":1}]},
+	{"id":"k2","idCard":"c2","pos":1,"checkItems":[{"id":"i2","name":"","state":"incomplete","pos":1}]}
+]}`
+	res, err = migrate.ReadTrello(strings.NewReader(board), clk.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 3, res.Skipped)
+	require.Len(t, res.Lists, 1)
+	require.Len(t, res.Lists[0].Items, 1)
+	assert.Equal(t, "Contratar frete", res.Lists[0].Items[0].Task.Title)
+
+	// Uma falha no meio não deixa listas nem tarefas para trás
+	res, err = migrate.ReadTaskwarrior(strings.NewReader(input), clk.Now())
+	require.NoError(t, err)
+	target := &failingImports{TaskListService: newMemoryService(service.WithClock(clk)), failAt: 2}
+	_, _, err = migrate.Import(ctx, target, res)
+	assert.ErrorContains(t, err, "disk full")
+	lists, err := target.ListTaskLists(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, lists)
+}
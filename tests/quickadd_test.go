@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"botasks/internal/clock"
+	"botasks/internal/httpapi"
+	"botasks/internal/ical"
+	"botasks/internal/quickadd"
+	"botasks/internal/service"
+	"botasks/internal/task"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuickAdd_Parse(t *testing.T) {
+	// Terça-feira, 7 de maio de 2024, 15:00
+	now := time.Date(2024, 5, 7, 15, 0, 0, 0, time.UTC)
+	day := func(d int, hour, minute int) time.Time {
+		return time.Date(2024, 5, d, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		text       string
+		title      string
+		deadline   time.Time
+		recurrence string
+		priority   string
+		tags       []string
+	}{
+		{"Pay rent every month on the 5th !high #finance", "Pay rent", time.Date(2024, 6, 5, 23, 59, 0, 0, time.UTC), "FREQ=MONTHLY;BYMONTHDAY=5", "A", []string{"finance"}},
+		{"revisar PR amanhã às 10h", "revisar PR", day(8, 10, 0), "", "", nil},
+		{"Call mom on friday at 3pm", "Call mom", day(10, 15, 0), "", "", nil},
+		{"Reunião na segunda 9h30 #trabalho", "Reunião", day(13, 9, 30), "", "", []string{"trabalho"}},
+		{"pedir segunda via do boleto", "pedir segunda via do boleto", time.Time{}, "", "", nil},
+		{"Standup every weekday at 9:15", "Standup", day(8, 9, 15), "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", "", nil},
+		{"Gym every monday and friday", "Gym", day(10, 23, 59), "FREQ=WEEKLY;BYDAY=MO,FR", "", nil},
+		{"lixo todas as terças 20h", "lixo", day(7, 20, 0), "FREQ=WEEKLY;BYDAY=TU", "", nil},
+		{"regar plantas a cada 3 dias", "regar plantas", day(7, 23, 59), "FREQ=DAILY;INTERVAL=3", "", nil},
+		{"pagar condomínio todo dia 10 !alta", "pagar condomínio", day(10, 23, 59), "FREQ=MONTHLY;BYMONTHDAY=10", "A", nil},
+		{"Dentist May 3 at 10am", "Dentist", time.Date(2025, 5, 3, 10, 0, 0, 0, time.UTC), "", "", nil},
+		{"aniversário da Ana 10 de junho", "aniversário da Ana", time.Date(2024, 6, 10, 23, 59, 0, 0, time.UTC), "", "", nil},
+		{"remind me in 2 hours", "remind me", day(7, 17, 0), "", "", nil},
+		{"estudar daqui a uma semana", "estudar", day(14, 23, 59), "", "", nil},
+		{"ir ao mercado hoje à noite", "ir ao mercado", day(7, 20, 0), "", "", nil},
+		{"Review 2024-06-01 18:00 !b", "Review", time.Date(2024, 6, 1, 18, 0, 0, 0, time.UTC), "", "B", nil},
+		{"Read chapter 3 at noon", "Read chapter 3", day(8, 12, 0), "", "", nil},
+		{"10 pushups", "10 pushups", time.Time{}, "", "", nil},
+		// Um número sozinho depois de "at" ou "até" só é hora se o resto do
+		// texto for só data, tags ou prioridade
+		{"Look at 3 options", "Look at 3 options", time.Time{}, "", "", nil},
+		{"I ate 2 apples", "I ate 2 apples", time.Time{}, "", "", nil},
+		{"responder até 2 emails", "responder até 2 emails", time.Time{}, "", "", nil},
+		{"Call mom at 5 #family", "Call mom", day(8, 5, 0), "", "", []string{"family"}},
+		{"Call mom at 10 tomorrow", "Call mom", day(8, 10, 0), "", "", nil},
+		{"ligar às 10 amanhã", "ligar", day(8, 10, 0), "", "", nil},
+		{"meeting at 9 on friday", "meeting", day(10, 9, 0), "", "", nil},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.text, func(t *testing.T) {
+			got := quickadd.Parse(tt.text, now)
+			assert.Equal(t, tt.title, got.Title)
+			assert.True(t, tt.deadline.Equal(got.Deadline), "deadline %v, want %v", got.Deadline, tt.deadline)
+			assert.Equal(t, tt.recurrence, got.Recurrence)
+			assert.Equal(t, tt.priority, got.Priority)
+			assert.Equal(t, tt.tags, got.Tags)
+		})
+	}
+}
+
+func TestQuickAdd_OptionsAndDefaultDeadline(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 7, 15, 0, 0, 0, time.UTC))
+	s := newMemoryService(service.WithClock(clk))
+	taskListID, err := s.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+
+	q := quickadd.Parse("Pay rent every month on the 5th !high #finance", clk.Now())
+	taskID, err := s.AddTask(ctx, taskListID, q.Title, "", q.DeadlineOr(clk.Now().Add(24*time.Hour)), q.Options()...)
+	require.NoError(t, err)
+	got, err := s.GetTask(ctx, taskID)
+	require.NoError(t, err)
+	assert.Equal(t, "A", got.Priority)
+	assert.Equal(t, []string{"finance"}, got.Tags)
+	assert.Equal(t, "FREQ=MONTHLY;BYMONTHDAY=5", got.Recurrence)
+
+	def := clk.Now().Add(24 * time.Hour)
+	assert.Equal(t, def, quickadd.Parse("Pagar luz", clk.Now()).DeadlineOr(def))
+}
+
+func TestICal_Recurrence(t *testing.T) {
+	now := time.Date(2024, 5, 7, 15, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	cal := ical.Calendar{Tasks: []task.Task{{
+		ID: "t1", Title: "Pay rent", Deadline: now, Recurrence: "FREQ=MONTHLY;BYMONTHDAY=5",
+	}}}
+	require.NoError(t, ical.Write(&buf, cal, now))
+	assert.Contains(t, buf.String(), "RRULE:FREQ=MONTHLY;BYMONTHDAY=5\r\n")
+
+	read, err := ical.Read(&buf, now)
+	require.NoError(t, err)
+	require.Len(t, read.Tasks, 1)
+	assert.Equal(t, "FREQ=MONTHLY;BYMONTHDAY=5", read.Tasks[0].Recurrence)
+}
+
+func TestHTTPAPI_QuickAdd(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 7, 15, 0, 0, 0, time.UTC))
+	s := newMemoryService(service.WithClock(clk))
+	taskListID, err := s.CreateTaskList(ctx, "Trabalho")
+	require.NoError(t, err)
+	h := httpapi.NewHandler(s, httpapi.WithClock(clk))
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := post("/lists/"+taskListID+"/tasks", `{"text":"revisar PR amanhã às 10h #dev"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created task.Task
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, "revisar PR", created.Title)
+	assert.True(t, time.Date(2024, 5, 8, 10, 0, 0, 0, time.UTC).Equal(created.Deadline))
+	assert.Equal(t, []string{"dev"}, created.Tags)
+	assert.Equal(t, "/tasks/"+created.ID, rec.Header().Get("Location"))
+
+	taskList, err := s.GetTaskList(ctx, taskListID)
+	require.NoError(t, err)
+	require.Len(t, taskList.Tasks, 1)
+
+	assert.Equal(t, http.StatusBadRequest, post("/lists/"+taskListID+"/tasks", `{"text":"#dev"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post("/lists/"+taskListID+"/tasks", `{`).Code)
+	assert.Equal(t, http.StatusNotFound, post("/lists/nope/tasks", `{"text":"x"}`).Code)
+
+	// Um prazo que já passou é erro do cliente, não do servidor
+	rec = post("/lists/"+taskListID+"/tasks", `{"text":"standup today 9h"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "invalid task parameters: deadline 2024-05-07 09:00 is in the past\n", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/lists/"+taskListID+"/tasks", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,479 @@
+package tests
+
+import (
+	"botasks/internal/bot"
+	"botasks/internal/clock"
+	"botasks/internal/event"
+	"botasks/internal/reminder"
+	"botasks/internal/repository/eventsourced"
+	"botasks/internal/service"
+	"botasks/internal/task"
+	"botasks/internal/webhook"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingNotifier guarda as notificações; se fail for true, todas falham.
+type recordingNotifier struct {
+	mu    sync.Mutex
+	got   []reminder.Notification
+	fail  bool
+	calls int
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification reminder.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.calls++
+	if n.fail {
+		return errors.New("unavailable")
+	}
+	n.got = append(n.got, notification)
+	return nil
+}
+
+func (n *recordingNotifier) texts() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var texts []string
+	for _, notification := range n.got {
+		texts = append(texts, notification.Text())
+	}
+	return texts
+}
+
+func TestReminder_ParseBefore(t *testing.T) {
+	tests := []struct {
+		text string
+		want time.Duration
+		ok   bool
+	}{
+		{"1h before", time.Hour, true},
+		{"30 min before", 30 * time.Minute, true},
+		{"2 days before", 48 * time.Hour, true},
+		{"1 dia antes", 24 * time.Hour, true},
+		{"15 Minutos Antes", 15 * time.Minute, true},
+		{"tomorrow 9am", 0, false},
+		{"1h", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := reminder.ParseBefore(tt.text)
+		assert.Equal(t, tt.ok, ok, tt.text)
+		assert.Equal(t, tt.want, got, tt.text)
+	}
+}
+
+func TestReminder_SchedulerFiresOncePerDueTime(t *testing.T) {
+	ctx := service.ContextWithActor(context.Background(), "ana")
+	clk := clock.NewFake(time.Date(2024, 5, 7, 15, 0, 0, 0, time.UTC))
+	s := newMemoryService(service.WithClock(clk))
+	taskListID, err := s.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+	rent, err := s.AddTask(ctx, taskListID, "Pay rent", "", clk.Now().Add(3*time.Hour))
+	require.NoError(t, err)
+	call, err := s.AddTask(ctx, taskListID, "Call mom", "", clk.Now().Add(24*time.Hour))
+	require.NoError(t, err)
+
+	notifier := &recordingNotifier{}
+	sched := reminder.New(s, reminder.NewMemoryStore(), notifier, reminder.WithClock(clk), reminder.WithInterval(time.Minute))
+
+	_, err = sched.Add(ctx, reminder.Reminder{TaskID: rent, Before: time.Hour})
+	require.NoError(t, err)
+	_, err = sched.Add(ctx, reminder.Reminder{TaskID: call, At: clk.Now().Add(30 * time.Minute)})
+	require.NoError(t, err)
+	_, err = sched.Add(ctx, reminder.Reminder{TaskID: "nope", Before: time.Hour})
+	assert.Error(t, err)
+
+	reminders, err := sched.Reminders(ctx, rent)
+	require.NoError(t, err)
+	require.Len(t, reminders, 1)
+	assert.Equal(t, "ana", reminders[0].CreatedBy)
+
+	sent, err := sched.Tick(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+
+	clk.Advance(30 * time.Minute)
+	sent, err = sched.Tick(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	clk.Advance(90 * time.Minute)
+	sent, err = sched.Tick(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	sent, err = sched.Tick(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, sent, "each reminder fires once")
+	assert.Equal(t, []string{
+		"Reminder: Call mom (due Wed May 8 15:00)",
+		"Reminder: Pay rent (due Tue May 7 18:00)",
+	}, notifier.texts())
+
+	// Um lembrete relativo acompanha o prazo da tarefa
+	require.NoError(t, s.UpdateTask(ctx, rent, "", "", clk.Now().Add(2*time.Hour)))
+	sent, err = sched.Tick(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+	clk.Advance(time.Hour)
+	sent, err = sched.Tick(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	// Lembretes de tarefas concluídas são descartados
+	_, err = sched.Add(ctx, reminder.Reminder{TaskID: call, Before: time.Hour})
+	require.NoError(t, err)
+	require.NoError(t, s.CompleteTask(ctx, call))
+	sent, err = sched.Tick(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+	reminders, err = sched.Reminders(ctx, call)
+	require.NoError(t, err)
+	assert.Empty(t, reminders)
+}
+
+func TestReminder_FailedDeliveryIsRetried(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 7, 15, 0, 0, 0, time.UTC))
+	s := newMemoryService(service.WithClock(clk))
+	taskListID, err := s.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+	taskID, err := s.AddTask(ctx, taskListID, "Pay rent", "", clk.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	notifier := &recordingNotifier{fail: true}
+	sched := reminder.New(s, reminder.NewMemoryStore(), notifier, reminder.WithClock(clk))
+	_, err = sched.Add(ctx, reminder.Reminder{TaskID: taskID, Before: time.Hour})
+	require.NoError(t, err)
+
+	sent, err := sched.Tick(ctx)
+	assert.ErrorContains(t, err, "unavailable")
+	assert.Zero(t, sent)
+
+	notifier.mu.Lock()
+	notifier.fail = false
+	notifier.mu.Unlock()
+	sent, err = sched.Tick(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, 2, notifier.calls)
+}
+
+// openFileService abre um serviço sobre o log de eventos em path, como o
+// processo faz ao iniciar com BOTASKS_DATA.
+func openFileService(t *testing.T, path string, opts ...service.Option) *service.TaskListService {
+	t.Helper()
+	log, err := eventsourced.OpenFileLog(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { log.Close() })
+	store, err := eventsourced.Open(context.Background(), log)
+	require.NoError(t, err)
+	return service.NewTaskListService(store.TaskLists(), store.Tasks(), append(opts, service.WithUnitOfWork(store))...)
+}
+
+func TestReminder_FileStoreCatchesUpAfterRestart(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "reminders.json")
+	dataPath := filepath.Join(dir, "events.jsonl")
+	clk := clock.NewFake(time.Date(2024, 5, 7, 15, 0, 0, 0, time.UTC))
+	s := openFileService(t, dataPath, service.WithClock(clk))
+	taskListID, err := s.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+	taskID, err := s.AddTask(ctx, taskListID, "Pay rent", "", clk.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+
+	store, err := reminder.OpenFileStore(path)
+	require.NoError(t, err)
+	first := reminder.New(s, store, &recordingNotifier{}, reminder.WithClock(clk))
+	reminderID, err := first.Add(ctx, reminder.Reminder{TaskID: taskID, Before: time.Hour, Channel: "email", Target: "ana@example.com"})
+	require.NoError(t, err)
+
+	// O processo fica parado até depois do prazo; as tarefas são relidas do log
+	clk.Advance(3 * time.Hour)
+	s = openFileService(t, dataPath, service.WithClock(clk))
+	store, err = reminder.OpenFileStore(path)
+	require.NoError(t, err)
+	notifier := &recordingNotifier{}
+	second := reminder.New(s, store, notifier, reminder.WithClock(clk))
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- second.Run(runCtx) }()
+	require.Eventually(t, func() bool { return len(notifier.texts()) == 1 }, time.Second, 5*time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+
+	notifier.mu.Lock()
+	got := notifier.got[0]
+	notifier.mu.Unlock()
+	assert.Equal(t, reminderID, got.Reminder.ID)
+	assert.Equal(t, "ana@example.com", got.Reminder.Target)
+	assert.True(t, got.Late)
+	assert.Equal(t, "Missed reminder: Pay rent (due Tue May 7 17:00)", got.Text())
+
+	// A entrega também fica registrada no arquivo
+	store, err = reminder.OpenFileStore(path)
+	require.NoError(t, err)
+	reminders, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, reminders, 1)
+	assert.True(t, time.Date(2024, 5, 7, 16, 0, 0, 0, time.UTC).Equal(reminders[0].FiredFor))
+}
+
+func TestReminder_MissingTaskKeepsReminder(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 7, 15, 0, 0, 0, time.UTC))
+	store := reminder.NewMemoryStore()
+	s := newMemoryService(service.WithClock(clk))
+	taskListID, err := s.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+	taskID, err := s.AddTask(ctx, taskListID, "Pay rent", "", clk.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+	_, err = reminder.New(s, store, &recordingNotifier{}, reminder.WithClock(clk)).Add(ctx, reminder.Reminder{TaskID: taskID, Before: time.Hour})
+	require.NoError(t, err)
+
+	// Um serviço que não conhece a tarefa não apaga o lembrete de imediato
+	sched := reminder.New(newMemoryService(service.WithClock(clk)), store, &recordingNotifier{},
+		reminder.WithClock(clk), reminder.WithMissingTaskGrace(time.Hour))
+	_, err = sched.Tick(ctx)
+	assert.ErrorContains(t, err, "not found")
+	reminders, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, reminders, 1)
+	assert.True(t, clk.Now().Equal(reminders[0].MissingSince))
+
+	// O erro é informado uma vez; passada a tolerância, o lembrete é removido
+	clk.Advance(30 * time.Minute)
+	_, err = sched.Tick(ctx)
+	require.NoError(t, err)
+	clk.Advance(30 * time.Minute)
+	_, err = sched.Tick(ctx)
+	require.NoError(t, err)
+	reminders, err = store.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, reminders)
+}
+
+func TestReminder_TaskFoundAgainClearsMissingSince(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 7, 15, 0, 0, 0, time.UTC))
+	s := newMemoryService(service.WithClock(clk))
+	taskListID, err := s.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+	taskID, err := s.AddTask(ctx, taskListID, "Pay rent", "", clk.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+
+	store := reminder.NewMemoryStore()
+	require.NoError(t, store.Save(ctx, reminder.Reminder{ID: "r1", TaskID: taskID, Before: time.Hour, MissingSince: clk.Now()}))
+	sched := reminder.New(s, store, &recordingNotifier{}, reminder.WithClock(clk))
+	_, err = sched.Tick(ctx)
+	require.NoError(t, err)
+	reminders, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, reminders, 1)
+	assert.True(t, reminders[0].MissingSince.IsZero())
+}
+
+func TestReminder_RemovedDuringDeliveryStaysRemoved(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 7, 15, 0, 0, 0, time.UTC))
+	s := newMemoryService(service.WithClock(clk))
+	taskListID, err := s.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+	taskID, err := s.AddTask(ctx, taskListID, "Pay rent", "", clk.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	store := reminder.NewMemoryStore()
+	var sched *reminder.Scheduler
+	sched = reminder.New(s, store, reminder.NotifierFunc(func(ctx context.Context, n reminder.Notification) error {
+		return sched.Remove(ctx, n.Reminder.ID)
+	}), reminder.WithClock(clk))
+	_, err = sched.Add(ctx, reminder.Reminder{TaskID: taskID, Before: time.Hour})
+	require.NoError(t, err)
+
+	sent, err := sched.Tick(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	reminders, err := store.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, reminders)
+}
+
+func TestReminder_DeletedTaskDropsReminders(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 7, 15, 0, 0, 0, time.UTC))
+	bus := event.NewBus()
+	defer bus.Close()
+	s := newMemoryService(service.WithClock(clk), service.WithEventPublisher(bus))
+	taskListID, err := s.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+	rent, err := s.AddTask(ctx, taskListID, "Pay rent", "", clk.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+	call, err := s.AddTask(ctx, taskListID, "Call mom", "", clk.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+
+	store := reminder.NewMemoryStore()
+	sched := reminder.New(s, store, &recordingNotifier{}, reminder.WithClock(clk))
+	sched.Subscribe(bus)
+	for _, taskID := range []string{rent, rent, call} {
+		_, err := sched.Add(ctx, reminder.Reminder{TaskID: taskID, Before: time.Hour})
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, s.DeleteTask(ctx, rent))
+	reminders, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, reminders, 1)
+	assert.Equal(t, call, reminders[0].TaskID)
+}
+
+func TestReminder_BeforeRequiresDeadline(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 7, 15, 0, 0, 0, time.UTC))
+	s := newMemoryService(service.WithClock(clk))
+	taskListID, err := s.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+	taskID, err := s.ImportTask(ctx, task.Task{Title: "Pay rent"}, taskListID)
+	require.NoError(t, err)
+
+	sched := reminder.New(s, reminder.NewMemoryStore(), &recordingNotifier{}, reminder.WithClock(clk))
+	_, err = sched.Add(ctx, reminder.Reminder{TaskID: taskID, Before: time.Hour})
+	assert.ErrorIs(t, err, reminder.ErrNoDeadline)
+	_, err = sched.Add(ctx, reminder.Reminder{TaskID: taskID, At: clk.Now().Add(time.Hour)})
+	assert.NoError(t, err)
+}
+
+func TestReminder_Notifiers(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 7, 15, 0, 0, 0, time.UTC))
+	s := newMemoryService(service.WithClock(clk))
+	taskListID, err := s.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+	taskID, err := s.AddTask(ctx, taskListID, "Pagar condomínio", "Boleto no e-mail", clk.Now().Add(time.Hour))
+	require.NoError(t, err)
+	got, err := s.GetTask(ctx, taskID)
+	require.NoError(t, err)
+	n := reminder.Notification{Task: *got, DueAt: clk.Now()}
+
+	var mu sync.Mutex
+	var bodies [][]byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !webhook.Verify("s3cret", body, r.Header.Get(webhook.SignatureHeader)) {
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, reminder.EventName, r.Header.Get(webhook.EventHeader))
+		mu.Lock()
+		bodies = append(bodies, body)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	var out strings.Builder
+	router := reminder.Router{
+		"":        reminder.NewWriterNotifier(&out),
+		"email":   reminder.NewEmailNotifier(dir, "botasks@example.com"),
+		"webhook": reminder.NewWebhookNotifier(srv.URL, "s3cret", nil),
+	}
+
+	n.Reminder = reminder.Reminder{ID: "r1", TaskID: taskID}
+	require.NoError(t, router.Notify(ctx, n))
+	n.Reminder.Channel = "sms"
+	require.NoError(t, router.Notify(ctx, n), "unknown channels use the default")
+	assert.Equal(t, strings.Repeat("Reminder: Pagar condomínio (due Tue May 7 16:00)\n", 2), out.String())
+	assert.Error(t, reminder.Router{}.Notify(ctx, n))
+
+	n.Reminder = reminder.Reminder{ID: "r2", TaskID: taskID, Channel: "email", Target: "ana@example.com"}
+	require.NoError(t, router.Notify(ctx, n))
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	eml, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	assert.Contains(t, string(eml), "To: ana@example.com\r\n")
+	assert.Contains(t, string(eml), "Subject: =?utf-8?q?")
+	assert.Contains(t, string(eml), "\r\n\r\nReminder: Pagar condomínio (due Tue May 7 16:00)\r\n\r\nBoleto no e-mail\r\n")
+	n.Reminder.Target = ""
+	assert.Error(t, router.Notify(ctx, n), "email needs a recipient")
+
+	n.Reminder = reminder.Reminder{ID: "r3", TaskID: taskID, Channel: "webhook", Target: "ops"}
+	require.NoError(t, router.Notify(ctx, n))
+	mu.Lock()
+	require.Len(t, bodies, 1)
+	var payload reminder.WebhookPayload
+	require.NoError(t, json.Unmarshal(bodies[0], &payload))
+	mu.Unlock()
+	assert.Equal(t, "r3", payload.ReminderID)
+	assert.Equal(t, taskID, payload.TaskID)
+	assert.Equal(t, "ops", payload.Target)
+	assert.Error(t, reminder.NewWebhookNotifier(srv.URL, "errado", nil).Notify(ctx, n))
+}
+
+// replySender guarda as mensagens enviadas pelo bot.ReminderNotifier.
+type replySender struct {
+	mu      sync.Mutex
+	replies []bot.Reply
+}
+
+func (s *replySender) Send(ctx context.Context, r bot.Reply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.replies = append(s.replies, r)
+	return nil
+}
+
+func TestBot_Remind(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 7, 15, 0, 0, 0, time.UTC))
+	s := newMemoryService(service.WithClock(clk))
+	sender := &replySender{}
+	sched := reminder.New(s, reminder.NewMemoryStore(),
+		reminder.Router{"telegram": bot.NewReminderNotifier(sender)}, reminder.WithClock(clk))
+	b := bot.New(s, bot.WithClock(clk), bot.WithReminders(sched, "telegram"))
+	say := func(text string) string {
+		return b.Handle(ctx, bot.Message{ChatID: "42", Text: text}).Text
+	}
+
+	say("/add Pay rent tomorrow 18h")
+	assert.Equal(t, "Error: no tasks shown (use /list first)", say("/remind 1 1h before"))
+	say("/list")
+	assert.Equal(t, "I'll remind you on Wed May 8 17:00: Pay rent", say("/remind 1 1h before"))
+	assert.Equal(t, "I'll remind you on Wed May 8 09:00: Pay rent", say("/remind 1 amanhã às 9h"))
+	assert.Equal(t, `Error: can't tell when "soon" is`, say("/remind 1 soon"))
+	assert.Equal(t, "Error: usage: /remind <n> <when|1h before>", say("/remind 1"))
+
+	clk.Set(time.Date(2024, 5, 8, 17, 0, 0, 0, time.UTC))
+	sent, err := sched.Tick(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+	require.Len(t, sender.replies, 2)
+	r := sender.replies[0]
+	assert.Equal(t, "42", r.ChatID)
+	assert.Equal(t, "Reminder: Pay rent (due Wed May 8 18:00)", r.Text)
+	require.Len(t, r.Buttons, 1)
+	assert.True(t, strings.HasPrefix(r.Buttons[0][0].Data, "/complete "))
+
+	assert.Equal(t, "Error: reminders are not enabled", bot.New(s).Handle(ctx, bot.Message{ChatID: "42", Text: "/remind 1 1h before"}).Text)
+}
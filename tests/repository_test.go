@@ -0,0 +1,14 @@
+package tests
+
+import (
+	"botasks/internal/repository"
+	"botasks/internal/repository/repotest"
+	"testing"
+)
+
+func TestMemoryRepositories_Contract(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) (repository.TaskRepository, repository.TaskListRepository) {
+		taskRepo := repository.NewMemoryTaskRepository()
+		return taskRepo, repository.NewMemoryTaskListRepository(taskRepo)
+	})
+}
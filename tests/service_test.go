@@ -4,6 +4,7 @@ import (
 	"botasks/internal/list"
 	"botasks/internal/service"
 	"botasks/internal/task"
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -21,68 +22,84 @@ type MockTaskRepo struct {
 	mock.Mock
 }
 
-func (m *MockTaskListRepo) Create(taskList list.TaskList) (string, error) {
-	args := m.Called(taskList)
+func (m *MockTaskListRepo) Create(ctx context.Context, taskList list.TaskList) (string, error) {
+	args := m.Called(ctx, taskList)
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockTaskListRepo) GetByID(taskListID string) (*list.TaskList, error) {
-	args := m.Called(taskListID)
+func (m *MockTaskListRepo) GetByID(ctx context.Context, taskListID string) (*list.TaskList, error) {
+	args := m.Called(ctx, taskListID)
 	return args.Get(0).(*list.TaskList), args.Error(1)
 }
 
-func (m *MockTaskListRepo) Update(taskList list.TaskList) error {
-	args := m.Called(taskList)
+func (m *MockTaskListRepo) Update(ctx context.Context, taskList list.TaskList) error {
+	args := m.Called(ctx, taskList)
 	return args.Error(0)
 }
 
-func (m *MockTaskListRepo) Delete(taskListID string) error {
-	args := m.Called(taskListID)
+func (m *MockTaskListRepo) Delete(ctx context.Context, taskListID string) error {
+	args := m.Called(ctx, taskListID)
 	return args.Error(0)
 }
 
-func (m *MockTaskListRepo) AddTaskToList(taskID, taskListID string) error {
-	args := m.Called(taskID, taskListID)
+func (m *MockTaskListRepo) AddTaskToList(ctx context.Context, taskID, taskListID string) error {
+	args := m.Called(ctx, taskID, taskListID)
 	return args.Error(0)
 }
 
-func (m *MockTaskListRepo) GetTasksByList(taskListID string) ([]list.Task, error) {
-	args := m.Called(taskListID)
+func (m *MockTaskListRepo) RemoveTaskFromList(ctx context.Context, taskID, taskListID string) error {
+	args := m.Called(ctx, taskID, taskListID)
+	return args.Error(0)
+}
+
+func (m *MockTaskListRepo) GetTasksByList(ctx context.Context, taskListID string) ([]list.Task, error) {
+	args := m.Called(ctx, taskListID)
 	return args.Get(0).([]list.Task), args.Error(1)
 }
 
+func (m *MockTaskListRepo) List(ctx context.Context) ([]list.TaskList, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]list.TaskList), args.Error(1)
+}
+
 //
 
-func (m *MockTaskRepo) Create(t task.Task) (string, error) {
-	args := m.Called(t)
+func (m *MockTaskRepo) Create(ctx context.Context, t task.Task) (string, error) {
+	args := m.Called(ctx, t)
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockTaskRepo) GetByID(taskID string) (*task.Task, error) {
-	args := m.Called(taskID)
+func (m *MockTaskRepo) GetByID(ctx context.Context, taskID string) (*task.Task, error) {
+	args := m.Called(ctx, taskID)
 	return args.Get(0).(*task.Task), args.Error(1)
 }
 
-func (m *MockTaskRepo) Update(t task.Task) error {
-	args := m.Called(t)
+func (m *MockTaskRepo) Update(ctx context.Context, t task.Task) error {
+	args := m.Called(ctx, t)
 	return args.Error(0)
 }
 
-func (m *MockTaskRepo) Delete(taskID string) error {
-	args := m.Called(taskID)
+func (m *MockTaskRepo) Delete(ctx context.Context, taskID string) error {
+	args := m.Called(ctx, taskID)
 	return args.Error(0)
 }
 
+func (m *MockTaskRepo) List(ctx context.Context) ([]task.Task, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]task.Task), args.Error(1)
+}
+
 // TestCreateTaskList verifica se o serviço cria uma lista de tarefas corretamente.
 func TestCreateTaskList(t *testing.T) {
+	ctx := context.Background()
 	mockTaskListRepo := new(MockTaskListRepo)
 	mockTaskRepo := new(MockTaskRepo)
 	s := service.NewTaskListService(mockTaskListRepo, mockTaskRepo)
 
 	// Configure o mock para esperar qualquer TaskList e retorne um ID fictício.
-	mockTaskListRepo.On("Create", mock.AnythingOfType("list.TaskList")).Return("id1", nil)
+	mockTaskListRepo.On("Create", mock.Anything, mock.AnythingOfType("list.TaskList")).Return("id1", nil)
 
-	taskListID, err := s.CreateTaskList("Lista de Tarefas")
+	taskListID, err := s.CreateTaskList(ctx, "Lista de Tarefas")
 
 	// Verifique se nenhum erro foi retornado e se o ID retornado é o esperado.
 	assert.NoError(t, err)
@@ -94,6 +111,7 @@ func TestCreateTaskList(t *testing.T) {
 
 // TestGetTaskListByID verifica se o serviço recupera a lista de tarefas corretamente dado um ID válido.
 func TestGetTaskListByID(t *testing.T) {
+	ctx := context.Background()
 	mockTaskListRepo := new(MockTaskListRepo)
 	mockTaskRepo := new(MockTaskRepo)
 	s := service.NewTaskListService(mockTaskListRepo, mockTaskRepo)
@@ -105,10 +123,10 @@ func TestGetTaskListByID(t *testing.T) {
 	}
 
 	// Configurar o mock para retornar a lista de tarefas esperada quando o ID correspondente é fornecido.
-	mockTaskListRepo.On("GetByID", "id1").Return(expectedTaskList, nil)
+	mockTaskListRepo.On("GetByID", mock.Anything, "id1").Return(expectedTaskList, nil)
 
 	// Chamar o método do serviço.
-	taskList, err := s.GetTaskList("id1")
+	taskList, err := s.GetTaskList(ctx, "id1")
 
 	// Verificar se nenhum erro foi retornado e se a lista de tarefas retornada é a esperada.
 	assert.NoError(t, err)
@@ -119,6 +137,7 @@ func TestGetTaskListByID(t *testing.T) {
 }
 
 func TestUpdateTaskList(t *testing.T) {
+	ctx := context.Background()
 	mockTaskListRepo := new(MockTaskListRepo)
 	mockTaskRepo := new(MockTaskRepo)
 	s := service.NewTaskListService(mockTaskListRepo, mockTaskRepo)
@@ -130,12 +149,12 @@ func TestUpdateTaskList(t *testing.T) {
 	}
 
 	// Configuração para o método GetByID do repositório ser chamado e para simular um sucesso na operação
-	mockTaskListRepo.On("GetByID", "id1").Return(&existingTaskList, nil)
+	mockTaskListRepo.On("GetByID", mock.Anything, "id1").Return(&existingTaskList, nil)
 	// Configuração para o método Update do repositório ser chamado e para simular um sucesso na operação
-	mockTaskListRepo.On("Update", mock.AnythingOfType("list.TaskList")).Return(nil)
+	mockTaskListRepo.On("Update", mock.Anything, mock.AnythingOfType("list.TaskList")).Return(nil)
 
 	// Execução do método UpdateTaskList do serviço
-	err := s.UpdateTaskList("id1", "Lista de Tarefas Atualizada")
+	err := s.UpdateTaskList(ctx, "id1", "Lista de Tarefas Atualizada")
 
 	// Verificações: se nenhum erro foi retornado e se as expectativas do mock foram atendidas
 	assert.NoError(t, err)
@@ -143,14 +162,15 @@ func TestUpdateTaskList(t *testing.T) {
 }
 
 func TestUpdateNonExistentTaskList(t *testing.T) {
+	ctx := context.Background()
 	mockTaskListRepo := new(MockTaskListRepo)
 	s := service.NewTaskListService(mockTaskListRepo, nil) // taskRepo não é necessário para este teste
 
 	// Configuração para o método GetByID do repositório ser chamado e para simular uma lista de tarefas inexistente
-	mockTaskListRepo.On("GetByID", "nonexistent-id").Return((*list.TaskList)(nil), errors.New("TaskList not found"))
+	mockTaskListRepo.On("GetByID", mock.Anything, "nonexistent-id").Return((*list.TaskList)(nil), errors.New("TaskList not found"))
 
 	// Execução do método UpdateTaskList do serviço
-	err := s.UpdateTaskList("nonexistent-id", "New Name")
+	err := s.UpdateTaskList(ctx, "nonexistent-id", "New Name")
 
 	// Verificações: se um erro foi retornado e se o erro é o esperado
 	assert.Error(t, err)
@@ -159,6 +179,7 @@ func TestUpdateNonExistentTaskList(t *testing.T) {
 }
 
 func TestAddTask_Success(t *testing.T) {
+	ctx := context.Background()
 	mockTaskListRepo := new(MockTaskListRepo)
 	mockTaskRepo := new(MockTaskRepo)
 	s := service.NewTaskListService(mockTaskListRepo, mockTaskRepo)
@@ -169,11 +190,11 @@ func TestAddTask_Success(t *testing.T) {
 	deadline := time.Now().Add(24 * time.Hour)
 
 	// Configure os mocks para simular o comportamento esperado dos repositórios
-	mockTaskRepo.On("Create", mock.AnythingOfType("task.Task")).Return("new-task-id", nil)
-	mockTaskListRepo.On("AddTaskToList", "new-task-id", taskListID).Return(nil)
+	mockTaskRepo.On("Create", mock.Anything, mock.AnythingOfType("task.Task")).Return("new-task-id", nil)
+	mockTaskListRepo.On("AddTaskToList", mock.Anything, "new-task-id", taskListID).Return(nil)
 
 	// Executa o método AddTask do serviço
-	taskID, err := s.AddTask(taskListID, title, description, deadline)
+	taskID, err := s.AddTask(ctx, taskListID, title, description, deadline)
 
 	// Verifica se nenhum erro foi retornado e se um ID de tarefa foi retornado
 	assert.NoError(t, err)
@@ -183,6 +204,7 @@ func TestAddTask_Success(t *testing.T) {
 }
 
 func TestAddTask_NonExistentTaskList(t *testing.T) {
+	ctx := context.Background()
 	mockTaskListRepo := new(MockTaskListRepo)
 	mockTaskRepo := new(MockTaskRepo)
 	s := service.NewTaskListService(mockTaskListRepo, mockTaskRepo)
@@ -193,12 +215,14 @@ func TestAddTask_NonExistentTaskList(t *testing.T) {
 	taskListID := "nonexistent-id"
 
 	// Aqui você precisa configurar o mock para o método Create, que é chamado dentro de AddTask.
-	mockTaskRepo.On("Create", mock.AnythingOfType("task.Task")).Return("new-task-id", nil)
+	mockTaskRepo.On("Create", mock.Anything, mock.AnythingOfType("task.Task")).Return("new-task-id", nil)
 	// Configuração do mock para simular a tentativa de adicionar a uma lista inexistente.
-	mockTaskListRepo.On("AddTaskToList", "new-task-id", taskListID).Return(errors.New("TaskList not found"))
+	mockTaskListRepo.On("AddTaskToList", mock.Anything, "new-task-id", taskListID).Return(errors.New("TaskList not found"))
+	// A tarefa criada deve ser removida quando a lista não existe.
+	mockTaskRepo.On("Delete", mock.Anything, "new-task-id").Return(nil)
 
 	// Execução do método AddTask do serviço
-	_, err := s.AddTask(taskListID, title, description, deadline)
+	_, err := s.AddTask(ctx, taskListID, title, description, deadline)
 
 	// Verificações: se um erro foi retornado e se o erro é o esperado.
 	assert.Error(t, err)
@@ -208,6 +232,7 @@ func TestAddTask_NonExistentTaskList(t *testing.T) {
 }
 
 func TestAddTask_RepoError(t *testing.T) {
+	ctx := context.Background()
 	mockTaskListRepo := new(MockTaskListRepo)
 	mockTaskRepo := new(MockTaskRepo)
 	s := service.NewTaskListService(mockTaskListRepo, mockTaskRepo)
@@ -218,12 +243,14 @@ func TestAddTask_RepoError(t *testing.T) {
 	taskListID := "existing-id"
 
 	// Configuração do mock para simular a criação de uma nova tarefa.
-	mockTaskRepo.On("Create", mock.AnythingOfType("task.Task")).Return("new-task-id", nil)
+	mockTaskRepo.On("Create", mock.Anything, mock.AnythingOfType("task.Task")).Return("new-task-id", nil)
 	// Configuração do mock para simular um erro do repositório ao adicionar a tarefa à lista.
-	mockTaskListRepo.On("AddTaskToList", "new-task-id", taskListID).Return(errors.New("internal error"))
+	mockTaskListRepo.On("AddTaskToList", mock.Anything, "new-task-id", taskListID).Return(errors.New("internal error"))
+	// A tarefa criada deve ser removida quando a adição à lista falha.
+	mockTaskRepo.On("Delete", mock.Anything, "new-task-id").Return(nil)
 
 	// Execução do método AddTask do serviço
-	_, err := s.AddTask(taskListID, title, description, deadline)
+	_, err := s.AddTask(ctx, taskListID, title, description, deadline)
 
 	// Verificações: se um erro interno foi retornado.
 	assert.Error(t, err)
@@ -233,16 +260,18 @@ func TestAddTask_RepoError(t *testing.T) {
 }
 
 func TestDeleteTaskList_Success(t *testing.T) {
+	ctx := context.Background()
 	mockTaskListRepo := new(MockTaskListRepo)
 	s := service.NewTaskListService(mockTaskListRepo, nil) // taskRepo não é necessário para este teste
 
 	taskListID := "existing-id"
 
 	// Configure o mock para simular a exclusão bem-sucedida da lista de tarefas
-	mockTaskListRepo.On("Delete", taskListID).Return(nil)
+	mockTaskListRepo.On("GetByID", mock.Anything, taskListID).Return(&list.TaskList{ID: taskListID}, nil)
+	mockTaskListRepo.On("Delete", mock.Anything, taskListID).Return(nil)
 
 	// Execução do método DeleteTaskList do serviço
-	err := s.DeleteTaskList(taskListID)
+	err := s.DeleteTaskList(ctx, taskListID)
 
 	// Verificações: se nenhum erro foi retornado
 	assert.NoError(t, err)
@@ -250,16 +279,17 @@ func TestDeleteTaskList_Success(t *testing.T) {
 }
 
 func TestDeleteTaskList_NonExistent(t *testing.T) {
+	ctx := context.Background()
 	mockTaskListRepo := new(MockTaskListRepo)
 	s := service.NewTaskListService(mockTaskListRepo, nil)
 
 	taskListID := "nonexistent-id"
 
 	// Configure o mock para simular que a lista de tarefas não existe
-	mockTaskListRepo.On("Delete", taskListID).Return(errors.New("TaskList not found"))
+	mockTaskListRepo.On("GetByID", mock.Anything, taskListID).Return((*list.TaskList)(nil), errors.New("TaskList not found"))
 
 	// Execução do método DeleteTaskList do serviço
-	err := s.DeleteTaskList(taskListID)
+	err := s.DeleteTaskList(ctx, taskListID)
 
 	// Verificações: se um erro foi retornado e se o erro é o esperado
 	assert.Error(t, err)
@@ -268,16 +298,18 @@ func TestDeleteTaskList_NonExistent(t *testing.T) {
 }
 
 func TestDeleteTaskList_RepoError(t *testing.T) {
+	ctx := context.Background()
 	mockTaskListRepo := new(MockTaskListRepo)
 	s := service.NewTaskListService(mockTaskListRepo, nil)
 
 	taskListID := "existing-id"
 
 	// Configure o mock para simular um erro de repositório
-	mockTaskListRepo.On("Delete", taskListID).Return(errors.New("internal error"))
+	mockTaskListRepo.On("GetByID", mock.Anything, taskListID).Return(&list.TaskList{ID: taskListID}, nil)
+	mockTaskListRepo.On("Delete", mock.Anything, taskListID).Return(errors.New("internal error"))
 
 	// Execução do método DeleteTaskList do serviço
-	err := s.DeleteTaskList(taskListID)
+	err := s.DeleteTaskList(ctx, taskListID)
 
 	// Verificações: se um erro interno foi retornado
 	assert.Error(t, err)
@@ -286,6 +318,7 @@ func TestDeleteTaskList_RepoError(t *testing.T) {
 }
 
 func TestGetTasksByTaskList_Success(t *testing.T) {
+	ctx := context.Background()
 	mockTaskListRepo := new(MockTaskListRepo)
 	// mockTaskRepo não é necessário para este teste porque não interagimos com ele diretamente
 	s := service.NewTaskListService(mockTaskListRepo, nil)
@@ -297,10 +330,10 @@ func TestGetTasksByTaskList_Success(t *testing.T) {
 	}
 
 	// Configure o mock para simular a recuperação bem-sucedida das tarefas como []list.Task
-	mockTaskListRepo.On("GetTasksByList", taskListID).Return(listTasks, nil)
+	mockTaskListRepo.On("GetTasksByList", mock.Anything, taskListID).Return(listTasks, nil)
 
 	// Execução do método GetTasksByTaskList do serviço
-	returnedTasks, err := s.GetTasksByTaskList(taskListID)
+	returnedTasks, err := s.GetTasksByTaskList(ctx, taskListID)
 
 	// Construir o resultado esperado como []task.Task
 	expectedTasks := make([]task.Task, len(listTasks))
@@ -315,6 +348,7 @@ func TestGetTasksByTaskList_Success(t *testing.T) {
 }
 
 func TestGetTasksByTaskList_NonExistent(t *testing.T) {
+	ctx := context.Background()
 	mockTaskListRepo := new(MockTaskListRepo)
 	s := service.NewTaskListService(mockTaskListRepo, nil)
 
@@ -322,10 +356,10 @@ func TestGetTasksByTaskList_NonExistent(t *testing.T) {
 
 	// Configure o mock para simular que a lista de tarefas não existe.
 	// Certifique-se de que o primeiro retorno seja um slice de 'list.Task' que é explicitamente nil.
-	mockTaskListRepo.On("GetTasksByList", taskListID).Return(([]list.Task)(nil), errors.New("TaskList not found"))
+	mockTaskListRepo.On("GetTasksByList", mock.Anything, taskListID).Return(([]list.Task)(nil), errors.New("TaskList not found"))
 
 	// Execução do método GetTasksByTaskList do serviço.
-	returnedTasks, err := s.GetTasksByTaskList(taskListID)
+	returnedTasks, err := s.GetTasksByTaskList(ctx, taskListID)
 
 	// Verificações: se um erro foi retornado e se nenhuma tarefa foi retornada.
 	assert.Error(t, err)
@@ -335,6 +369,7 @@ func TestGetTasksByTaskList_NonExistent(t *testing.T) {
 }
 
 func TestGetTasksByTaskList_RepoError(t *testing.T) {
+	ctx := context.Background()
 	mockTaskListRepo := new(MockTaskListRepo)
 	s := service.NewTaskListService(mockTaskListRepo, nil)
 
@@ -342,10 +377,10 @@ func TestGetTasksByTaskList_RepoError(t *testing.T) {
 
 	// Configure o mock para simular um erro de repositório
 	// e para retornar um slice 'nil' do tipo correto ([]list.Task).
-	mockTaskListRepo.On("GetTasksByList", taskListID).Return(([]list.Task)(nil), errors.New("internal error"))
+	mockTaskListRepo.On("GetTasksByList", mock.Anything, taskListID).Return(([]list.Task)(nil), errors.New("internal error"))
 
 	// Execução do método GetTasksByTaskList do serviço
-	returnedTasks, err := s.GetTasksByTaskList(taskListID)
+	returnedTasks, err := s.GetTasksByTaskList(ctx, taskListID)
 
 	// Verificações: se um erro interno foi retornado e se nenhuma tarefa foi retornada
 	assert.Error(t, err)
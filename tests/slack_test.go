@@ -0,0 +1,202 @@
+package tests
+
+import (
+	"botasks/internal/clock"
+	"botasks/internal/service"
+	"botasks/internal/slack"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const slackSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+func TestSlack_VerifySignature(t *testing.T) {
+	now := time.Unix(1531420618, 0)
+	body := []byte("token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&command=%2Fweather")
+	ts := "1531420618"
+
+	h := http.Header{}
+	h.Set(slack.TimestampHeader, ts)
+	h.Set(slack.SignatureHeader, slack.Sign(slackSecret, ts, body))
+	assert.NoError(t, slack.VerifySignature(slackSecret, h, body, now))
+
+	assert.ErrorIs(t, slack.VerifySignature("outro", h, body, now), slack.ErrInvalidSignature)
+	assert.ErrorIs(t, slack.VerifySignature(slackSecret, h, append(body, '&'), now), slack.ErrInvalidSignature)
+	assert.ErrorIs(t, slack.VerifySignature(slackSecret, h, body, now.Add(6*time.Minute)), slack.ErrInvalidSignature, "replayed request")
+
+	h.Set(slack.TimestampHeader, "ontem")
+	assert.ErrorIs(t, slack.VerifySignature(slackSecret, h, body, now), slack.ErrInvalidSignature)
+}
+
+func TestSlack_SlashCommandsAndInteractivity(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 7, 15, 0, 0, 0, time.UTC))
+	s := newMemoryService(service.WithClock(clk))
+
+	var mu sync.Mutex
+	var responses []slack.Message
+	responseURL := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var m slack.Message
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&m))
+		mu.Lock()
+		responses = append(responses, m)
+		mu.Unlock()
+	}))
+	defer responseURL.Close()
+
+	h := slack.NewHandler(s, slackSecret, slack.WithClock(clk))
+	post := func(form url.Values, sign bool) *httptest.ResponseRecorder {
+		body := form.Encode()
+		req := httptest.NewRequest(http.MethodPost, "/slack", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		ts := strconv.FormatInt(clk.Now().Unix(), 10)
+		req.Header.Set(slack.TimestampHeader, ts)
+		if sign {
+			req.Header.Set(slack.SignatureHeader, slack.Sign(slackSecret, ts, []byte(body)))
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	command := func(user, text string) slack.Message {
+		t.Helper()
+		rec := post(url.Values{
+			"command": {"/tasks"}, "text": {text}, "team_id": {"T1"}, "user_id": {user},
+			"channel_id": {"C1"}, "channel_name": {"general"}, "response_url": {responseURL.URL},
+		}, true)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var m slack.Message
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &m))
+		return m
+	}
+
+	rec := post(url.Values{"command": {"/tasks"}, "text": {"add x"}}, false)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	m := command("U1", "add Revisar <PR> & deploy")
+	assert.Equal(t, "in_channel", m.ResponseType)
+	assert.Equal(t, "<@U1> added *Revisar &lt;PR&gt; &amp; deploy* to *#general*", m.Text)
+	require.Len(t, m.Blocks, 2)
+	assert.Equal(t, "section", m.Blocks[0].Type)
+	assert.Equal(t, "context", m.Blocks[1].Type)
+
+	command("U1", "add Pagar luz")
+	command("U2", "add Tarefa da Bia")
+
+	m = command("U1", "mine")
+	assert.Equal(t, "ephemeral", m.ResponseType)
+	assert.Equal(t, "Your open tasks (2)", m.Text)
+	require.Len(t, m.Blocks, 3)
+	require.NotNil(t, m.Blocks[1].Accessory)
+	assert.Equal(t, "button", m.Blocks[1].Accessory.Type)
+	assert.Equal(t, "complete", m.Blocks[1].Accessory.ActionID)
+	taskID := m.Blocks[1].Accessory.Value
+
+	taskLists, err := s.ListTaskLists(ctx)
+	require.NoError(t, err)
+	require.Len(t, taskLists, 1, "one list per channel")
+	assert.Equal(t, "#general", taskLists[0].Name)
+	assert.Len(t, taskLists[0].Tasks, 3)
+
+	// Block Kit: o JSON dos botões segue o formato do Slack
+	raw, err := json.Marshal(m.Blocks[1])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"section","text":{"type":"mrkdwn","text":"*Revisar &lt;PR&gt; &amp; deploy*\nDue Wed May 8 15:00"},
+		"accessory":{"type":"button","text":{"type":"plain_text","text":"Complete"},"action_id":"complete","value":"`+taskID+`"}}`, string(raw))
+
+	interact := func(user, taskID string) int {
+		payload, err := json.Marshal(map[string]any{
+			"type":         "block_actions",
+			"team":         map[string]string{"id": "T1"},
+			"user":         map[string]string{"id": user},
+			"response_url": responseURL.URL,
+			"actions":      []map[string]string{{"action_id": "complete", "value": taskID}},
+		})
+		require.NoError(t, err)
+		code := post(url.Values{"payload": {string(payload)}}, true).Code
+		h.Wait()
+		return code
+	}
+
+	// Só quem criou a tarefa pode concluí-la pelo botão
+	assert.Equal(t, http.StatusOK, interact("U2", taskID))
+	assert.Equal(t, http.StatusOK, interact("U1", taskID))
+
+	mu.Lock()
+	require.Len(t, responses, 2)
+	assert.Equal(t, "Error: you can only complete your own tasks", responses[0].Text)
+	assert.False(t, responses[0].ReplaceOriginal)
+	assert.Equal(t, "Your open tasks (1)", responses[1].Text)
+	assert.True(t, responses[1].ReplaceOriginal)
+	mu.Unlock()
+
+	got, err := s.GetTask(ctx, taskID)
+	require.NoError(t, err)
+	assert.True(t, got.IsCompleted())
+	assert.Equal(t, "slack:T1:U1", got.UpdatedBy)
+
+	assert.Equal(t, "Usage: `/tasks add <title>` or `/tasks mine`", command("U1", "").Text)
+	assert.Contains(t, command("U1", "delete 1").Text, `Unknown subcommand "delete"`)
+	assert.Equal(t, "You have no open tasks.", command("U3", "mine").Text)
+}
+
+func TestSlack_InteractionAnswersBeforeResponseURL(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 7, 15, 0, 0, 0, time.UTC))
+	s := newMemoryService(service.WithClock(clk))
+
+	// response_url só responde depois que a interação foi respondida
+	release := make(chan struct{})
+	responded := make(chan slack.Message, 1)
+	responseURL := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		var m slack.Message
+		if err := json.NewDecoder(r.Body).Decode(&m); err == nil {
+			responded <- m
+		}
+	}))
+	defer responseURL.Close()
+
+	h := slack.NewHandler(s, slackSecret, slack.WithClock(clk))
+	payload, err := json.Marshal(map[string]any{
+		"type":         "block_actions",
+		"team":         map[string]string{"id": "T1"},
+		"user":         map[string]string{"id": "U1"},
+		"response_url": responseURL.URL,
+	})
+	require.NoError(t, err)
+	body := url.Values{"payload": {string(payload)}}.Encode()
+	reqCtx, cancel := context.WithCancel(ctx)
+	req := httptest.NewRequest(http.MethodPost, "/slack", strings.NewReader(body)).WithContext(reqCtx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	ts := strconv.FormatInt(clk.Now().Unix(), 10)
+	req.Header.Set(slack.TimestampHeader, ts)
+	req.Header.Set(slack.SignatureHeader, slack.Sign(slackSecret, ts, []byte(body)))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// O fim da requisição não cancela o envio para response_url
+	cancel()
+	close(release)
+	h.Wait()
+	select {
+	case m := <-responded:
+		assert.Equal(t, "You have no open tasks.", m.Text)
+		assert.True(t, m.ReplaceOriginal)
+	default:
+		t.Fatal("no message posted to response_url")
+	}
+}
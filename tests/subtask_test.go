@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"botasks/internal/audit"
+	"botasks/internal/event"
+	"botasks/internal/repository"
+	"botasks/internal/service"
+	"botasks/internal/task"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubtasks_RejectSelfReferenceAndCycles(t *testing.T) {
+	ctx := context.Background()
+	s := newMemoryService()
+	taskListID, err := s.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+
+	cozinha, err := s.ImportTask(ctx, task.Task{Title: "Arrumar a cozinha"}, taskListID)
+	require.NoError(t, err)
+	louca, err := s.ImportTask(ctx, task.Task{Title: "Lavar a louça", ParentID: cozinha}, taskListID)
+	require.NoError(t, err)
+	pratos, err := s.ImportTask(ctx, task.Task{Title: "Secar os pratos", ParentID: louca}, taskListID)
+	require.NoError(t, err)
+
+	_, err = s.ImportTask(ctx, task.Task{ID: "propria", Title: "Própria", ParentID: "propria"}, taskListID)
+	assert.ErrorIs(t, err, service.ErrInvalidParent)
+	assert.ErrorIs(t, s.SetTaskParent(ctx, cozinha, cozinha), service.ErrInvalidParent)
+	assert.ErrorIs(t, s.SetTaskParent(ctx, cozinha, pratos), service.ErrInvalidParent)
+
+	require.NoError(t, s.SetTaskParent(ctx, pratos, cozinha))
+	stored, err := s.GetTask(ctx, pratos)
+	require.NoError(t, err)
+	assert.Equal(t, cozinha, stored.ParentID)
+	require.NoError(t, s.SetTaskParent(ctx, pratos, ""))
+	stored, err = s.GetTask(ctx, pratos)
+	require.NoError(t, err)
+	assert.Empty(t, stored.ParentID)
+}
+
+func TestSubtasks_DeleteParentOrphansChildren(t *testing.T) {
+	ctx := service.ContextWithActor(context.Background(), "alice")
+	s, m := newUndoService(t)
+	taskListID, err := s.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+	cozinha, err := s.ImportTask(ctx, task.Task{Title: "Arrumar a cozinha"}, taskListID)
+	require.NoError(t, err)
+	louca, err := s.ImportTask(ctx, task.Task{Title: "Lavar a louça", ParentID: cozinha}, taskListID)
+	require.NoError(t, err)
+	pratos, err := s.ImportTask(ctx, task.Task{Title: "Secar os pratos", ParentID: louca}, taskListID)
+	require.NoError(t, err)
+
+	require.NoError(t, s.RemoveAndDeleteTask(ctx, cozinha, taskListID))
+	stored, err := s.GetTask(ctx, louca)
+	require.NoError(t, err)
+	assert.Empty(t, stored.ParentID)
+	stored, err = s.GetTask(ctx, pratos)
+	require.NoError(t, err)
+	assert.Equal(t, louca, stored.ParentID)
+
+	// Desfazer a exclusão devolve a tarefa e a ligação com a subtarefa
+	require.NoError(t, m.Undo(ctx))
+	stored, err = s.GetTask(ctx, louca)
+	require.NoError(t, err)
+	assert.Equal(t, cozinha, stored.ParentID)
+
+	// Excluindo a lista com as tarefas, nada fica apontando para elas
+	outra, err := s.CreateTaskList(ctx, "Outra")
+	require.NoError(t, err)
+	solta, err := s.ImportTask(ctx, task.Task{Title: "Solta", ParentID: cozinha}, outra)
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteTaskListAndTasks(ctx, taskListID))
+	stored, err = s.GetTask(ctx, solta)
+	require.NoError(t, err)
+	assert.Empty(t, stored.ParentID)
+	_, err = s.GetTask(ctx, louca)
+	assert.ErrorIs(t, err, repository.ErrTaskNotFound)
+}
+
+func TestSubtasks_ParentChangeIsAudited(t *testing.T) {
+	ctx := context.Background()
+	bus := event.NewBus()
+	auditLog := audit.NewLog(audit.NewMemoryStore())
+	auditLog.Subscribe(bus)
+	s := newMemoryService(service.WithEventPublisher(bus))
+
+	taskListID, err := s.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+	cozinha, err := s.ImportTask(ctx, task.Task{Title: "Arrumar a cozinha"}, taskListID)
+	require.NoError(t, err)
+	louca, err := s.ImportTask(ctx, task.Task{Title: "Lavar a louça"}, taskListID)
+	require.NoError(t, err)
+	require.NoError(t, s.SetTaskParent(ctx, louca, cozinha))
+
+	history, err := auditLog.TaskHistory(ctx, louca)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, []audit.FieldChange{{Field: "ParentID", Before: "", After: cozinha}}, history[1].Changes)
+}
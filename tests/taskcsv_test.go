@@ -0,0 +1,202 @@
+package tests
+
+import (
+	"botasks/internal/clock"
+	"botasks/internal/service"
+	"botasks/internal/task"
+	"botasks/internal/taskcsv"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskCSV_DetectDateFormat(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   string
+	}{
+		{[]string{"2024-05-10", "2024-12-01"}, "2006-01-02"},
+		{[]string{"03/04/2024", "10/05/2024"}, "2/1/2006"},
+		{[]string{"03/04/2024", "04/25/2024"}, "1/2/2006"},
+		{[]string{"10.05.2024"}, "2.1.2006"},
+		{[]string{"2024-05-10T09:00:00-03:00"}, time.RFC3339},
+		// Nenhum aceita todos: vence o que aceita mais
+		{[]string{"2024-05-10", "amanhã", "2024-05-11"}, "2006-01-02"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(strings.Join(tt.values, ","), func(t *testing.T) {
+			assert.Equal(t, tt.want, taskcsv.DetectDateFormat(tt.values))
+		})
+	}
+}
+
+func TestTaskCSV_CompletedWithoutNowUsesCurrentTime(t *testing.T) {
+	input := "title,completed\nLavar,x\nVarrer,sim\nPassar,done\nCozinhar,\n"
+	before := time.Now()
+	rep, err := taskcsv.Parse(strings.NewReader(input), taskcsv.Options{})
+	require.NoError(t, err)
+	require.Len(t, rep.Tasks, 4)
+	for _, task := range rep.Tasks[:3] {
+		assert.True(t, task.IsCompleted(), task.Title)
+		assert.False(t, task.CompletedAt.Before(before), task.Title)
+	}
+	assert.False(t, rep.Tasks[3].IsCompleted())
+}
+
+func TestTaskCSV_ImportWithMappingAndRowErrors(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 9, 0, 0, 0, time.Local))
+	s := newMemoryService(service.WithClock(clk))
+	casa, err := s.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+
+	input := strings.Join([]string{
+		"Tarefa;Prazo;Prioridade;Etiquetas;Feito",
+		"Lavar;03/05/2024;alta;casa, cozinha;não",
+		";04/05/2024;;;",
+		"Varrer;31/02/2024;;;",
+		"Pagar luz;10/05/2024;7;contas;sim",
+		"Regar;;Q;;talvez",
+		";;;;",
+	}, "\n")
+	opts := taskcsv.Options{
+		Mapping: taskcsv.Mapping{
+			taskcsv.Title:     "tarefa",
+			taskcsv.Deadline:  "Prazo",
+			taskcsv.Priority:  "Prioridade",
+			taskcsv.Tags:      "Etiquetas",
+			taskcsv.Completed: "Feito",
+		},
+		Comma:  ';',
+		DryRun: true,
+		Now:    clk.Now(),
+	}
+
+	rep, err := taskcsv.Import(ctx, s, strings.NewReader(input), casa, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "2/1/2006", rep.DateFormat)
+	assert.Equal(t, 0, rep.Imported)
+	assert.Equal(t, []int{2, 5}, rep.Rows)
+	require.Len(t, rep.Errors, 3)
+	assert.Equal(t, 3, rep.Errors[0].Row)
+	assert.Equal(t, "tarefa", rep.Errors[0].Column)
+	assert.Equal(t, 4, rep.Errors[1].Row)
+	assert.Equal(t, "Prazo", rep.Errors[1].Column)
+	assert.Equal(t, `row 6, column "Feito": invalid completion "talvez"`, rep.Errors[2].Error())
+
+	tasks, err := s.GetTasksByTaskList(ctx, casa)
+	require.NoError(t, err)
+	assert.Empty(t, tasks, "dry run must not import")
+
+	opts.DryRun = false
+	rep, err = taskcsv.Import(ctx, s, strings.NewReader(input), casa, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, rep.Imported)
+
+	tasks, err = s.GetTasksByTaskList(ctx, casa)
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	assert.Equal(t, "Lavar", tasks[0].Title)
+	assert.Equal(t, time.Date(2024, 5, 3, 0, 0, 0, 0, time.Local), tasks[0].Deadline)
+	assert.Equal(t, "A", tasks[0].Priority)
+	assert.Equal(t, []string{"casa", "cozinha"}, tasks[0].Tags)
+	assert.False(t, tasks[0].IsCompleted())
+	assert.Equal(t, "G", tasks[1].Priority)
+	assert.Equal(t, clk.Now(), tasks[1].CompletedAt)
+
+	_, err = taskcsv.Parse(strings.NewReader("nome,prazo\nx,y\n"), taskcsv.Options{})
+	assert.ErrorIs(t, err, taskcsv.ErrNoTitleColumn)
+}
+
+func TestTaskCSV_ExportQueryAndReimport(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC))
+	s := newMemoryService(service.WithClock(clk))
+	casa, err := s.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+	trabalho, err := s.CreateTaskList(ctx, "Trabalho")
+	require.NoError(t, err)
+
+	lavar, err := s.AddTask(ctx, casa, "Lavar, secar", "Com \"sabão\"", clk.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, s.CompleteTask(ctx, lavar))
+	_, err = s.ImportTask(ctx, task.Task{Title: "Relatório", Tags: []string{"a", "b"}, Priority: "B"}, trabalho)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	completed := false
+	require.NoError(t, taskcsv.Export(ctx, s, &buf, "", task.Query{Completed: &completed}))
+	records, err := csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "Relatório", records[1][1])
+	assert.Equal(t, "a,b", records[1][5])
+
+	buf.Reset()
+	require.NoError(t, taskcsv.Export(ctx, s, &buf, casa, task.Query{}))
+	target := newMemoryService(service.WithClock(clk))
+	importada, err := target.CreateTaskList(ctx, "Importada")
+	require.NoError(t, err)
+	rep, err := taskcsv.Import(ctx, target, &buf, importada, taskcsv.Options{Now: clk.Now()})
+	require.NoError(t, err)
+	require.Empty(t, rep.Errors)
+	assert.Equal(t, time.RFC3339, rep.DateFormat)
+
+	tasks, err := target.GetTasksByTaskList(ctx, importada)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "Lavar, secar", tasks[0].Title)
+	assert.Equal(t, `Com "sabão"`, tasks[0].Description)
+	assert.True(t, clk.Now().Add(time.Hour).Equal(tasks[0].Deadline))
+	assert.True(t, clk.Now().Equal(tasks[0].CompletedAt))
+}
+
+func TestTaskCSV_ImportIsAllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC))
+	target := &failingImports{TaskListService: newMemoryService(service.WithClock(clk)), failAt: 2}
+	taskListID, err := target.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+
+	input := "title\nLavar\nVarrer\n"
+	rep, err := taskcsv.Import(ctx, target, strings.NewReader(input), taskListID, taskcsv.Options{Now: clk.Now()})
+	assert.ErrorContains(t, err, "disk full")
+	assert.Zero(t, rep.Imported)
+
+	tasks, err := target.GetTasksByTaskList(ctx, taskListID)
+	require.NoError(t, err)
+	assert.Empty(t, tasks)
+}
+
+func TestTaskCSV_ExportEscapesFormulas(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC))
+	s := newMemoryService(service.WithClock(clk))
+	casa, err := s.CreateTaskList(ctx, "Casa")
+	require.NoError(t, err)
+	_, err = s.ImportTask(ctx, task.Task{Title: `=HYPERLINK("http://evil","x")`, Description: "-5 kg", Tags: []string{"@home"}}, casa)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, taskcsv.Export(ctx, s, &buf, casa, task.Query{}))
+	records, err := csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, `'=HYPERLINK("http://evil","x")`, records[1][1])
+	assert.Equal(t, "'-5 kg", records[1][2])
+	assert.Equal(t, "'@home", records[1][5])
+
+	rep, err := taskcsv.Parse(&buf, taskcsv.Options{Now: clk.Now()})
+	require.NoError(t, err)
+	require.Len(t, rep.Tasks, 1)
+	assert.Equal(t, `=HYPERLINK("http://evil","x")`, rep.Tasks[0].Title)
+	assert.Equal(t, "-5 kg", rep.Tasks[0].Description)
+	assert.Equal(t, []string{"@home"}, rep.Tasks[0].Tags)
+}
@@ -0,0 +1,275 @@
+package tests
+
+import (
+	"botasks/internal/bot"
+	"botasks/internal/clock"
+	"botasks/internal/service"
+	"botasks/internal/telegram"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const telegramToken = "123:abc"
+
+type sentMessage struct {
+	ChatID      int64                          `json:"chat_id"`
+	Text        string                         `json:"text"`
+	ReplyMarkup *telegram.InlineKeyboardMarkup `json:"reply_markup"`
+}
+
+// fakeBotAPI imita os métodos da Bot API usados pelo adaptador.
+type fakeBotAPI struct {
+	mu        sync.Mutex
+	updates   []telegram.Update
+	queued    chan struct{}
+	sent      []sentMessage
+	answers   []string
+	offsets   []int64
+	failUntil int // getUpdates falha com 502 nas primeiras chamadas
+	calls     int
+}
+
+func newFakeBotAPI(t *testing.T) (*fakeBotAPI, *httptest.Server) {
+	f := &fakeBotAPI{queued: make(chan struct{}, 100)}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeBotAPI) push(u telegram.Update) {
+	f.mu.Lock()
+	f.updates = append(f.updates, u)
+	f.mu.Unlock()
+	f.queued <- struct{}{}
+}
+
+func (f *fakeBotAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method, ok := strings.CutPrefix(r.URL.Path, "/bot"+telegramToken+"/")
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]any{"ok": false, "error_code": 401, "description": "Unauthorized"})
+		return
+	}
+	var params map[string]json.RawMessage
+	json.NewDecoder(r.Body).Decode(&params)
+	raw, _ := json.Marshal(params)
+
+	var result any = true
+	switch method {
+	case "getUpdates":
+		var p struct {
+			Offset  int64 `json:"offset"`
+			Timeout int   `json:"timeout"`
+		}
+		json.Unmarshal(raw, &p)
+		f.mu.Lock()
+		f.calls++
+		if f.calls <= f.failUntil {
+			f.mu.Unlock()
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		f.offsets = append(f.offsets, p.Offset)
+		f.mu.Unlock()
+		result = f.waitUpdates(r.Context(), p.Offset, time.Duration(p.Timeout)*time.Second)
+	case "sendMessage":
+		var m sentMessage
+		json.Unmarshal(raw, &m)
+		f.mu.Lock()
+		f.sent = append(f.sent, m)
+		f.mu.Unlock()
+	case "answerCallbackQuery":
+		var a struct {
+			Text string `json:"text"`
+		}
+		json.Unmarshal(raw, &a)
+		f.mu.Lock()
+		f.answers = append(f.answers, a.Text)
+		f.mu.Unlock()
+	}
+	json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+}
+
+// waitUpdates devolve as atualizações a partir de offset, esperando até
+// timeout se não houver nenhuma, como o long polling do Telegram.
+func (f *fakeBotAPI) waitUpdates(ctx context.Context, offset int64, timeout time.Duration) []telegram.Update {
+	deadline := time.After(timeout)
+	for {
+		f.mu.Lock()
+		var pending []telegram.Update
+		for _, u := range f.updates {
+			if u.UpdateID >= offset {
+				pending = append(pending, u)
+			}
+		}
+		f.mu.Unlock()
+		if len(pending) > 0 {
+			return pending
+		}
+		select {
+		case <-f.queued:
+		case <-deadline:
+			return []telegram.Update{}
+		case <-ctx.Done():
+			return []telegram.Update{}
+		}
+	}
+}
+
+func (f *fakeBotAPI) sentMessages() []sentMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]sentMessage(nil), f.sent...)
+}
+
+func textUpdate(id int64, chat int64, username, text string) telegram.Update {
+	return telegram.Update{UpdateID: id, Message: &telegram.Message{
+		MessageID: id,
+		From:      &telegram.User{ID: chat, Username: username},
+		Chat:      telegram.Chat{ID: chat},
+		Text:      text,
+	}}
+}
+
+func TestTelegram_LongPollingWithInlineKeyboard(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	clk := clock.NewFake(time.Date(2024, 5, 7, 15, 0, 0, 0, time.UTC))
+	s := newMemoryService(service.WithClock(clk))
+	b := bot.New(s, bot.WithClock(clk))
+
+	api, srv := newFakeBotAPI(t)
+	api.failUntil = 1
+	var errs []error
+	var errsMu sync.Mutex
+	adapter := telegram.New(telegramToken, b,
+		telegram.WithBaseURL(srv.URL),
+		telegram.WithPollTimeout(time.Second),
+		telegram.WithRetryDelay(10*time.Millisecond),
+	)
+	adapter.OnError = func(err error) {
+		errsMu.Lock()
+		errs = append(errs, err)
+		errsMu.Unlock()
+	}
+
+	pollDone := make(chan error, 1)
+	go func() { pollDone <- adapter.Poll(ctx) }()
+
+	api.push(textUpdate(10, 42, "ana", "/add Buy milk tomorrow 18h #home"))
+	api.push(textUpdate(11, 42, "ana", "/list"))
+	require.Eventually(t, func() bool { return len(api.sentMessages()) == 2 }, 5*time.Second, 5*time.Millisecond)
+
+	sent := api.sentMessages()
+	assert.Equal(t, int64(42), sent[0].ChatID)
+	assert.Equal(t, "Added to Inbox: Buy milk (due Wed May 8 18:00) #home", sent[0].Text)
+	assert.Nil(t, sent[0].ReplyMarkup)
+	require.NotNil(t, sent[1].ReplyMarkup)
+	keyboard := sent[1].ReplyMarkup.InlineKeyboard
+	require.Len(t, keyboard, 1)
+	require.Len(t, keyboard[0], 2)
+	assert.Equal(t, "Done 1", keyboard[0][0].Text)
+	assert.Equal(t, "Snooze 1", keyboard[0][1].Text)
+
+	taskLists, err := s.ListTaskLists(ctx)
+	require.NoError(t, err)
+	require.Len(t, taskLists, 1)
+	taskID := taskLists[0].Tasks[0].ID
+	assert.Equal(t, "/complete "+taskID, keyboard[0][0].CallbackData)
+	assert.Equal(t, "telegram:ana", taskLists[0].Tasks[0].CreatedBy)
+
+	// Adiar empurra o prazo em um dia
+	api.push(telegram.Update{UpdateID: 12, CallbackQuery: &telegram.CallbackQuery{
+		ID: "q1", From: telegram.User{ID: 42, Username: "ana"},
+		Message: &telegram.Message{MessageID: 11, Chat: telegram.Chat{ID: 42}},
+		Data:    keyboard[0][1].CallbackData,
+	}})
+	require.Eventually(t, func() bool { return len(api.sentMessages()) == 3 }, 5*time.Second, 5*time.Millisecond)
+	got, err := s.GetTask(ctx, taskID)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 9, 18, 0, 0, 0, time.UTC), got.Deadline)
+
+	// Outro chat não enxerga as tarefas do primeiro
+	api.push(telegram.Update{UpdateID: 13, CallbackQuery: &telegram.CallbackQuery{
+		ID: "q2", From: telegram.User{ID: 7},
+		Message: &telegram.Message{MessageID: 1, Chat: telegram.Chat{ID: 7}},
+		Data:    keyboard[0][0].CallbackData,
+	}})
+	api.push(telegram.Update{UpdateID: 14, CallbackQuery: &telegram.CallbackQuery{
+		ID: "q3", From: telegram.User{ID: 42, Username: "ana"},
+		Message: &telegram.Message{MessageID: 11, Chat: telegram.Chat{ID: 42}},
+		Data:    keyboard[0][0].CallbackData,
+	}})
+	require.Eventually(t, func() bool { return len(api.sentMessages()) == 5 }, 5*time.Second, 5*time.Millisecond)
+
+	api.mu.Lock()
+	assert.Equal(t, []string{
+		"Snoozed: Buy milk (due Thu May 9 18:00) #home",
+		`Error: task "` + taskID + `" not found`,
+		"Done: Buy milk",
+	}, api.answers)
+	assert.Equal(t, int64(0), api.offsets[0])
+	assert.Contains(t, api.offsets, int64(12), "updates are acknowledged")
+	api.mu.Unlock()
+
+	got, err = s.GetTask(ctx, taskID)
+	require.NoError(t, err)
+	assert.True(t, got.IsCompleted())
+
+	cancel()
+	assert.ErrorIs(t, <-pollDone, context.Canceled)
+	errsMu.Lock()
+	require.Len(t, errs, 1, "the failed getUpdates is reported and retried")
+	assert.Contains(t, errs[0].Error(), "getUpdates")
+	assert.NotContains(t, errs[0].Error(), telegramToken)
+	errsMu.Unlock()
+}
+
+func TestTelegram_PollStopsOnInvalidToken(t *testing.T) {
+	_, srv := newFakeBotAPI(t)
+	adapter := telegram.New("wrong", bot.New(newMemoryService()), telegram.WithBaseURL(srv.URL))
+
+	err := adapter.Poll(context.Background())
+	var apiErr *telegram.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.Code)
+}
+
+func TestTelegram_Webhook(t *testing.T) {
+	api, srv := newFakeBotAPI(t)
+	adapter := telegram.New(telegramToken, bot.New(newMemoryService()), telegram.WithBaseURL(srv.URL))
+	h := adapter.WebhookHandler("s3cret")
+
+	post := func(secret string, body []byte) int {
+		req := httptest.NewRequest(http.MethodPost, "/telegram", bytes.NewReader(body))
+		if secret != "" {
+			req.Header.Set(telegram.SecretTokenHeader, secret)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	body, err := json.Marshal(textUpdate(1, 99, "", "/newlist Casa"))
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusUnauthorized, post("", body))
+	assert.Equal(t, http.StatusUnauthorized, post("errado", body))
+	assert.Empty(t, api.sentMessages())
+	assert.Equal(t, http.StatusBadRequest, post("s3cret", []byte("{")))
+
+	assert.Equal(t, http.StatusOK, post("s3cret", body))
+	sent := api.sentMessages()
+	require.Len(t, sent, 1)
+	assert.Equal(t, int64(99), sent[0].ChatID)
+	assert.Equal(t, "Created list 1: Casa", sent[0].Text)
+}
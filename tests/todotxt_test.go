@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"botasks/internal/clock"
+	"botasks/internal/service"
+	"botasks/internal/todotxt"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.Local)
+}
+
+func TestTodoTxt_Parse(t *testing.T) {
+	now := day(2024, 5, 20)
+	tests := []struct {
+		line string
+		want todotxt.Item
+	}{
+		{
+			line: "(A) 2024-05-01 Ligar para o banco +Financeiro @telefone due:2024-05-10",
+			want: todotxt.Item{Projects: []string{"Financeiro"}},
+		},
+		{
+			line: "x 2024-05-02 2024-05-01 Lavar a louça +Casa @cozinha pri:B",
+			want: todotxt.Item{Projects: []string{"Casa"}},
+		},
+		{line: "x Comprar pão", want: todotxt.Item{}},
+		{line: "Texto com due:amanhã e + solto", want: todotxt.Item{}},
+	}
+	tests[0].want.Task.Title = "Ligar para o banco"
+	tests[0].want.Task.Priority = "A"
+	tests[0].want.Task.CreatedAt = day(2024, 5, 1)
+	tests[0].want.Task.Deadline = day(2024, 5, 10)
+	tests[0].want.Task.Tags = []string{"telefone"}
+	tests[1].want.Task.Title = "Lavar a louça"
+	tests[1].want.Task.Priority = "B"
+	tests[1].want.Task.CompletedAt = day(2024, 5, 2)
+	tests[1].want.Task.CreatedAt = day(2024, 5, 1)
+	tests[1].want.Task.Tags = []string{"cozinha"}
+	tests[2].want.Task.Title = "Comprar pão"
+	tests[2].want.Task.CompletedAt = now
+	tests[3].want.Task.Title = "Texto com due:amanhã e + solto"
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.line, func(t *testing.T) {
+			got, err := todotxt.Parse(tt.line, now)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := todotxt.Parse("(A) +Casa @cozinha", now)
+	assert.ErrorIs(t, err, todotxt.ErrNoTitle)
+}
+
+func TestTodoTxt_FormatKeepsAmbiguousTitles(t *testing.T) {
+	it := todotxt.Item{Projects: []string{"Casa Nova"}}
+	it.Task.Title = "x marca o lugar"
+	line := todotxt.Format(it)
+	assert.Equal(t, "+Casa_Nova x marca o lugar", line)
+
+	parsed, err := todotxt.Parse(line, time.Now())
+	require.NoError(t, err)
+	assert.False(t, parsed.Task.IsCompleted())
+	assert.Equal(t, "x marca o lugar", parsed.Task.Title)
+}
+
+func TestTodoTxt_ExportImportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 9, 0, 0, 0, time.Local))
+	source := newMemoryService(service.WithClock(clk))
+
+	casa, err := source.CreateTaskList(ctx, "Casa Nova")
+	require.NoError(t, err)
+	lavar, err := source.AddTask(ctx, casa, "Lavar", "", day(2024, 5, 3))
+	require.NoError(t, err)
+	require.NoError(t, source.CompleteTask(ctx, lavar))
+	trabalho, err := source.CreateTaskList(ctx, "Trabalho")
+	require.NoError(t, err)
+	require.NoError(t, source.AddTaskToList(ctx, lavar, trabalho))
+
+	var buf bytes.Buffer
+	require.NoError(t, todotxt.Export(ctx, source, &buf))
+	assert.Equal(t, "x 2024-05-01 2024-05-01 Lavar +Casa_Nova +Trabalho due:2024-05-03\n", buf.String())
+
+	target := newMemoryService(service.WithClock(clk))
+	existing, err := target.CreateTaskList(ctx, "Casa Nova")
+	require.NoError(t, err)
+	input := buf.String() + "\n(C) Sem projeto @rua\n"
+	_, err = todotxt.Import(ctx, target, strings.NewReader(input), "", clk.Now())
+	assert.Error(t, err)
+
+	// A lista padrão não existe: a tarefa com projeto também não é criada
+	_, err = todotxt.Import(ctx, target, strings.NewReader(input), "inexistente", clk.Now())
+	assert.Error(t, err)
+	lists, err := target.ListTaskLists(ctx)
+	require.NoError(t, err)
+	require.Len(t, lists, 1)
+	tasks, err := target.GetTasksByTaskList(ctx, existing)
+	require.NoError(t, err)
+	assert.Empty(t, tasks)
+
+	n, err := todotxt.Import(ctx, target, strings.NewReader(input), existing, clk.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	lists, err = target.ListTaskLists(ctx)
+	require.NoError(t, err)
+	require.Len(t, lists, 2)
+
+	tasks, err = target.GetTasksByTaskList(ctx, existing)
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	assert.Equal(t, "Lavar", tasks[0].Title)
+	assert.True(t, tasks[0].IsCompleted())
+	assert.Equal(t, day(2024, 5, 3), tasks[0].Deadline)
+	assert.Equal(t, "Sem projeto", tasks[1].Title)
+	assert.Equal(t, "C", tasks[1].Priority)
+	assert.Equal(t, []string{"rua"}, tasks[1].Tags)
+
+	buf.Reset()
+	require.NoError(t, todotxt.Export(ctx, target, &buf))
+	assert.Contains(t, buf.String(), "x 2024-05-01 2024-05-01 Lavar +Casa_Nova +Trabalho due:2024-05-03\n")
+}
+
+func FuzzTodoTxtRoundTrip(f *testing.F) {
+	for _, seed := range []string{
+		"(A) 2024-05-01 Ligar +Financeiro @telefone due:2024-05-10",
+		"x 2024-05-02 2024-05-01 Lavar +Casa pri:B",
+		"x 2024-05-02 @a 2024-05-03",
+		"due:2024-01-01 x",
+		"(B) +p 2024-01-01",
+		"pri:A (A) x",
+		"0001-01-01 0",
+	} {
+		f.Add(seed)
+	}
+	now := day(2024, 5, 20)
+	f.Fuzz(func(t *testing.T, line string) {
+		it, err := todotxt.Parse(line, now)
+		if err != nil {
+			return
+		}
+		again, err := todotxt.Parse(todotxt.Format(it), now)
+		require.NoError(t, err)
+		assert.Equal(t, it, again, "line %q formatted as %q", line, todotxt.Format(it))
+	})
+}
@@ -0,0 +1,252 @@
+package tests
+
+import (
+	"botasks/internal/cli"
+	"botasks/internal/clock"
+	"botasks/internal/event"
+	"botasks/internal/repository"
+	"botasks/internal/service"
+	"botasks/internal/undo"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newUndoService(t *testing.T) (*service.TaskListService, *undo.Manager) {
+	taskRepo := repository.NewMemoryTaskRepository()
+	taskListRepo := repository.NewMemoryTaskListRepository(taskRepo)
+	bus := event.NewBus()
+	t.Cleanup(bus.Close)
+	s := service.NewTaskListService(taskListRepo, taskRepo, service.WithUnitOfWork(taskListRepo), service.WithEventPublisher(bus))
+	m := undo.NewManager(s, 10)
+	m.Subscribe(bus)
+	return s, m
+}
+
+func TestUndo_UpdateTaskAndRedo(t *testing.T) {
+	s, m := newUndoService(t)
+	ctx := service.ContextWithActor(context.Background(), "alice")
+
+	taskListID, err := s.CreateTaskList(ctx, "Lista")
+	require.NoError(t, err)
+	taskID, err := s.AddTask(ctx, taskListID, "Original", "", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, s.UpdateTask(ctx, taskID, "Alterada", "", time.Time{}))
+
+	require.NoError(t, m.Undo(ctx))
+	stored, err := s.GetTask(ctx, taskID)
+	require.NoError(t, err)
+	assert.Equal(t, "Original", stored.Title)
+
+	require.NoError(t, m.Redo(ctx))
+	stored, err = s.GetTask(ctx, taskID)
+	require.NoError(t, err)
+	assert.Equal(t, "Alterada", stored.Title)
+}
+
+func TestUndo_DeleteListWithTasks(t *testing.T) {
+	s, m := newUndoService(t)
+	ctx := service.ContextWithActor(context.Background(), "alice")
+
+	taskListID, err := s.CreateTaskList(ctx, "Lista")
+	require.NoError(t, err)
+	for _, title := range []string{"A", "B"} {
+		_, err := s.AddTask(ctx, taskListID, title, "", time.Now().Add(time.Hour))
+		require.NoError(t, err)
+	}
+	require.NoError(t, s.DeleteTaskListAndTasks(ctx, taskListID))
+	_, err = s.GetTaskList(ctx, taskListID)
+	require.ErrorIs(t, err, repository.ErrTaskListNotFound)
+
+	// Uma única operação de undo restaura a lista e as duas tarefas
+	require.NoError(t, m.Undo(ctx))
+	tasks, err := s.GetTasksByTaskList(ctx, taskListID)
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	assert.Equal(t, "A", tasks[0].Title)
+	assert.Equal(t, "B", tasks[1].Title)
+
+	// As três operações restantes desfazem as criações
+	for i := 0; i < 3; i++ {
+		require.NoError(t, m.Undo(ctx))
+	}
+	assert.ErrorIs(t, m.Undo(ctx), undo.ErrNothingToUndo)
+	_, err = s.GetTaskList(ctx, taskListID)
+	assert.ErrorIs(t, err, repository.ErrTaskListNotFound)
+}
+
+func TestUndo_HistoryIsPerActorAndNewOperationClearsRedo(t *testing.T) {
+	s, m := newUndoService(t)
+	alice := service.ContextWithActor(context.Background(), "alice")
+	bob := service.ContextWithActor(context.Background(), "bob")
+
+	_, err := s.CreateTaskList(alice, "Da Alice")
+	require.NoError(t, err)
+	assert.ErrorIs(t, m.Undo(bob), undo.ErrNothingToUndo)
+
+	require.NoError(t, m.Undo(alice))
+	_, err = s.CreateTaskList(alice, "Outra")
+	require.NoError(t, err)
+	assert.ErrorIs(t, m.Redo(alice), undo.ErrNothingToRedo)
+}
+
+func TestUndo_ChainedUndoRedoOfSameTask(t *testing.T) {
+	s, m := newUndoService(t)
+	ctx := service.ContextWithActor(context.Background(), "alice")
+
+	taskListID, err := s.CreateTaskList(ctx, "Lista")
+	require.NoError(t, err)
+	taskID, err := s.AddTask(ctx, taskListID, "Original", "", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, s.UpdateTask(ctx, taskID, "Primeira", "", time.Time{}))
+	require.NoError(t, s.UpdateTask(ctx, taskID, "Segunda", "", time.Time{}))
+
+	require.NoError(t, m.Undo(ctx))
+	require.NoError(t, m.Undo(ctx))
+	stored, err := s.GetTask(ctx, taskID)
+	require.NoError(t, err)
+	assert.Equal(t, "Original", stored.Title)
+
+	require.NoError(t, m.Redo(ctx))
+	require.NoError(t, m.Redo(ctx))
+	stored, err = s.GetTask(ctx, taskID)
+	require.NoError(t, err)
+	assert.Equal(t, "Segunda", stored.Title)
+}
+
+func TestUndo_ConflictsWithLaterEditByAnotherActor(t *testing.T) {
+	s, m := newUndoService(t)
+	alice := service.ContextWithActor(context.Background(), "alice")
+	bob := service.ContextWithActor(context.Background(), "bob")
+
+	taskListID, err := s.CreateTaskList(alice, "Lista")
+	require.NoError(t, err)
+	taskID, err := s.AddTask(alice, taskListID, "Original", "", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, s.UpdateTask(alice, taskID, "Da Alice", "", time.Time{}))
+	require.NoError(t, s.UpdateTask(bob, taskID, "Do Bob", "", time.Time{}))
+
+	assert.ErrorIs(t, m.Undo(alice), repository.ErrConflict)
+	stored, err := s.GetTask(alice, taskID)
+	require.NoError(t, err)
+	assert.Equal(t, "Do Bob", stored.Title)
+	assert.ErrorIs(t, m.Redo(alice), undo.ErrNothingToRedo)
+
+	// Bob desfaz a sua alteração e a da Alice volta a ser a atual
+	require.NoError(t, m.Undo(bob))
+	require.NoError(t, m.Undo(alice))
+	stored, err = s.GetTask(alice, taskID)
+	require.NoError(t, err)
+	assert.Equal(t, "Original", stored.Title)
+}
+
+func TestUndo_FailedReplayIsRolledBack(t *testing.T) {
+	s, m := newUndoService(t)
+	alice := service.ContextWithActor(context.Background(), "alice")
+	bob := service.ContextWithActor(context.Background(), "bob")
+
+	taskListID, err := s.CreateTaskList(alice, "Lista")
+	require.NoError(t, err)
+	taskID, err := s.AddTask(alice, taskListID, "Lavar", "", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, s.RemoveAndDeleteTask(alice, taskID, taskListID))
+	require.NoError(t, s.DeleteTaskList(bob, taskListID))
+
+	// A tarefa seria recriada, mas a lista não existe mais
+	assert.ErrorIs(t, m.Undo(alice), repository.ErrTaskListNotFound)
+	_, err = s.GetTask(alice, taskID)
+	assert.ErrorIs(t, err, repository.ErrTaskNotFound)
+
+	// A operação continua na pilha e pode ser desfeita depois
+	require.NoError(t, m.Undo(bob))
+	require.NoError(t, m.Undo(alice))
+	tasks, err := s.GetTasksByTaskList(alice, taskListID)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "Lavar", tasks[0].Title)
+}
+
+func TestCLI_UndoRedo(t *testing.T) {
+	s, m := newUndoService(t)
+	ctx := service.ContextWithActor(context.Background(), "cli")
+	var out bytes.Buffer
+	c := cli.New(s, m, &out)
+
+	script := strings.Join([]string{
+		"newlist Casa", "add Lavar", "add Varrer", "done 1", "rmlist",
+		"undo", "tasks", "undo 2", "tasks", "redo", "tasks", "quit",
+	}, "\n")
+	require.NoError(t, c.Run(ctx, strings.NewReader(script)))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 6)
+	assert.Equal(t, "created list 1: Casa", lines[0])
+	assert.Contains(t, lines[1], "1. [x] Lavar")
+	assert.Contains(t, lines[2], "2. [ ] Varrer")
+	assert.Contains(t, lines[3], "1. [ ] Lavar")
+	assert.Contains(t, lines[4], "1. [ ] Lavar")
+	assert.Contains(t, lines[5], "2. [ ] Varrer")
+}
+
+func TestCLI_AddUsesClock(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 5, 1, 15, 0, 0, 0, time.Local))
+	s := newMemoryService(service.WithClock(clk))
+	ctx := service.ContextWithActor(context.Background(), "cli")
+	var out bytes.Buffer
+	c := cli.New(s, undo.NewManager(s, 10), &out, cli.WithClock(clk))
+
+	script := strings.Join([]string{"newlist Casa", "add Pagar luz amanhã 9h", "add Lavar", "tasks", "quit"}, "\n")
+	require.NoError(t, c.Run(ctx, strings.NewReader(script)))
+
+	assert.Contains(t, out.String(), "1. [ ] Pagar luz (due 2024-05-02 09:00)")
+	assert.Contains(t, out.String(), "2. [ ] Lavar (due 2024-05-02 15:00)")
+}
+
+func TestUndo_CreateConflictsWithLaterEditByAnotherActor(t *testing.T) {
+	s, m := newUndoService(t)
+	alice := service.ContextWithActor(context.Background(), "alice")
+	bob := service.ContextWithActor(context.Background(), "bob")
+
+	taskListID, err := s.CreateTaskList(alice, "Lista")
+	require.NoError(t, err)
+	taskID, err := s.AddTask(alice, taskListID, "Original", "", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, s.UpdateTask(bob, taskID, "Do Bob", "", time.Time{}))
+
+	assert.ErrorIs(t, m.Undo(alice), repository.ErrConflict)
+	tasks, err := s.GetTasksByTaskList(alice, taskListID)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "Do Bob", tasks[0].Title)
+}
+
+func TestUndo_DeleteTracksOrphanedSubtasks(t *testing.T) {
+	s, m := newUndoService(t)
+	alice := service.ContextWithActor(context.Background(), "alice")
+	bob := service.ContextWithActor(context.Background(), "bob")
+
+	taskListID, err := s.CreateTaskList(alice, "Lista")
+	require.NoError(t, err)
+	parentID, err := s.AddTask(alice, taskListID, "Mudança", "", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	childID, err := s.AddTask(bob, taskListID, "Caixas", "", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, s.SetTaskParent(bob, childID, parentID))
+
+	// Desfazer a criação da mãe solta a subtarefa do Bob
+	require.NoError(t, m.Undo(alice))
+	stored, err := s.GetTask(bob, childID)
+	require.NoError(t, err)
+	assert.Empty(t, stored.ParentID)
+
+	// As operações do Bob continuam desfazíveis com a nova versão
+	require.NoError(t, m.Undo(bob))
+	require.NoError(t, m.Undo(bob))
+	_, err = s.GetTask(bob, childID)
+	assert.ErrorIs(t, err, repository.ErrTaskNotFound)
+}
@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"botasks/internal/event"
+	"botasks/internal/list"
+	"botasks/internal/repository"
+	"botasks/internal/service"
+	"botasks/internal/task"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddTask_NonExistentListLeavesNoTask(t *testing.T) {
+	ctx := context.Background()
+	taskRepo := repository.NewMemoryTaskRepository()
+	taskListRepo := repository.NewMemoryTaskListRepository(taskRepo)
+	s := service.NewTaskListService(taskListRepo, taskRepo, service.WithUnitOfWork(taskListRepo))
+
+	_, err := s.AddTask(ctx, "nonexistent-id", "Tarefa", "", time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, repository.ErrTaskListNotFound)
+
+	tasks, err := taskRepo.GetTasksByIDs(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, tasks)
+}
+
+func TestJournalUnitOfWork_Rollback(t *testing.T) {
+	ctx := context.Background()
+	taskRepo := repository.NewMemoryTaskRepository()
+	taskListRepo := repository.NewMemoryTaskListRepository(taskRepo)
+
+	_, err := taskRepo.Create(ctx, task.Task{ID: "t1", Title: "Original"})
+	require.NoError(t, err)
+	_, err = taskListRepo.Create(ctx, list.TaskList{ID: "l1", Name: "Lista"})
+	require.NoError(t, err)
+	require.NoError(t, taskListRepo.AddTaskToList(ctx, "t1", "l1"))
+
+	uow, err := taskListRepo.Begin(ctx)
+	require.NoError(t, err)
+
+	stored, err := uow.Tasks().GetByID(ctx, "t1")
+	require.NoError(t, err)
+	stored.Title = "Alterada"
+	require.NoError(t, uow.Tasks().Update(ctx, *stored))
+	_, err = uow.Tasks().Create(ctx, task.Task{ID: "t2", Title: "Nova"})
+	require.NoError(t, err)
+	require.NoError(t, uow.TaskLists().AddTaskToList(ctx, "t2", "l1"))
+	require.NoError(t, uow.TaskLists().Delete(ctx, "l1"))
+
+	require.NoError(t, uow.Rollback())
+	assert.ErrorIs(t, uow.Commit(), repository.ErrUnitOfWorkDone)
+
+	restored, err := taskRepo.GetByID(ctx, "t1")
+	require.NoError(t, err)
+	assert.Equal(t, "Original", restored.Title)
+
+	_, err = taskRepo.GetByID(ctx, "t2")
+	assert.ErrorIs(t, err, repository.ErrTaskNotFound)
+
+	tasks, err := taskListRepo.GetTasksByList(ctx, "l1")
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "t1", tasks[0].ID)
+}
+
+// countingUnitOfWorkFactory conta as unidades de trabalho iniciadas.
+// failingImports falha as importações a partir da de número failAt,
+// contando de 1, para testar importações interrompidas no meio.
+type failingImports struct {
+	*service.TaskListService
+	failAt int
+	calls  int
+}
+
+func (s *failingImports) ImportTask(ctx context.Context, t task.Task, taskListIDs ...string) (string, error) {
+	s.calls++
+	if s.calls >= s.failAt {
+		return "", errors.New("disk full")
+	}
+	return s.TaskListService.ImportTask(ctx, t, taskListIDs...)
+}
+
+type countingUnitOfWorkFactory struct {
+	repository.UnitOfWorkFactory
+	begun int
+}
+
+func (f *countingUnitOfWorkFactory) Begin(ctx context.Context) (repository.UnitOfWork, error) {
+	f.begun++
+	return f.UnitOfWorkFactory.Begin(ctx)
+}
+
+func TestDeleteTaskAndTaskList_UseUnitOfWork(t *testing.T) {
+	ctx := context.Background()
+	taskRepo := repository.NewMemoryTaskRepository()
+	taskListRepo := repository.NewMemoryTaskListRepository(taskRepo)
+	uow := &countingUnitOfWorkFactory{UnitOfWorkFactory: taskListRepo}
+	s := service.NewTaskListService(taskListRepo, taskRepo, service.WithUnitOfWork(uow))
+
+	taskListID, err := s.CreateTaskList(ctx, "Lista")
+	require.NoError(t, err)
+	taskID, err := s.AddTask(ctx, taskListID, "Tarefa", "", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	begun := uow.begun
+
+	require.NoError(t, s.DeleteTask(ctx, taskID))
+	require.NoError(t, s.DeleteTaskList(ctx, taskListID))
+	assert.Equal(t, begun+2, uow.begun)
+
+	_, err = s.GetTask(ctx, taskID)
+	assert.ErrorIs(t, err, repository.ErrTaskNotFound)
+	_, err = s.GetTaskList(ctx, taskListID)
+	assert.ErrorIs(t, err, repository.ErrTaskListNotFound)
+	assert.ErrorIs(t, s.DeleteTask(ctx, taskID), repository.ErrTaskNotFound)
+}
+
+func TestWithinUnitOfWork_RollsBackAndDropsEvents(t *testing.T) {
+	ctx := context.Background()
+	bus := event.NewBus()
+	rec := &recorder{}
+	bus.Subscribe(rec.handle)
+	s := newMemoryService(service.WithEventPublisher(bus))
+
+	taskListID, err := s.CreateTaskList(ctx, "Lista")
+	require.NoError(t, err)
+	taskID, err := s.AddTask(ctx, taskListID, "Lavar", "", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	failure := errors.New("falhou")
+	err = s.WithinUnitOfWork(ctx, func(ctx context.Context) error {
+		if err := s.UpdateTask(ctx, taskID, "Alterada", "", time.Time{}); err != nil {
+			return err
+		}
+		if err := s.RemoveAndDeleteTask(ctx, taskID, taskListID); err != nil {
+			return err
+		}
+		return failure
+	})
+	assert.ErrorIs(t, err, failure)
+
+	tasks, err := s.GetTasksByTaskList(ctx, taskListID)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "Lavar", tasks[0].Title)
+	assert.Equal(t, []string{"list.created", "task.created"}, rec.names())
+
+	// Confirmada, os eventos saem em ordem depois do fim da unidade
+	require.NoError(t, s.WithinUnitOfWork(ctx, func(ctx context.Context) error {
+		return s.RemoveAndDeleteTask(ctx, taskID, taskListID)
+	}))
+	assert.Equal(t, []string{"list.created", "task.created", "list.task_removed", "task.deleted"}, rec.names())
+	_, err = s.GetTask(ctx, taskID)
+	assert.ErrorIs(t, err, repository.ErrTaskNotFound)
+}
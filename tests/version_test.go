@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"botasks/internal/httpapi"
+	"botasks/internal/repository"
+	"botasks/internal/task"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryTaskRepository_VersionConflict(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository()
+	_, err := repo.Create(ctx, task.Task{ID: "t1", Title: "Original"})
+	require.NoError(t, err)
+
+	first, err := repo.GetByID(ctx, "t1")
+	require.NoError(t, err)
+	second, err := repo.GetByID(ctx, "t1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, first.Version)
+
+	first.Title = "Primeiro"
+	require.NoError(t, repo.Update(ctx, *first))
+
+	// O segundo cliente ainda está com a versão 1
+	second.Title = "Segundo"
+	assert.ErrorIs(t, repo.Update(ctx, *second), repository.ErrConflict)
+
+	stored, err := repo.GetByID(ctx, "t1")
+	require.NoError(t, err)
+	assert.Equal(t, "Primeiro", stored.Title)
+	assert.Equal(t, 2, stored.Version)
+}
+
+func TestServiceUpdateIfMatch(t *testing.T) {
+	ctx := context.Background()
+	s := newMemoryService()
+	taskListID, err := s.CreateTaskList(ctx, "Lista")
+	require.NoError(t, err)
+	taskID, err := s.AddTask(ctx, taskListID, "Tarefa", "", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, s.UpdateTaskIfMatch(ctx, taskID, 2, "Outra", "", time.Time{}), repository.ErrConflict)
+	assert.NoError(t, s.UpdateTaskIfMatch(ctx, taskID, 1, "Outra", "", time.Time{}))
+
+	assert.ErrorIs(t, s.UpdateTaskListIfMatch(ctx, taskListID, 3, "Nova"), repository.ErrConflict)
+	assert.NoError(t, s.UpdateTaskListIfMatch(ctx, taskListID, 1, "Nova"))
+}
+
+func TestHTTPAPI_IfMatch(t *testing.T) {
+	ctx := context.Background()
+	s := newMemoryService()
+	taskListID, err := s.CreateTaskList(ctx, "Lista")
+	require.NoError(t, err)
+	taskID, err := s.AddTask(ctx, taskListID, "Tarefa", "", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	h := httpapi.NewHandler(s)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tasks/"+taskID, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	etag := rec.Header().Get("ETag")
+	assert.Equal(t, `"1"`, etag)
+
+	put := func(ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/tasks/"+taskID, strings.NewReader(`{"title":"Editada"}`))
+		req.Header.Set("If-Match", ifMatch)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec = put(etag)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+
+	// Reenviar a ETag antiga deve falhar
+	rec = put(etag)
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+	// Uma ETag malformada é erro do cliente, não uma versão diferente
+	for _, bad := range []string{"2", `"dois"`, `"0"`, `W/"2"`} {
+		rec = put(bad)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, bad)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/lists/nao-existe", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
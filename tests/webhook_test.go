@@ -0,0 +1,257 @@
+package tests
+
+import (
+	"botasks/internal/event"
+	"botasks/internal/idgen"
+	"botasks/internal/service"
+	"botasks/internal/webhook"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookReceiver é um endpoint de teste que falha as primeiras failures requisições.
+type webhookReceiver struct {
+	failures int
+	bodies   [][]byte
+	headers  []http.Header
+	mu       sync.Mutex
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	body, _ := io.ReadAll(req.Body)
+	r.bodies = append(r.bodies, body)
+	r.headers = append(r.headers, req.Header.Clone())
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func noSleep(waits *[]time.Duration) webhook.Option {
+	return webhook.WithSleep(func(_ context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		return nil
+	})
+}
+
+func TestWebhook_DeliversSignedPayload(t *testing.T) {
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	bus := event.NewBus()
+	hooks := webhook.NewService(webhook.WithIDGenerator(idgen.NewSequential("wh-")))
+	hooks.Register(webhook.Endpoint{URL: server.URL, Secret: "s3cr3t", Events: []string{"task.completed"}})
+	hooks.Subscribe(bus)
+
+	ctx := service.ContextWithActor(context.Background(), "alice")
+	s := newMemoryService(service.WithEventPublisher(bus))
+	taskListID, err := s.CreateTaskList(ctx, "CI")
+	require.NoError(t, err)
+	taskID, err := s.AddTask(ctx, taskListID, "Deploy", "", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, s.CompleteTask(ctx, taskID))
+	bus.Close()
+	hooks.Close()
+
+	require.Len(t, receiver.bodies, 1)
+	body, header := receiver.bodies[0], receiver.headers[0]
+	assert.True(t, webhook.Verify("s3cr3t", body, header.Get(webhook.SignatureHeader)))
+	assert.False(t, webhook.Verify("outro", body, header.Get(webhook.SignatureHeader)))
+	assert.Equal(t, "task.completed", header.Get(webhook.EventHeader))
+
+	var p webhook.Payload
+	require.NoError(t, json.Unmarshal(body, &p))
+	assert.Equal(t, "task.completed", p.Event)
+	assert.Equal(t, taskID, p.AggregateID)
+	assert.Equal(t, "alice", p.Actor)
+	assert.Equal(t, header.Get(webhook.DeliveryHeader), p.DeliveryID)
+
+	delivered := hooks.Deliveries(webhook.DeliveryQuery{Status: webhook.StatusDelivered})
+	require.Len(t, delivered, 1)
+	assert.Len(t, delivered[0].Attempts, 1)
+}
+
+func TestWebhook_RetriesWithExponentialBackoff(t *testing.T) {
+	receiver := &webhookReceiver{failures: 2}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	var waits []time.Duration
+	hooks := webhook.NewService(webhook.WithRetry(5, time.Second, time.Minute), noSleep(&waits))
+	hooks.Register(webhook.Endpoint{ID: "ci", URL: server.URL, Secret: "k"})
+
+	hooks.Handle(context.Background(), event.ListCreated{})
+	hooks.Close()
+
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, waits)
+	deliveries := hooks.Deliveries(webhook.DeliveryQuery{EndpointID: "ci"})
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, webhook.StatusDelivered, deliveries[0].Status)
+	require.Len(t, deliveries[0].Attempts, 3)
+	assert.Equal(t, http.StatusServiceUnavailable, deliveries[0].Attempts[0].StatusCode)
+	assert.Equal(t, http.StatusNoContent, deliveries[0].Attempts[2].StatusCode)
+}
+
+func TestWebhook_DeadLetterAndRedeliver(t *testing.T) {
+	receiver := &webhookReceiver{failures: 3}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	var waits []time.Duration
+	hooks := webhook.NewService(webhook.WithRetry(3, time.Second, 1500*time.Millisecond), noSleep(&waits))
+	hooks.Register(webhook.Endpoint{ID: "chat", URL: server.URL, Secret: "k"})
+
+	ctx := context.Background()
+	hooks.Handle(ctx, event.ListCreated{})
+	hooks.Close()
+
+	assert.Equal(t, []time.Duration{time.Second, 1500 * time.Millisecond}, waits)
+	dead, err := hooks.DeadLetters()
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, webhook.StatusDead, dead[0].Status)
+	assert.Len(t, dead[0].Attempts, 3)
+
+	require.NoError(t, hooks.Redeliver(ctx, dead[0].ID))
+	dead, err = hooks.DeadLetters()
+	require.NoError(t, err)
+	assert.Empty(t, dead)
+
+	deliveries := hooks.Deliveries(webhook.DeliveryQuery{})
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, webhook.StatusDelivered, deliveries[0].Status)
+	assert.Len(t, deliveries[0].Attempts, 4)
+	// O reenvio usa o mesmo corpo
+	assert.Equal(t, receiver.bodies[0], receiver.bodies[3])
+}
+
+func TestWebhook_DeliveryDoesNotBlockHandle(t *testing.T) {
+	receiver := &webhookReceiver{failures: 1}
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		receiver.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	hooks := webhook.NewService(noSleep(&waits))
+	hooks.Register(webhook.Endpoint{ID: "ci", URL: server.URL, Secret: "k"})
+
+	// Handle volta antes da primeira tentativa terminar
+	hooks.Handle(context.Background(), event.ListCreated{})
+	deliveries := hooks.Deliveries(webhook.DeliveryQuery{})
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, webhook.StatusPending, deliveries[0].Status)
+	assert.Empty(t, deliveries[0].Attempts)
+
+	close(release)
+	hooks.Close()
+	deliveries = hooks.Deliveries(webhook.DeliveryQuery{})
+	assert.Equal(t, webhook.StatusDelivered, deliveries[0].Status)
+	assert.Len(t, deliveries[0].Attempts, 2)
+	assert.Equal(t, []time.Duration{time.Second}, waits)
+}
+
+func TestWebhook_HandleConcurrentWithClose(t *testing.T) {
+	receiver := &webhookReceiver{failures: 100}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	hooks := webhook.NewService(webhook.WithRetry(3, time.Millisecond, time.Millisecond))
+	hooks.Register(webhook.Endpoint{ID: "ci", URL: server.URL, Secret: "k"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hooks.Handle(context.Background(), event.ListCreated{})
+		}()
+	}
+	hooks.Close()
+	wg.Wait()
+
+	// Agendadas antes ou depois do Close, todas terminam no DeadLetterStore
+	dead, err := hooks.DeadLetters()
+	require.NoError(t, err)
+	assert.Len(t, dead, 20)
+}
+
+func TestWebhook_CloseBuriesPendingRetries(t *testing.T) {
+	receiver := &webhookReceiver{failures: 1}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	hooks := webhook.NewService(webhook.WithRetry(3, time.Hour, time.Hour))
+	hooks.Register(webhook.Endpoint{ID: "ci", URL: server.URL, Secret: "k"})
+
+	hooks.Handle(context.Background(), event.ListCreated{})
+	hooks.Close()
+
+	dead, err := hooks.DeadLetters()
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Len(t, dead[0].Attempts, 1)
+}
+
+func TestWebhook_DeliveryLogIsCapped(t *testing.T) {
+	// As entregas são concorrentes: só a primeira tentativa de wh-1 falha
+	var failed sync.Once
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := http.StatusNoContent
+		if r.Header.Get(webhook.DeliveryHeader) == "wh-1" {
+			failed.Do(func() { status = http.StatusServiceUnavailable })
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	hooks := webhook.NewService(
+		webhook.WithIDGenerator(idgen.NewSequential("wh-")),
+		webhook.WithRetry(1, time.Second, time.Second),
+		webhook.WithDeliveryLogSize(2),
+		noSleep(&waits),
+	)
+	hooks.Register(webhook.Endpoint{ID: "ci", URL: server.URL, Secret: "k"})
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		hooks.Handle(ctx, event.ListCreated{})
+	}
+	hooks.Close()
+
+	deliveries := hooks.Deliveries(webhook.DeliveryQuery{})
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, "wh-2", deliveries[0].ID)
+	assert.Equal(t, "wh-3", deliveries[1].ID)
+
+	// A primeira saiu do log, mas continua no DeadLetterStore
+	dead, err := hooks.DeadLetters()
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, "wh-1", dead[0].ID)
+	require.NoError(t, hooks.Redeliver(ctx, "wh-1"))
+
+	deliveries = hooks.Deliveries(webhook.DeliveryQuery{})
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, "wh-1", deliveries[1].ID)
+	assert.Equal(t, webhook.StatusDelivered, deliveries[1].Status)
+	assert.Len(t, deliveries[1].Attempts, 2)
+}